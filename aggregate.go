@@ -0,0 +1,191 @@
+package pgmodel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Grouping is the way an Aggregation groups rows.
+type Grouping int
+
+const (
+	// GroupBy groups rows by every column.
+	GroupBy Grouping = iota
+
+	// GroupByRollup groups rows by every prefix of the columns, from all of the
+	// columns to none, giving subtotals for each level of a hierarchy such as
+	// (region, country, city) and a grand total.
+	GroupByRollup
+
+	// GroupByCube groups rows by every combination of the columns.
+	GroupByCube
+
+	// GroupBySets groups rows by each of the aggregation's sets of columns.
+	GroupBySets
+)
+
+// Aggregation describes an aggregate query on a model's table, e.g.
+//
+//	rs, _, err := pgmodel.Aggregate(new(Sale), t, pgmodel.Aggregation{
+//		Columns:  []string{"region", "product"},
+//		Grouping: pgmodel.GroupByRollup,
+//		Values:   map[string]string{"total": "sum(amount)", "sales": "count(*)"},
+//	})
+//
+// Columns and values are used as given so that they may be expressions.
+type Aggregation struct {
+
+	// The columns or expressions rows are grouped by.
+	Columns []string
+
+	// The way rows are grouped by the columns.
+	Grouping Grouping
+
+	// The sets of columns rows are grouped by with GroupBySets. Each set is a
+	// subset of the columns, and an empty set is a grand total.
+	Sets [][]string
+
+	// The aggregate expressions, such as "sum(amount)", keyed by their names.
+	Values map[string]string
+}
+
+// AggregateRow is a row of the results of an Aggregation.
+type AggregateRow struct {
+
+	// The values of the columns the row is grouped by, keyed by column. Columns
+	// that the row isn't grouped by, such as the columns rolled up in to a
+	// subtotal, are omitted so that they can be told apart from groups of NULL
+	// values.
+	Groups map[string]interface{}
+
+	// The values of the aggregate expressions keyed by their names.
+	Values map[string]interface{}
+}
+
+// MARK: Exported functions
+
+// Aggregate performs the aggregation, a, of the rows of pm's table with the
+// given executor. Options that filter rows, such as Where, apply to the rows
+// before they're grouped, and orderings, such as WithOrderBy, apply to the
+// results.
+func Aggregate(pm KeyedModel, t Executor, a Aggregation, opts ...QueryOption) ([]AggregateRow, *Result, error) {
+	if len(a.Values) == 0 {
+		return nil, nil, fmt.Errorf("pgmodel: Aggregate requires at least one value")
+	}
+
+	o := newQueryOptions(opts)
+	q, qa, err := createAggregateQuery(pm, a, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ms []map[string]interface{}
+	res, err := runContext(o.context(), OperationGetMany, pm, t, func() (orm.Result, error) {
+		return o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryContext(o.context(), &ms, q, qa...)
+		})
+	})
+	if err != nil {
+		return nil, newResult(res, q), err
+	}
+
+	// Split the rows in to their groups and values
+	rs := make([]AggregateRow, len(ms))
+	for i, m := range ms {
+		rs[i] = AggregateRow{
+			Groups: make(map[string]interface{}),
+			Values: make(map[string]interface{}),
+		}
+		for j, c := range a.Columns {
+			if g, _ := m[fmt.Sprintf("pgmodel_grouped_%d", j)].(bool); g {
+				rs[i].Groups[c] = m[fmt.Sprintf("pgmodel_group_%d", j)]
+			}
+		}
+		for n := range a.Values {
+			rs[i].Values[n] = m[n]
+		}
+	}
+	return rs, newResult(res, q), nil
+}
+
+// MARK: Non-exported functions
+
+// createAggregateQuery creates the query of the aggregation, a, of pm's rows
+// and returns it with its parameters.
+func createAggregateQuery(pm KeyedModel, a Aggregation, o *queryOptions) (string, []interface{}, error) {
+	if len(a.Columns) == 0 && a.Grouping != GroupBy {
+		return "", nil, fmt.Errorf("pgmodel: grouping requires at least one column")
+	}
+
+	// Select each group, whether the row is grouped by it, and the values
+	var sl []string
+	for i, c := range a.Columns {
+		sl = append(sl, fmt.Sprintf("%s AS %s", c, quoteIdent(fmt.Sprintf("pgmodel_group_%d", i))))
+		sl = append(sl, fmt.Sprintf("GROUPING(%s) = 0 AS %s", c, quoteIdent(fmt.Sprintf("pgmodel_grouped_%d", i))))
+	}
+	var vn []string
+	for n := range a.Values {
+		vn = append(vn, n)
+	}
+	sort.Strings(vn)
+	for _, n := range vn {
+		sl = append(sl, fmt.Sprintf("%s AS %s", a.Values[n], quoteIdent(n)))
+	}
+
+	// Create the grouping
+	var g string
+	cl := strings.Join(a.Columns, ", ")
+	switch a.Grouping {
+	case GroupBy:
+		if cl != "" {
+			g = "GROUP BY " + cl
+		}
+	case GroupByRollup:
+		g = fmt.Sprintf("GROUP BY ROLLUP (%s)", cl)
+	case GroupByCube:
+		g = fmt.Sprintf("GROUP BY CUBE (%s)", cl)
+	case GroupBySets:
+		if len(a.Sets) == 0 {
+			return "", nil, fmt.Errorf("pgmodel: GroupBySets requires at least one set")
+		}
+		cm := make(map[string]bool, len(a.Columns))
+		for _, c := range a.Columns {
+			cm[c] = true
+		}
+		var ss []string
+		for _, s := range a.Sets {
+			for _, c := range s {
+				if !cm[c] {
+					return "", nil, fmt.Errorf("pgmodel: grouping set column %s isn't one of the aggregation's columns", c)
+				}
+			}
+			ss = append(ss, "("+strings.Join(s, ", ")+")")
+		}
+		g = fmt.Sprintf("GROUP BY GROUPING SETS (%s)", strings.Join(ss, ", "))
+	default:
+		return "", nil, fmt.Errorf("pgmodel: unknown grouping %d", a.Grouping)
+	}
+
+	// Create the query
+	ps, pa, err := o.predicates(pm)
+	if err != nil {
+		return "", nil, err
+	}
+	ps = append([]string{"TRUE"}, ps...)
+	return fmt.Sprintf(
+		`SELECT %s FROM %s
+		WHERE %s
+		%s
+		%s
+		%s`,
+		strings.Join(sl, ", "),
+		o.qualifiedName(pm),
+		strings.Join(ps, " AND "),
+		g,
+		o.orderByClause(),
+		o.limitClause(),
+	), pa, nil
+}
@@ -0,0 +1,76 @@
+package pgmodel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestCreateAggregateQuery(t *testing.T) {
+	a := Aggregation{
+		Columns:  []string{"name", "id"},
+		Grouping: GroupBySets,
+		Sets:     [][]string{{"name"}, {}},
+		Values:   map[string]string{"total": "sum(id)", "count": "count(*)"},
+	}
+	q, _, err := createAggregateQuery(&testModel{}, a, new(queryOptions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT name AS "pgmodel_group_0", GROUPING(name) = 0 AS "pgmodel_grouped_0", id AS "pgmodel_group_1", GROUPING(id) = 0 AS "pgmodel_grouped_1", count(*) AS "count", sum(id) AS "total" FROM "test"."models" WHERE TRUE GROUP BY GROUPING SETS ((name), ())`
+	if q := squash(q); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+
+	for g, want := range map[Grouping]string{
+		GroupByRollup: "GROUP BY ROLLUP (name, id)",
+		GroupByCube:   "GROUP BY CUBE (name, id)",
+	} {
+		a.Grouping = g
+		if q, _, err := createAggregateQuery(&testModel{}, a, new(queryOptions)); err != nil || !strings.HasSuffix(squash(q), want) {
+			t.Errorf("got query %q and error %v, want %q", q, err, want)
+		}
+	}
+
+	// Sets must be made of the aggregation's columns
+	a.Grouping = GroupBySets
+	a.Sets = [][]string{{"tags"}}
+	if _, _, err := createAggregateQuery(&testModel{}, a, new(queryOptions)); err == nil {
+		t.Error("expected an error for a set's unknown column")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		ms := model.(*[]map[string]interface{})
+		*ms = append(*ms,
+			map[string]interface{}{"pgmodel_group_0": "a", "pgmodel_grouped_0": true, "count": 2},
+			map[string]interface{}{"pgmodel_group_0": nil, "pgmodel_grouped_0": false, "count": 3},
+		)
+		return testResult{returned: 2}, nil
+	}}
+
+	rs, _, err := Aggregate(&testModel{}, e, Aggregation{
+		Columns:  []string{"name"},
+		Grouping: GroupByRollup,
+		Values:   map[string]string{"count": "count(*)"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The grand total isn't grouped by the name
+	want := []AggregateRow{
+		{Groups: map[string]interface{}{"name": "a"}, Values: map[string]interface{}{"count": 2}},
+		{Groups: map[string]interface{}{}, Values: map[string]interface{}{"count": 3}},
+	}
+	if !reflect.DeepEqual(rs, want) {
+		t.Errorf("got rows %+v, want %+v", rs, want)
+	}
+
+	if _, _, err := Aggregate(&testModel{}, e, Aggregation{Columns: []string{"name"}}); err == nil {
+		t.Error("expected an error without values")
+	}
+}
@@ -0,0 +1,228 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-pg/pg/v10"
+)
+
+const (
+	// DefaultBackfillBatchSize is the number of rows Backfill processes per
+	// transaction when no batch size is given.
+	DefaultBackfillBatchSize = 500
+
+	// DefaultCheckpointTable is the table Backfill records its progress in when
+	// no checkpoint table is given.
+	DefaultCheckpointTable = "public.pgmodel_backfills"
+)
+
+// BackfillOptions configures Backfill.
+type BackfillOptions struct {
+
+	// The name identifying the backfill's checkpoint. Running a backfill with
+	// the name of an interrupted backfill resumes it. Required.
+	Name string
+
+	// The number of rows to process per transaction. Defaults to
+	// DefaultBackfillBatchSize.
+	BatchSize int
+
+	// The table to record checkpoints in. It is created if it doesn't exist.
+	// Defaults to DefaultCheckpointTable.
+	CheckpointTable string
+
+	// Limits the rate at which batches are processed.
+	Throttle Throttle
+
+	// If set, called after each batch with the progress of the backfill. The
+	// total is the planner's estimate of the number of rows in the table.
+	OnProgress func(Progress)
+}
+
+// MARK: Exported functions
+
+// Backfill iterates over the rows of pm's table in primary key order, calling
+// transform on each row's model and then saving it. Each batch of rows is
+// transformed, saved and checkpointed in its own transaction.
+//
+// If ctx is done, or transform or a save fails, the backfill stops after the
+// last completed batch and can be resumed by calling Backfill again with the
+// same name. Running a completed backfill again does nothing until it is
+// reset with ResetBackfill.
+func Backfill(ctx context.Context, db *pg.DB, pm PGModel, opts BackfillOptions, transform func(pm PGModel) error) error {
+	if opts.Name == "" {
+		return fmt.Errorf("pgmodel: backfills require a name")
+	}
+	size := opts.BatchSize
+	if size <= 0 {
+		size = DefaultBackfillBatchSize
+	}
+	ct := checkpointTable(opts)
+
+	// Find where to start
+	if err := createCheckpointTable(ctx, db, ct); err != nil {
+		return err
+	}
+	var cp struct {
+		LastKey *string
+		Done    bool
+	}
+	_, err := db.QueryOneContext(ctx, &cp,
+		fmt.Sprintf(`SELECT last_key, done FROM %s WHERE name = ?`, ct),
+		opts.Name,
+	)
+	if err != nil && !errors.Is(err, pg.ErrNoRows) {
+		return err
+	}
+	if cp.Done {
+		return nil
+	}
+
+	var total int64
+	if opts.OnProgress != nil {
+		q := fmt.Sprintf(`SELECT 1 FROM %s`, qualify(pm.SchemaName(), pm.TableName()))
+		if total, err = estimateRows(ctx, db, q); err != nil {
+			return err
+		}
+	}
+	pr := newProgress(opts.OnProgress, total)
+	th := newThrottler(opts.Throttle)
+
+	lastKey := cp.LastKey
+	for {
+		if err := th.wait(ctx); err != nil {
+			return err
+		}
+
+		var n int
+		err := db.RunInTransaction(ctx, func(t *pg.Tx) error {
+			var err error
+			n, lastKey, err = backfillBatch(t, pm, lastKey, size, transform)
+			if err != nil || n == 0 {
+				return err
+			}
+
+			_, err = t.Exec(fmt.Sprintf(
+				`INSERT INTO %s (name, last_key, updated_at)
+				VALUES (?, ?, now())
+				ON CONFLICT (name)
+				DO UPDATE
+				SET last_key = EXCLUDED.last_key, updated_at = EXCLUDED.updated_at`,
+				ct,
+			), opts.Name, *lastKey)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		th.done(n)
+		pr.add(n)
+
+		if n < size {
+			break
+		}
+	}
+
+	// Mark the backfill as complete
+	_, err = db.ExecContext(ctx, fmt.Sprintf(
+		`INSERT INTO %s (name, last_key, done, updated_at)
+		VALUES (?, ?, true, now())
+		ON CONFLICT (name)
+		DO UPDATE
+		SET done = true, updated_at = EXCLUDED.updated_at`,
+		ct,
+	), opts.Name, lastKey)
+	return err
+}
+
+// ResetBackfill removes the checkpoint of the named backfill so that running
+// it again starts from the beginning of the table.
+func ResetBackfill(ctx context.Context, db *pg.DB, opts BackfillOptions) error {
+	ct := checkpointTable(opts)
+	if err := createCheckpointTable(ctx, db, ct); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE name = ?`, ct), opts.Name)
+	return err
+}
+
+// MARK: Non-exported functions
+
+// backfillBatch transforms and saves up to size of pm's rows after lastKey,
+// returning the number of rows processed and the last key processed.
+func backfillBatch(t *pg.Tx, pm PGModel, lastKey *string, size int, transform func(pm PGModel) error) (int, *string, error) {
+	// Get everything once
+	pk := quoteIdent(pm.PrimaryKey())
+	qn := qualify(pm.SchemaName(), pm.TableName())
+
+	// Fetch the batch
+	p := "true"
+	var a []interface{}
+	if lastKey != nil {
+		p = fmt.Sprintf("%s > ?", pk)
+		a = append(a, *lastKey)
+	}
+	dst := reflect.New(reflect.SliceOf(reflect.TypeOf(pm)))
+	_, err := t.Query(dst.Interface(), fmt.Sprintf(
+		`SELECT * FROM %s
+		WHERE %s
+		ORDER BY %s
+		LIMIT %d`,
+		qn,
+		p,
+		pk,
+		size,
+	), a...)
+	if err != nil {
+		return 0, lastKey, err
+	}
+
+	// Transform and save the batch
+	rows := dst.Elem()
+	for i := 0; i < rows.Len(); i++ {
+		m := rows.Index(i).Interface().(PGModel)
+		if err := transform(m); err != nil {
+			return 0, lastKey, err
+		}
+		if _, err := Save(m, t); err != nil {
+			return 0, lastKey, err
+		}
+	}
+	if rows.Len() == 0 {
+		return 0, lastKey, nil
+	}
+
+	// Find the new checkpoint
+	var k string
+	last := rows.Index(rows.Len() - 1).Interface().(PGModel)
+	if _, err := t.QueryOne(pg.Scan(&k), `SELECT ?::text`, last.PrimaryKeyValue()); err != nil {
+		return 0, lastKey, err
+	}
+	return rows.Len(), &k, nil
+}
+
+// checkpointTable returns the checkpoint table of the options.
+func checkpointTable(opts BackfillOptions) string {
+	if opts.CheckpointTable != "" {
+		return opts.CheckpointTable
+	}
+	return DefaultCheckpointTable
+}
+
+// createCheckpointTable creates the checkpoint table, ct, if it doesn't exist.
+func createCheckpointTable(ctx context.Context, db *pg.DB, ct string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+			name text PRIMARY KEY,
+			last_key text,
+			done boolean NOT NULL DEFAULT false,
+			updated_at timestamptz NOT NULL DEFAULT now()
+		)`,
+		ct,
+	))
+	return err
+}
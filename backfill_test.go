@@ -0,0 +1,80 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// backfillModel is a model of the pgmodel_backfill_test.items table, which is
+// committed by the backfill tests and dropped when they finish.
+type backfillModel struct {
+	Base[backfillModel] `pgmodel:"pgmodel_backfill_test.items"`
+	ID                  int    `pg:"id,pk"`
+	Name                string `pg:"name"`
+}
+
+func TestBackfillRequiresName(t *testing.T) {
+	err := Backfill(context.Background(), unreachableDB(t), &backfillModel{}, BackfillOptions{}, func(pm PGModel) error {
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "name") {
+		t.Errorf("got %v, want an error requiring a name", err)
+	}
+}
+
+func TestBackfillResumes(t *testing.T) {
+	db := testDB(t)
+	testExec(t, db,
+		`DROP SCHEMA IF EXISTS pgmodel_backfill_test CASCADE`,
+		`CREATE SCHEMA pgmodel_backfill_test`,
+		`CREATE TABLE pgmodel_backfill_test.items (id int PRIMARY KEY, name text)`,
+		`INSERT INTO pgmodel_backfill_test.items SELECT i, 'item' FROM generate_series(1, 5) i`,
+	)
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DROP SCHEMA pgmodel_backfill_test CASCADE`)
+	})
+
+	opts := BackfillOptions{
+		Name:            "upper",
+		BatchSize:       2,
+		CheckpointTable: "pgmodel_backfill_test.backfills",
+	}
+	var seen []int
+	failAt := 4
+	transform := func(pm PGModel) error {
+		m := pm.(*backfillModel)
+		if m.ID == failAt {
+			return errors.New("transform failed")
+		}
+		seen = append(seen, m.ID)
+		m.Name = strings.ToUpper(m.Name)
+		return nil
+	}
+
+	// The batch containing the failure is rolled back
+	if err := Backfill(context.Background(), db, &backfillModel{}, opts, transform); err == nil {
+		t.Fatal("expected the transform's error")
+	}
+
+	// The backfill resumes after the last completed batch
+	failAt = 0
+	seen = nil
+	if err := Backfill(context.Background(), db, &backfillModel{}, opts, transform); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 3 || seen[0] != 3 {
+		t.Errorf("transformed %v after resuming, want [3 4 5]", seen)
+	}
+
+	var n int
+	if _, err := db.QueryOne(pg.Scan(&n), `SELECT count(*) FROM pgmodel_backfill_test.items WHERE name = 'ITEM'`); err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Errorf("got %d transformed rows, want 5", n)
+	}
+}
@@ -0,0 +1,245 @@
+package pgmodel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// Base implements the PGModel methods of the struct type T, in which it must
+// be embedded, from T's struct tags. For example,
+//
+//	type Bar struct {
+//		pgmodel.Base[Bar] `pgmodel:"foo.bars"`
+//		ID                uuid.UUID `pg:"id,pk"`
+//		Name              string    `pg:"name"`
+//		Values            []float64 `pg:"values,array"`
+//	}
+//
+// makes *Bar a PGModel for the table foo.bars.
+//
+// The table is named by the Base field's pgmodel tag, or by the pg tag of a
+// go-pg style tableName field, and is unqualified if no schema is given. Every
+// exported field is a column named by its pg or pgmodel tag, and fields tagged
+// "-" are skipped. The primary key is the field tagged with the pk option, or
+// the id column if no field is.
+//
+// The fields of embedded structs without a tagged name are flattened in to
+// the model's columns, so that groups of fields, such as created_at and
+// updated_at, can be shared between models.
+//
+// Tables and columns without names in tags are named by the strategy set with
+// SetNamingStrategy, which defaults to SnakeCase.
+type Base[T any] struct{}
+
+// baseModel describes the table and columns of a struct embedding Base.
+type baseModel struct {
+	offset  uintptr
+	schema  string
+	table   string
+	pk      string
+	pkIndex []int
+	columns []string
+	indexes [][]int
+}
+
+// baseModels caches the descriptions of the types embedding Base.
+var baseModels sync.Map
+
+// MARK: Exported functions
+
+// PrimaryKey returns the primary key column of T.
+func (b *Base[T]) PrimaryKey() string {
+	return b.model().pk
+}
+
+// PrimaryKeyValue returns the value of T's primary key field.
+func (b *Base[T]) PrimaryKeyValue() interface{} {
+	return b.value().FieldByIndex(b.model().pkIndex).Interface()
+}
+
+// SchemaName returns the schema name of T's table.
+func (b *Base[T]) SchemaName() string {
+	return b.model().schema
+}
+
+// TableName returns T's table name.
+func (b *Base[T]) TableName() string {
+	return b.model().table
+}
+
+// ColumnCount returns the number of columns in T's table.
+func (b *Base[T]) ColumnCount() int {
+	return len(b.model().columns) + 1
+}
+
+// NonPKColumns returns T's non-primary key columns.
+func (b *Base[T]) NonPKColumns() []string {
+	return append([]string(nil), b.model().columns...)
+}
+
+// NonPKValues returns the values of T's non-primary key fields.
+func (b *Base[T]) NonPKValues() []interface{} {
+	bm := b.model()
+	v := b.value()
+	vs := make([]interface{}, len(bm.indexes))
+	for i, fi := range bm.indexes {
+		vs[i] = v.FieldByIndex(fi).Interface()
+	}
+	return vs
+}
+
+// MARK: Non-exported functions
+
+// model returns the description of T.
+func (b *Base[T]) model() *baseModel {
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	if bm, ok := baseModels.Load(rt); ok {
+		return bm.(*baseModel)
+	}
+
+	bm, err := newBaseModel(rt, reflect.TypeOf(b).Elem())
+	if err != nil {
+		panic(err)
+	}
+	baseModels.Store(rt, bm)
+	return bm
+}
+
+// value returns the struct of type T that b is embedded in.
+func (b *Base[T]) value() reflect.Value {
+	p := unsafe.Add(unsafe.Pointer(b), -int(b.model().offset))
+	return reflect.NewAt(reflect.TypeOf((*T)(nil)).Elem(), p).Elem()
+}
+
+// newBaseModel describes the struct type, rt, which embeds the Base type, bt.
+func newBaseModel(rt reflect.Type, bt reflect.Type) (*baseModel, error) {
+	if rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("pgmodel: %v embeds %v but is not a struct", rt, bt)
+	}
+
+	ns := namingStrategy()
+	bm := new(baseModel)
+	found := false
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+
+		switch {
+		case f.Type == bt:
+			found = true
+			bm.offset = f.Offset
+			if tn := f.Tag.Get("pgmodel"); tn != "" {
+				bm.schema, bm.table = splitTableName(tn)
+			}
+			continue
+		case f.Name == "tableName":
+			if bm.table == "" {
+				tn, _ := tagOptions(f.Tag.Get("pg"))
+				bm.schema, bm.table = splitTableName(strings.Trim(tn, `"`))
+			}
+			continue
+		}
+		bm.addField(f, f.Index, ns)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("pgmodel: %v must be embedded directly in %v", bt, rt)
+	}
+	if bm.table == "" {
+		bm.table = ns.TableName(rt.Name())
+	}
+
+	// Default to the id column
+	if bm.pkIndex == nil {
+		for i, c := range bm.columns {
+			if c == "id" {
+				bm.pk = c
+				bm.pkIndex = bm.indexes[i]
+				bm.columns = append(bm.columns[:i:i], bm.columns[i+1:]...)
+				bm.indexes = append(bm.indexes[:i:i], bm.indexes[i+1:]...)
+				break
+			}
+		}
+	}
+	if bm.pkIndex == nil {
+		return nil, fmt.Errorf("pgmodel: %v has no primary key", rt)
+	}
+
+	return bm, nil
+}
+
+// addField adds the column of the field, f, at the index, fi, to bm, or the
+// columns of its fields if it's an embedded struct to flatten.
+func (bm *baseModel) addField(f reflect.StructField, fi []int, ns NamingStrategy) {
+	if isFlattened(f) {
+		for i := 0; i < f.Type.NumField(); i++ {
+			ef := f.Type.Field(i)
+			bm.addField(ef, append(fi[:len(fi):len(fi)], ef.Index...), ns)
+		}
+		return
+	}
+	if f.PkgPath != "" {
+		return
+	}
+
+	n, pk := fieldColumn(f, ns)
+	if n == "-" {
+		return
+	}
+	if pk && bm.pkIndex == nil {
+		bm.pk = n
+		bm.pkIndex = fi
+		return
+	}
+	bm.columns = append(bm.columns, n)
+	bm.indexes = append(bm.indexes, fi)
+}
+
+// isFlattened returns whether the field, f, is an embedded struct without a
+// tagged name, whose fields are columns of the model it's embedded in.
+func isFlattened(f reflect.StructField) bool {
+	if !f.Anonymous || f.Type.Kind() != reflect.Struct {
+		return false
+	}
+	n, _ := tagOptions(f.Tag.Get("pgmodel"))
+	if n == "" {
+		n, _ = tagOptions(f.Tag.Get("pg"))
+	}
+	return n == ""
+}
+
+// fieldColumn returns the column name of the field, f, and whether it is the
+// primary key. Fields without tagged names are named by the strategy, ns.
+func fieldColumn(f reflect.StructField, ns NamingStrategy) (string, bool) {
+	n, opts := tagOptions(f.Tag.Get("pgmodel"))
+	if n == "" && len(opts) == 0 {
+		n, opts = tagOptions(f.Tag.Get("pg"))
+	}
+	if n == "" {
+		n = ns.ColumnName(f.Name)
+	}
+
+	for _, o := range opts {
+		if o == "pk" {
+			return n, true
+		}
+	}
+	return n, false
+}
+
+// tagOptions splits a struct tag value in to its name and options.
+func tagOptions(tag string) (string, []string) {
+	ps := strings.Split(tag, ",")
+	return ps[0], ps[1:]
+}
+
+// splitTableName splits the possibly qualified table name, n, in to its schema
+// and table names.
+func splitTableName(n string) (string, string) {
+	if i := strings.LastIndex(n, "."); i >= 0 {
+		return n[:i], n[i+1:]
+	}
+	return "", n
+}
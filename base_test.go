@@ -0,0 +1,84 @@
+package pgmodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+// legacyModel is a model of the legacy_items table named with a go-pg style
+// tableName field, and whose primary key defaults to its id column.
+type legacyModel struct {
+	Base[legacyModel]
+	tableName struct{} `pg:"legacy_items"`
+
+	ItemName string
+	ID       int
+	Secret   string `pg:"-"`
+	internal int
+}
+
+func TestBase(t *testing.T) {
+	m := &testModel{ID: 7, Name: "seven", Tags: []string{"a"}}
+	if m.SchemaName() != "test" || m.TableName() != "models" || m.PrimaryKey() != "id" || m.ColumnCount() != 3 {
+		t.Errorf("got %s.%s with primary key %s and %d columns", m.SchemaName(), m.TableName(), m.PrimaryKey(), m.ColumnCount())
+	}
+	if m.PrimaryKeyValue() != 7 {
+		t.Errorf("got primary key value %v, want 7", m.PrimaryKeyValue())
+	}
+	if c := m.NonPKColumns(); !reflect.DeepEqual(c, []string{"name", "tags"}) {
+		t.Errorf("got columns %v", c)
+	}
+	if v := m.NonPKValues(); !reflect.DeepEqual(v, []interface{}{"seven", []string{"a"}}) {
+		t.Errorf("got values %v", v)
+	}
+
+	// Values are read from the model they're embedded in
+	m.Name = "eight"
+	if v := m.NonPKValues(); v[0] != "eight" {
+		t.Errorf("got value %v after changing the model, want eight", v[0])
+	}
+}
+
+func TestBaseDefaults(t *testing.T) {
+	m := &legacyModel{ItemName: "a", ID: 3}
+	if m.SchemaName() != "" || m.TableName() != "legacy_items" {
+		t.Errorf("got table %q.%q", m.SchemaName(), m.TableName())
+	}
+	if m.PrimaryKey() != "id" || m.PrimaryKeyValue() != 3 {
+		t.Errorf("got primary key %s = %v", m.PrimaryKey(), m.PrimaryKeyValue())
+	}
+
+	// Untagged fields are named by the naming strategy, and skipped and
+	// unexported fields aren't columns
+	if c := m.NonPKColumns(); !reflect.DeepEqual(c, []string{"item_name"}) {
+		t.Errorf("got columns %v", c)
+	}
+}
+
+// audit is a group of timestamp columns shared by models.
+type audit struct {
+	CreatedAt string `pg:"created_at"`
+	UpdatedAt string `pg:"updated_at"`
+}
+
+// auditedModel is a model of the test.audited table with flattened audit
+// columns.
+type auditedModel struct {
+	Base[auditedModel] `pgmodel:"test.audited"`
+	ID                 int `pg:"id,pk"`
+	audit
+	Owner audit `pg:"owner"`
+}
+
+func TestBaseFlattensEmbeddedStructs(t *testing.T) {
+	m := &auditedModel{ID: 1}
+	m.CreatedAt = "yesterday"
+	m.UpdatedAt = "today"
+
+	if c := m.NonPKColumns(); !reflect.DeepEqual(c, []string{"created_at", "updated_at", "owner"}) {
+		t.Errorf("got columns %v", c)
+	}
+	if v := m.NonPKValues(); len(v) != 3 || v[0] != "yesterday" || v[1] != "today" {
+		t.Errorf("got values %v", v)
+	}
+}
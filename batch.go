@@ -0,0 +1,477 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// DefaultChunkSize is the number of rows SaveAll inserts per statement when no
+// chunk size is given.
+const DefaultChunkSize = 1000
+
+// MaxParameters is the maximum number of parameters Postgres allows in a
+// single statement. SaveAll reduces its chunk size so that each statement's
+// rows stay within it.
+const MaxParameters = 65535
+
+// DuplicatePolicy controls how SaveAll handles models in a batch that have the
+// same primary key value.
+type DuplicatePolicy int
+
+const (
+	// AllowDuplicates performs no detection. Postgres rejects statements that
+	// affect the same row more than once, so batches containing duplicates
+	// fail.
+	AllowDuplicates DuplicatePolicy = iota
+
+	// KeepLastDuplicate saves only the last of the models with the same primary
+	// key value.
+	KeepLastDuplicate
+
+	// RejectDuplicates returns an error wrapping ErrDuplicateKey before saving
+	// anything if any models have the same primary key value.
+	RejectDuplicates
+)
+
+// BatchFailure describes a model that couldn't be saved by a batch operation.
+type BatchFailure struct {
+
+	// The model that failed to save.
+	Model PGModel
+
+	// The error returned when saving the model.
+	Err error
+}
+
+// BatchError is returned by SaveAll and SaveAllConcurrent with WithSavepoints
+// when some of the batch's models failed to save.
+type BatchError struct {
+
+	// The models that failed to save, in the order they appeared in the batch.
+	Failures []BatchFailure
+}
+
+// Error returns a description of the failures.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("pgmodel: %d models in batch failed to save, first: %v", len(e.Failures), e.Failures[0].Err)
+}
+
+// MARK: Exported functions
+
+// WithDuplicates sets how SaveAll handles models with the same primary key
+// value. The default policy is AllowDuplicates.
+func WithDuplicates(p DuplicatePolicy) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.duplicates = p
+	})
+}
+
+// WithChunkSize sets the maximum number of rows SaveAll and
+// SaveAllConcurrent write per statement. Larger chunks reduce round trips but
+// hold more row locks per statement. The default is DefaultChunkSize.
+func WithChunkSize(n int) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.chunkSize = n
+	})
+}
+
+// WithWorkers sets the number of chunks SaveAllConcurrent writes in parallel.
+// The default is 1.
+func WithWorkers(n int) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.workers = n
+	})
+}
+
+// WithSavepoints isolates failures in SaveAll and SaveAllConcurrent by writing
+// each chunk inside a savepoint. If a chunk fails, it is rolled back to its
+// savepoint and its models are retried individually, each in its own
+// savepoint, so that only the models that fail are skipped.
+//
+// The models that were skipped are reported in a *BatchError, which is
+// returned along with the result of the models that were saved. The
+// transaction can continue to be used and committed.
+func WithSavepoints() QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.savepoints = true
+	})
+}
+
+// SaveAll performs an upsert of every model in the given transaction using
+// multi-row INSERT statements of at most DefaultChunkSize rows each. All of
+// the models must belong to the same table.
+//
+// The chunk size can be changed with WithChunkSize, and is reduced for models
+// with many columns so that statements don't exceed MaxParameters. The rate
+// at which chunks are written can be limited with WithThrottle, and progress
+// can be monitored with WithProgress.
+func SaveAll(pms []PGModel, t *pg.Tx, opts ...QueryOption) (*Result, error) {
+	return SaveAllContext(context.Background(), pms, t, opts...)
+}
+
+// SaveAllContext is identical to SaveAll but performs its queries and waits on
+// its throttle with the context, ctx, so that it can be cancelled or given a
+// deadline.
+func SaveAllContext(ctx context.Context, pms []PGModel, t *pg.Tx, opts ...QueryOption) (*Result, error) {
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	pms, err := prepareBatch(pms, t, o)
+	if err != nil {
+		return nil, err
+	}
+	if len(pms) == 0 {
+		return new(Result), nil
+	}
+
+	br := new(Result)
+	var fs []BatchFailure
+	th := newThrottler(o.throttle)
+	pr := newProgress(o.progress, int64(len(pms)))
+	for _, chunk := range chunks(pms, o.chunkSizeOrDefault(pms[0])) {
+		if err := th.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		res, cfs, err := writeChunk(chunk, t, o)
+		if err != nil {
+			return nil, err
+		}
+		br.add(res)
+		fs = append(fs, cfs...)
+		th.done(len(chunk))
+		pr.add(len(chunk))
+	}
+
+	if len(fs) > 0 {
+		return br, &BatchError{Failures: fs}
+	}
+	return br, nil
+}
+
+// SaveMany performs an upsert of every model in the given transaction using
+// multi-row INSERT ... ON CONFLICT DO UPDATE statements. It is identical to
+// SaveAll, and is the batch counterpart of Save for saving many rows without a
+// round trip per row.
+func SaveMany(pms []PGModel, t *pg.Tx, opts ...QueryOption) (*Result, error) {
+	return SaveAll(pms, t, opts...)
+}
+
+// SaveAllConcurrent is identical to SaveAll but writes its chunks in parallel
+// using the number of workers set by WithWorkers, each chunk in its own
+// transaction.
+//
+// Because chunks are committed independently, an error leaves the chunks that
+// were already written committed. The first error cancels the chunks that
+// haven't started and is returned.
+func SaveAllConcurrent(ctx context.Context, db *pg.DB, pms []PGModel, opts ...QueryOption) (*Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	pms, err := prepareBatch(pms, db, o)
+	if err != nil {
+		return nil, err
+	}
+	if len(pms) == 0 {
+		return new(Result), nil
+	}
+
+	workers := o.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var mu sync.Mutex
+	br := new(Result)
+	var fs []BatchFailure
+	var ferr error
+	pr := newProgress(o.progress, int64(len(pms)))
+	fail := func(err error) {
+		mu.Lock()
+		if ferr == nil {
+			ferr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	// Start the workers
+	cs := make(chan []PGModel)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range cs {
+				var res *Result
+				var cfs []BatchFailure
+				err := db.RunInTransaction(ctx, func(t *pg.Tx) error {
+					var err error
+					res, cfs, err = writeChunk(chunk, t, o)
+					return err
+				})
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				mu.Lock()
+				br.add(res)
+				fs = append(fs, cfs...)
+				pr.add(len(chunk))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Dispatch the chunks
+	if err := dispatchChunks(ctx, cs, chunks(pms, o.chunkSizeOrDefault(pms[0])), newThrottler(o.throttle)); err != nil {
+		fail(err)
+	}
+	close(cs)
+	wg.Wait()
+
+	if ferr != nil {
+		return nil, ferr
+	}
+	if len(fs) > 0 {
+		return br, &BatchError{Failures: fs}
+	}
+	return br, nil
+}
+
+// MARK: Non-exported functions
+
+// prepareBatch validates the models of a batch, calls their BeforeSave hooks
+// with the given executor, assigns their IDs and applies the options'
+// duplicate policy.
+func prepareBatch(pms []PGModel, t Executor, o *queryOptions) ([]PGModel, error) {
+	if len(pms) == 0 {
+		return nil, nil
+	}
+
+	// Check that the models share a table
+	sn := pms[0].SchemaName()
+	tn := pms[0].TableName()
+	for _, pm := range pms[1:] {
+		if pm.SchemaName() != sn || pm.TableName() != tn {
+			return nil, fmt.Errorf("pgmodel: batch models belong to both %s.%s and %s.%s", sn, tn, pm.SchemaName(), pm.TableName())
+		}
+	}
+
+	for _, pm := range pms {
+		if err := errPartial(pm, "batch saves"); err != nil {
+			return nil, err
+		}
+		if err := beforeSave(o.context(), pm, t); err != nil {
+			return nil, err
+		}
+		if err := assignID(pm); err != nil {
+			return nil, err
+		}
+	}
+
+	return deduplicate(pms, o.duplicates)
+}
+
+// chunks splits the models in to chunks of at most n models.
+func chunks(pms []PGModel, n int) [][]PGModel {
+	var cs [][]PGModel
+	for i := 0; i < len(pms); i += n {
+		end := i + n
+		if end > len(pms) {
+			end = len(pms)
+		}
+		cs = append(cs, pms[i:end])
+	}
+	return cs
+}
+
+// dispatchChunks sends the chunks to the workers receiving from cs at the rate
+// allowed by the throttler, th, and returns ctx's error if it's done before
+// every chunk has been sent.
+func dispatchChunks(ctx context.Context, cs chan<- []PGModel, chunks [][]PGModel, th *throttler) error {
+	for _, chunk := range chunks {
+		if err := th.wait(ctx); err != nil {
+			return err
+		}
+		select {
+		case cs <- chunk:
+			th.done(len(chunk))
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// writeChunk saves the chunk in the given transaction, isolating the failures
+// of its models if the options, o, use savepoints, and calls the AfterSave
+// hooks of the models that were saved.
+//
+// Failures are only returned when using savepoints. Otherwise, the chunk's
+// error is returned.
+func writeChunk(chunk []PGModel, t *pg.Tx, o *queryOptions) (*Result, []BatchFailure, error) {
+	if !o.savepoints {
+		res, err := saveChunk(chunk, t, o)
+		if err != nil {
+			return nil, nil, err
+		}
+		return res, nil, afterSaveAll(chunk, t, o)
+	}
+
+	// Try the whole chunk first
+	var res *Result
+	err := savepoint(t, func() error {
+		var err error
+		res, err = saveChunk(chunk, t, o)
+		return err
+	})
+	if err == nil {
+		return res, nil, afterSaveAll(chunk, t, o)
+	}
+
+	// Retry the models individually to find the ones that fail
+	br := new(Result)
+	var fs []BatchFailure
+	for _, pm := range chunk {
+		err := savepoint(t, func() error {
+			var err error
+			res, err = saveChunk([]PGModel{pm}, t, o)
+			return err
+		})
+		if err != nil {
+			fs = append(fs, BatchFailure{Model: pm, Err: err})
+			continue
+		}
+		br.add(res)
+		if err := afterSave(o.context(), pm, t); err != nil {
+			return nil, nil, err
+		}
+	}
+	return br, fs, nil
+}
+
+// afterSaveAll calls the AfterSave hooks of the saved models, pms, with the
+// given transaction, stopping at the first error.
+func afterSaveAll(pms []PGModel, t *pg.Tx, o *queryOptions) error {
+	for _, pm := range pms {
+		if err := afterSave(o.context(), pm, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveChunk performs a multi-row upsert of the chunk in the given transaction,
+// applying the settings of the options, o, and removes the chunk's models from
+// their cache.
+func saveChunk(chunk []PGModel, t *pg.Tx, o *queryOptions) (*Result, error) {
+	defer func() {
+		for _, pm := range chunk {
+			InvalidateCache(pm)
+		}
+	}()
+	if o.unnest != nil {
+		return saveChunkUnnest(chunk, t, o)
+	}
+
+	// Create our inputs
+	var tv []interface{}
+	for _, pm := range chunk {
+		tv = append(tv, insertValues(pm)...)
+	}
+
+	// Perform the query
+	q := createSaveAllQuery(chunk[0], len(chunk), o)
+	res, err := runContext(o.context(), OperationSaveAll, chunk[0], t, func() (orm.Result, error) {
+		return o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryContext(o.context(), pg.Discard, q, tv...)
+		})
+	})
+	return newResult(res, q), err
+}
+
+// createSaveAllQuery creates a multi-row upsert query for n models of pm's
+// table.
+func createSaveAllQuery(pm PGModel, n int, o *queryOptions) string {
+	// Get everything once
+	qn := o.qualifiedName(pm)
+	npkc := pm.NonPKColumns()
+	c := columns(pm)
+
+	// Create arrays to join
+	im := make([]string, len(c))
+	for i, u := range c {
+		im[i] = insertValue(pm, u)
+	}
+	r := "(" + strings.Join(im, ", ") + ")"
+	rm := make([]string, n)
+	for i := range rm {
+		rm[i] = r
+	}
+	var sm []string
+	for _, u := range npkc {
+		sm = append(sm, fmt.Sprintf("%s = %s", quoteIdent(u), excludedValue(pm, o.tableName(pm), u)))
+	}
+
+	// Create the query
+	return fmt.Sprintf(
+		`INSERT INTO %s (%s) 
+		VALUES %s 
+		ON CONFLICT (%s) 
+		DO UPDATE
+		SET %s
+		%s`,
+		qn,
+		quoteList(c),
+		strings.Join(rm, ", "),
+		quoteList(primaryKeys(pm)),
+		strings.Join(sm, ", "),
+		o.scopedWhere(pm, ""),
+	)
+}
+
+// deduplicate applies the duplicate policy, p, to the models.
+func deduplicate(pms []PGModel, p DuplicatePolicy) ([]PGModel, error) {
+	if p == AllowDuplicates {
+		return pms, nil
+	}
+
+	// Find the last index of each key
+	last := make(map[interface{}]int, len(pms))
+	for i, pm := range pms {
+		k := keyOf(pm)
+		if j, ok := last[k]; ok && p == RejectDuplicates {
+			return nil, fmt.Errorf("%w: models %d and %d have the primary key value %v", ErrDuplicateKey, j, i, primaryKeyValues(pm))
+		}
+		last[k] = i
+	}
+	if len(last) == len(pms) {
+		return pms, nil
+	}
+
+	// Keep the models in the order of their last occurrences
+	var d []PGModel
+	for i, pm := range pms {
+		if last[keyOf(pm)] == i {
+			d = append(d, pm)
+		}
+	}
+	return d, nil
+}
+
+// mapKey returns v if it can be used as a map key, or its string
+// representation otherwise.
+func mapKey(v interface{}) interface{} {
+	if v == nil || reflect.TypeOf(v).Comparable() {
+		return v
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,231 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestDispatchChunksCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cs := make(chan []PGModel)
+	ch := chunks([]PGModel{&testModel{ID: 1}, &testModel{ID: 2}}, 1)
+
+	// Receive the first chunk and then stop, as if the workers were busy
+	go func() {
+		<-cs
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	err := dispatchChunks(ctx, cs, ch, newThrottler(Throttle{}))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+}
+
+func TestDispatchChunks(t *testing.T) {
+	cs := make(chan []PGModel, 3)
+	ch := chunks([]PGModel{&testModel{ID: 1}, &testModel{ID: 2}, &testModel{ID: 3}}, 2)
+	if err := dispatchChunks(context.Background(), cs, ch, newThrottler(Throttle{})); err != nil {
+		t.Fatal(err)
+	}
+	close(cs)
+
+	var n []int
+	for c := range cs {
+		n = append(n, len(c))
+	}
+	if len(n) != 2 || n[0] != 2 || n[1] != 1 {
+		t.Fatalf("got chunks of %v models, want [2 1]", n)
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	pms := []PGModel{
+		&testModel{ID: 1, Name: "a"},
+		&testModel{ID: 2, Name: "b"},
+		&testModel{ID: 1, Name: "c"},
+	}
+
+	if d, err := deduplicate(pms, AllowDuplicates); err != nil || len(d) != 3 {
+		t.Errorf("got %v, %v, want every model", d, err)
+	}
+
+	d, err := deduplicate(pms, KeepLastDuplicate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d) != 2 || d[0].(*testModel).Name != "b" || d[1].(*testModel).Name != "c" {
+		t.Errorf("got %v, want the last model of each key in order", d)
+	}
+
+	if _, err := deduplicate(pms, RejectDuplicates); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("got %v, want %v", err, ErrDuplicateKey)
+	}
+}
+
+func TestSaveAllWithSavepoints(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx, `ALTER TABLE test.models ADD CHECK (name <> 'bad')`)
+
+	bad := &testModel{ID: 2, Name: "bad"}
+	pms := []PGModel{&testModel{ID: 1, Name: "one"}, bad, &testModel{ID: 3, Name: "three"}}
+	res, err := SaveAll(pms, tx, WithSavepoints())
+
+	var be *BatchError
+	if !errors.As(err, &be) || len(be.Failures) != 1 || be.Failures[0].Model != bad {
+		t.Fatalf("got error %v, want a failure of the bad model", err)
+	}
+	if res.RowsAffected() != 2 {
+		t.Errorf("got %d rows affected, want 2", res.RowsAffected())
+	}
+
+	// The transaction can still be used
+	var n int
+	if _, err := tx.QueryOne(pg.Scan(&n), `SELECT count(*) FROM test.models`); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d rows, want 2", n)
+	}
+}
+
+// wideModel is a hand-written model of the test.wide table with many columns.
+type wideModel struct {
+	ID int
+}
+
+func (m *wideModel) SchemaName() string           { return "test" }
+func (m *wideModel) TableName() string            { return "wide" }
+func (m *wideModel) PrimaryKey() string           { return "id" }
+func (m *wideModel) PrimaryKeyValue() interface{} { return m.ID }
+func (m *wideModel) ColumnCount() int             { return 1001 }
+
+func (m *wideModel) NonPKColumns() []string {
+	cs := make([]string, 1000)
+	for i := range cs {
+		cs[i] = fmt.Sprintf("c%d", i)
+	}
+	return cs
+}
+
+func (m *wideModel) NonPKValues() []interface{} {
+	return make([]interface{}, 1000)
+}
+
+func TestChunkSizeOrDefault(t *testing.T) {
+	if n := new(queryOptions).chunkSizeOrDefault(&testModel{}); n != DefaultChunkSize {
+		t.Errorf("got chunk size %d, want %d", n, DefaultChunkSize)
+	}
+	if n := newQueryOptions([]QueryOption{WithChunkSize(10)}).chunkSizeOrDefault(&testModel{}); n != 10 {
+		t.Errorf("got chunk size %d, want 10", n)
+	}
+
+	// Wide models are limited by the number of parameters
+	if n := newQueryOptions([]QueryOption{WithChunkSize(1000)}).chunkSizeOrDefault(&wideModel{}); n != MaxParameters/1001 {
+		t.Errorf("got chunk size %d, want %d", n, MaxParameters/1001)
+	}
+}
+
+func TestChunks(t *testing.T) {
+	pms := []PGModel{&testModel{ID: 1}, &testModel{ID: 2}, &testModel{ID: 3}}
+	cs := chunks(pms, 2)
+	if len(cs) != 2 || len(cs[0]) != 2 || len(cs[1]) != 1 || cs[1][0] != pms[2] {
+		t.Errorf("got chunks %v", cs)
+	}
+}
+
+func TestCreateSaveAllQuery(t *testing.T) {
+	q := squash(createSaveAllQuery(&testModel{}, 2, new(queryOptions)))
+	if !strings.HasPrefix(q, `INSERT INTO "test"."models" ("id", "name", "tags") VALUES (`) {
+		t.Errorf("got query %q", q)
+	}
+	if n := strings.Count(q, "?"); n != 6 {
+		t.Errorf("got %d parameters in %q, want 6", n, q)
+	}
+	if !strings.Contains(q, `ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`) {
+		t.Errorf("got query %q", q)
+	}
+}
+
+func TestSaveAllChunked(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+
+	var pms []PGModel
+	for id := 1; id <= 5; id++ {
+		pms = append(pms, &testModel{ID: id, Name: "model"})
+	}
+	res, err := SaveAll(pms, tx, WithChunkSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsAffected() != 5 {
+		t.Errorf("got %d rows affected, want 5", res.RowsAffected())
+	}
+}
+
+// concurrentModel is a model of the pgmodel_concurrent_test.items table.
+type concurrentModel struct {
+	Base[concurrentModel] `pgmodel:"pgmodel_concurrent_test.items"`
+	ID                    int    `pg:"id,pk"`
+	Name                  string `pg:"name"`
+}
+
+func TestSaveAllConcurrent(t *testing.T) {
+	db := testDB(t)
+	testExec(t, db,
+		`DROP SCHEMA IF EXISTS pgmodel_concurrent_test CASCADE`,
+		`CREATE SCHEMA pgmodel_concurrent_test`,
+		`CREATE TABLE pgmodel_concurrent_test.items (id int PRIMARY KEY, name text)`,
+	)
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DROP SCHEMA pgmodel_concurrent_test CASCADE`)
+	})
+
+	var pms []PGModel
+	for id := 1; id <= 10; id++ {
+		pms = append(pms, &concurrentModel{ID: id, Name: "model"})
+	}
+	res, err := SaveAllConcurrent(context.Background(), db, pms, WithChunkSize(3), WithWorkers(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsAffected() != 10 {
+		t.Errorf("got %d rows affected, want 10", res.RowsAffected())
+	}
+
+	// Every chunk was committed
+	var n int
+	if _, err := db.QueryOne(pg.Scan(&n), `SELECT count(*) FROM pgmodel_concurrent_test.items`); err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 {
+		t.Errorf("got %d rows, want 10", n)
+	}
+}
+
+func TestSaveAllConcurrentErrors(t *testing.T) {
+	pms := []PGModel{&testModel{ID: 1}, &testModel{ID: 2}, &testModel{ID: 3}}
+	if _, err := SaveAllConcurrent(context.Background(), unreachableDB(t), pms, WithChunkSize(1), WithWorkers(2)); err == nil {
+		t.Error("expected the connection error")
+	}
+}
+
+func TestSaveAllContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The throttle's wait returns the context's error before anything is written
+	_, err := SaveAllContext(ctx, []PGModel{&testModel{ID: 1}}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,165 @@
+package pgmodel
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Bits is a bit string stored in a bit or bit varying column, such as a bitmap
+// of feature flags, e.g.
+//
+//	type Account struct {
+//		pgmodel.Base[Account] `pgmodel:"bank.accounts"`
+//		ID                    int          `pg:"id,pk"`
+//		Flags                 pgmodel.Bits `pg:"flags,type:varbit"`
+//	}
+//
+// Bit 0 is the leftmost bit of the string, which is the most significant bit
+// of its first byte. Use a *Bits field for nullable columns.
+type Bits struct {
+	n int
+	b []byte
+}
+
+// MARK: Exported functions
+
+// NewBits returns a bit string of n bits that are all unset.
+func NewBits(n int) Bits {
+	return Bits{n: n, b: make([]byte, (n+7)/8)}
+}
+
+// BitsFromBytes returns the bit string of the first n bits of b.
+func BitsFromBytes(b []byte, n int) Bits {
+	bs := NewBits(n)
+	copy(bs.b, b)
+	if r := n % 8; r != 0 && len(bs.b) > 0 {
+		bs.b[len(bs.b)-1] &= 0xff << (8 - r)
+	}
+	return bs
+}
+
+// ParseBits returns the bit string of s, which must contain only 0s and 1s,
+// such as "0101".
+func ParseBits(s string) (Bits, error) {
+	bs := NewBits(len(s))
+	for i, r := range s {
+		switch r {
+		case '0':
+		case '1':
+			bs.Set(i, true)
+		default:
+			return Bits{}, fmt.Errorf("pgmodel: invalid bit string %q", s)
+		}
+	}
+	return bs, nil
+}
+
+// Len returns the number of bits in the string.
+func (bs Bits) Len() int {
+	return bs.n
+}
+
+// Bytes returns a copy of the bits packed in to bytes. Bits after the end of
+// the string in the last byte are unset.
+func (bs Bits) Bytes() []byte {
+	return append([]byte(nil), bs.b...)
+}
+
+// Get returns whether bit i is set. Bits after the end of the string are
+// unset.
+func (bs Bits) Get(i int) bool {
+	if i < 0 || i >= bs.n {
+		return false
+	}
+	return bs.b[i/8]&(0x80>>(i%8)) != 0
+}
+
+// Set sets or unsets bit i, lengthening the string if it's too short to hold
+// bit i. Lengthening a string stored in a bit column, rather than a bit
+// varying column, causes its next save to fail.
+func (bs *Bits) Set(i int, v bool) {
+	if i < 0 {
+		return
+	}
+	if i >= bs.n {
+		bs.n = i + 1
+		for len(bs.b) < (bs.n+7)/8 {
+			bs.b = append(bs.b, 0)
+		}
+	}
+	if v {
+		bs.b[i/8] |= 0x80 >> (i % 8)
+	} else {
+		bs.b[i/8] &^= 0x80 >> (i % 8)
+	}
+}
+
+// String returns the bits as 0s and 1s, such as "0101".
+func (bs Bits) String() string {
+	var b strings.Builder
+	b.Grow(bs.n)
+	for i := 0; i < bs.n; i++ {
+		if bs.Get(i) {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+// Value returns the bits as a bit string value.
+func (bs Bits) Value() (driver.Value, error) {
+	return bs.String(), nil
+}
+
+// Scan sets bs to the bit string value, src.
+func (bs *Bits) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*bs = Bits{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("pgmodel: can't scan %T in to Bits", src)
+	}
+
+	p, err := ParseBits(s)
+	if err != nil {
+		return err
+	}
+	*bs = p
+	return nil
+}
+
+// BitSet returns a condition matching the rows whose bit string column has
+// bit i set, e.g.
+//
+//	pgmodel.GetMany[*Account](t, "", nil, pgmodel.BitSet("flags", betaFlag))
+//
+// Rows whose bit strings are too short to have bit i don't match.
+func BitSet(column string, i int) *Condition {
+	return bitCondition(column, i, "1")
+}
+
+// BitUnset returns a condition matching the rows whose bit string column has
+// bit i unset. Rows whose bit strings are too short to have bit i don't
+// match.
+func BitUnset(column string, i int) *Condition {
+	return bitCondition(column, i, "0")
+}
+
+// MARK: Non-exported functions
+
+// bitCondition returns a condition matching the rows whose bit string column
+// has the bit, v, at i.
+func bitCondition(column string, i int, v string) *Condition {
+	return columnCondition(column, func(col string) string {
+		return fmt.Sprintf("substring(%s from ? for 1) = B'%s'", col, v)
+	}, i+1)
+}
@@ -0,0 +1,75 @@
+package pgmodel
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBits(t *testing.T) {
+	bs, err := ParseBits("0100000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs.Len() != 10 || !bs.Get(1) || !bs.Get(9) || bs.Get(0) || bs.Get(10) || bs.Get(-1) {
+		t.Errorf("got bits %s", bs)
+	}
+	if b := bs.Bytes(); !bytes.Equal(b, []byte{0x40, 0x40}) {
+		t.Errorf("got bytes %x, want 4040", b)
+	}
+
+	// Setting a bit past the end lengthens the string
+	bs.Set(1, false)
+	bs.Set(12, true)
+	if s := bs.String(); s != "0000000001001" {
+		t.Errorf("got bits %s, want 0000000001001", s)
+	}
+
+	if _, err := ParseBits("012"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestBitsFromBytes(t *testing.T) {
+	bs := BitsFromBytes([]byte{0xff, 0xff}, 12)
+	if s := bs.String(); s != "111111111111" {
+		t.Errorf("got bits %s", s)
+	}
+	if b := bs.Bytes(); !bytes.Equal(b, []byte{0xff, 0xf0}) {
+		t.Errorf("got bytes %x, want fff0", b)
+	}
+}
+
+func TestBitsValueAndScan(t *testing.T) {
+	v, err := NewBits(3).Value()
+	if err != nil || v != "000" {
+		t.Fatalf("got value %v, %v", v, err)
+	}
+
+	var bs Bits
+	if err := bs.Scan([]byte("101")); err != nil || bs.String() != "101" {
+		t.Errorf("got bits %s, %v", bs, err)
+	}
+	if err := bs.Scan(nil); err != nil || bs.Len() != 0 {
+		t.Errorf("got bits %s, %v", bs, err)
+	}
+	if err := bs.Scan(5); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestBitConditions(t *testing.T) {
+	p, a, err := BitSet("flags", 3).And("id", Eq, 1).predicate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `((substring("flags" from ? for 1) = B'1') AND ("id" = ?))`; p != want {
+		t.Errorf("got predicate %q, want %q", p, want)
+	}
+	if len(a) != 2 || a[0] != 4 {
+		t.Errorf("got parameters %v, want the 1-based position 4 first", a)
+	}
+
+	if p, _, err := BitUnset("flags", 0).predicate(); err != nil || p != `(substring("flags" from ? for 1) = B'0')` {
+		t.Errorf("got predicate %q, %v", p, err)
+	}
+}
@@ -0,0 +1,443 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// cacheEntry is a cached copy of a model.
+type cacheEntry struct {
+	key    interface{}
+	model  reflect.Value
+	loaded time.Time
+	hits   int
+}
+
+// cacheCall is a read of a model from the database that's in flight.
+type cacheCall struct {
+	done  chan struct{}
+	gen   cacheGeneration
+	model reflect.Value
+	res   *Result
+	err   error
+}
+
+// cacheRead counts the reads of a model from the database that are in flight,
+// and the invalidations of the model since the first of them began.
+type cacheRead struct {
+	n   int
+	gen uint64
+}
+
+// cacheGeneration identifies the invalidations of a model, and of its cache,
+// that a read began after. A read's model is only stored if its generation
+// hasn't changed by the time the read finishes.
+type cacheGeneration struct {
+	cache uint64
+	model uint64
+}
+
+// modelCache caches the models of a single type by primary key value.
+type modelCache struct {
+	sync.Mutex
+	ttl     time.Duration
+	gen     uint64
+	entries map[interface{}]*cacheEntry
+	calls   map[interface{}]*cacheCall
+	reads   map[interface{}]*cacheRead
+}
+
+// errCachePanic is received by the calls waiting on a read of a model that
+// panicked.
+var errCachePanic = errors.New("pgmodel: the cached read being waited on panicked")
+
+// caches holds the caches enabled by EnableCache.
+var caches = struct {
+	sync.RWMutex
+	models map[reflect.Type]*modelCache
+}{
+	models: make(map[reflect.Type]*modelCache),
+}
+
+// MARK: Exported functions
+
+// EnableCache enables a read-through cache of the models of pm's type, which
+// must be a pointer to a struct, used by GetCached. Cached models expire ttl
+// after they're loaded. A ttl of zero or less disables the cache and removes
+// its models.
+//
+// The functions writing models of the type, such as Save, Delete, SaveAll and
+// CopyFrom, remove the models they write from the cache, and those that don't
+// know which models they write, such as DeleteWhere, UpdateWhere and
+// MergeTempTable, remove every model of the type. A read that's in flight
+// when its model is removed doesn't cache the model. Models written by other
+// processes remain cached until they expire or are removed with
+// InvalidateCache.
+func EnableCache(pm KeyedModel, ttl time.Duration) {
+	caches.Lock()
+	defer caches.Unlock()
+
+	rt := reflect.TypeOf(pm)
+	if ttl > 0 {
+		caches.models[rt] = &modelCache{
+			ttl:     ttl,
+			entries: make(map[interface{}]*cacheEntry),
+			calls:   make(map[interface{}]*cacheCall),
+			reads:   make(map[interface{}]*cacheRead),
+		}
+	} else {
+		delete(caches.models, rt)
+	}
+}
+
+// GetCached gets the row whose primary key value is key and scans it in to pm,
+// reading it from pm's cache if it's there, and from the executor, t, and
+// caching it otherwise. The returned result is nil if the row was read from
+// the cache.
+//
+// Concurrent calls that miss the cache for the same key share a single read
+// from the database, so a hot model that expires under load is only read once.
+// The waiting calls receive the reading call's result and error.
+//
+// Models are copied in to and out of the cache, but the copies share the
+// contents of slices, maps and pointers, which shouldn't be modified.
+func GetCached(pm KeyedModel, t Executor, key interface{}) (*Result, error) {
+	c, err := cacheOf(pm)
+	if err != nil {
+		return nil, err
+	}
+	hit, call, leader := c.join(pm, key)
+	if hit {
+		return nil, nil
+	}
+	if !leader {
+		<-call.done
+		if call.err == nil {
+			reflect.ValueOf(pm).Elem().Set(call.model.Elem())
+		}
+		return call.res, call.err
+	}
+
+	// Release the waiting calls even if the read panics
+	var res *Result
+	err = errCachePanic
+	defer func() {
+		c.finish(key, call, pm, res, err)
+	}()
+
+	res, err = Get(pm, t, quoteIdent(pm.PrimaryKey()), key)
+	if err == nil {
+		c.store(pm, time.Now(), call.gen)
+	}
+	return res, err
+}
+
+// WarmCache loads the rows whose primary key values are keys in to pm's cache
+// with a single query, so that the rows are cached ahead of the traffic that
+// reads them. Keys without rows are skipped.
+func WarmCache(pm KeyedModel, t Executor, keys []interface{}) error {
+	c, err := cacheOf(pm)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err = c.warm(context.Background(), pm, t, keys)
+	return err
+}
+
+// GetByPKsCached gets the models of type T whose primary key values are keys,
+// e.g.
+//
+//	bs, err := pgmodel.GetByPKsCached[*Bar](t, ids)
+//
+// Cached models are read from the cache and the rest are read from the
+// executor, t, with a single query and cached. The models are returned in the
+// order of keys, and keys without rows have zero models. The keys must have
+// the type of the models' primary key values to be matched to their models.
+func GetByPKsCached[T KeyedModel](t Executor, keys []interface{}) ([]T, error) {
+	m := newModel[T]()
+	c, err := cacheOf(m)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the cached models
+	ms := make([]T, len(keys))
+	var misses []interface{}
+	c.Lock()
+	for i, k := range keys {
+		u := newModel[T]()
+		if c.load(u, k) {
+			ms[i] = u
+		} else {
+			misses = append(misses, k)
+		}
+	}
+	c.Unlock()
+	if len(misses) == 0 {
+		return ms, nil
+	}
+
+	// Read and cache the rest
+	loaded, err := c.warm(context.Background(), m, t, misses)
+	if err != nil {
+		return nil, err
+	}
+	lm := make(map[interface{}]T, len(loaded))
+	for _, u := range loaded {
+		lm[mapKey(u.PrimaryKeyValue())] = u.(T)
+	}
+	for i, k := range keys {
+		if u, ok := lm[mapKey(k)]; ok {
+			ms[i] = u
+		}
+	}
+	return ms, nil
+}
+
+// RefreshCache re-reads the models in pm's cache that have been read since
+// they were loaded and would expire before the next refresh, every interval,
+// until ctx is done, and then returns ctx's error. Hot models are therefore
+// replaced before they expire, rather than expiring and being read from the
+// database by the next request for them.
+//
+// Errors refreshing the models are ignored, leaving the models to expire.
+func RefreshCache(ctx context.Context, pm KeyedModel, t Executor, interval time.Duration) error {
+	c, err := cacheOf(pm)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if keys := c.expiring(time.Now().Add(interval)); len(keys) > 0 {
+			_, _ = c.warm(ctx, pm, t, keys)
+		}
+	}
+}
+
+// InvalidateCache removes pm from its type's cache so that the next call to
+// GetCached reads it from the database.
+func InvalidateCache(pm KeyedModel) {
+	if c := cacheFor(pm); c != nil {
+		k := mapKey(pm.PrimaryKeyValue())
+		c.Lock()
+		delete(c.entries, k)
+		if r, ok := c.reads[k]; ok {
+			r.gen++
+		}
+		c.Unlock()
+	}
+}
+
+// MARK: Non-exported functions
+
+// invalidateCaches removes every model from the cache of pm's type, for
+// writes that don't know which of its models they changed.
+func invalidateCaches(pm KeyedModel) {
+	if c := cacheFor(pm); c != nil {
+		c.Lock()
+		c.entries = make(map[interface{}]*cacheEntry)
+		c.gen++
+		c.Unlock()
+	}
+}
+
+// cacheFor returns the cache of pm's type, or nil if it isn't cached.
+func cacheFor(pm KeyedModel) *modelCache {
+	caches.RLock()
+	defer caches.RUnlock()
+	return caches.models[reflect.TypeOf(pm)]
+}
+
+// cacheOf returns the cache of pm's type, or an error if it isn't cached.
+func cacheOf(pm KeyedModel) (*modelCache, error) {
+	if reflect.TypeOf(pm).Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("pgmodel: %T can't be cached as it isn't a pointer", pm)
+	}
+	c := cacheFor(pm)
+	if c == nil {
+		return nil, fmt.Errorf("pgmodel: %T has no cache", pm)
+	}
+	return c, nil
+}
+
+// load copies the cached model with the primary key value, key, in to pm, and
+// returns false if there is no such model or it has expired. The cache must be
+// locked.
+func (c *modelCache) load(pm KeyedModel, key interface{}) bool {
+	e, ok := c.entries[mapKey(key)]
+	if !ok || time.Since(e.loaded) >= c.ttl {
+		return false
+	}
+	e.hits++
+	reflect.ValueOf(pm).Elem().Set(e.model.Elem())
+	return true
+}
+
+// join copies the cached model with the primary key value, key, in to pm and
+// returns true if it's cached. Otherwise, it returns the read of the model
+// that's in flight, and whether the read was started by the caller, which must
+// then finish it.
+func (c *modelCache) join(pm KeyedModel, key interface{}) (bool, *cacheCall, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.load(pm, key) {
+		return true, nil, false
+	}
+
+	k := mapKey(key)
+	if call, ok := c.calls[k]; ok {
+		return false, call, false
+	}
+	call := &cacheCall{
+		done: make(chan struct{}),
+		gen:  c.begin(k),
+	}
+	c.calls[k] = call
+	return false, call, true
+}
+
+// begin begins a read of the model with the map key, k, and returns its
+// generation. The cache must be locked.
+func (c *modelCache) begin(k interface{}) cacheGeneration {
+	r, ok := c.reads[k]
+	if !ok {
+		r = new(cacheRead)
+		c.reads[k] = r
+	}
+	r.n++
+	return cacheGeneration{cache: c.gen, model: r.gen}
+}
+
+// end ends a read of the model with the map key, k. The cache must be locked.
+func (c *modelCache) end(k interface{}) {
+	if r, ok := c.reads[k]; ok {
+		if r.n--; r.n <= 0 {
+			delete(c.reads, k)
+		}
+	}
+}
+
+// finish completes the read, call, of the model with the primary key value,
+// key, that was read in to pm, and releases the calls waiting on it.
+func (c *modelCache) finish(key interface{}, call *cacheCall, pm KeyedModel, res *Result, err error) {
+	if err == nil {
+		call.model = reflect.New(reflect.TypeOf(pm).Elem())
+		call.model.Elem().Set(reflect.ValueOf(pm).Elem())
+	}
+	call.res = res
+	call.err = err
+
+	c.Lock()
+	delete(c.calls, mapKey(key))
+	c.end(mapKey(key))
+	c.Unlock()
+	close(call.done)
+}
+
+// store caches a copy of pm that was loaded at the time, loaded, by a read that
+// began at the generation, gen, unless pm has been invalidated since.
+func (c *modelCache) store(pm KeyedModel, loaded time.Time, gen cacheGeneration) {
+	m := reflect.New(reflect.TypeOf(pm).Elem())
+	m.Elem().Set(reflect.ValueOf(pm).Elem())
+
+	k := mapKey(pm.PrimaryKeyValue())
+	c.Lock()
+	defer c.Unlock()
+	r, ok := c.reads[k]
+	if !ok || gen != (cacheGeneration{cache: c.gen, model: r.gen}) {
+		return
+	}
+	c.entries[k] = &cacheEntry{
+		key:    pm.PrimaryKeyValue(),
+		model:  m,
+		loaded: loaded,
+	}
+}
+
+// warm loads the rows of pm's table whose primary key values are keys in to
+// the cache and returns their models.
+func (c *modelCache) warm(ctx context.Context, pm KeyedModel, t Executor, keys []interface{}) ([]KeyedModel, error) {
+	o := new(queryOptions)
+	o.ctx = ctx
+	q, a, err := createSelectQuery(pm, quoteIdent(pm.PrimaryKey())+" IN (?)", []interface{}{pg.In(keys)}, o)
+	if err != nil {
+		return nil, err
+	}
+
+	// Begin a read of each model
+	gens := make(map[interface{}]cacheGeneration, len(keys))
+	c.Lock()
+	for _, k := range keys {
+		gens[mapKey(k)] = c.begin(mapKey(k))
+	}
+	c.Unlock()
+	defer func() {
+		c.Lock()
+		for _, k := range keys {
+			c.end(mapKey(k))
+		}
+		c.Unlock()
+	}()
+
+	loaded := time.Now()
+	dst := reflect.New(reflect.SliceOf(reflect.TypeOf(pm)))
+	_, err = runContext(ctx, OperationGetMany, pm, t, func() (orm.Result, error) {
+		res, err := t.QueryContext(ctx, dst.Interface(), q, a...)
+		normalizeTimes(dst.Interface())
+		return res, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []KeyedModel
+	rv := dst.Elem()
+	for i := 0; i < rv.Len(); i++ {
+		u := rv.Index(i).Interface().(KeyedModel)
+		if gen, ok := gens[mapKey(u.PrimaryKeyValue())]; ok {
+			c.store(u, loaded, gen)
+		}
+		ms = append(ms, u)
+	}
+	return ms, nil
+}
+
+// expiring returns the primary key values of the models that have been read
+// since they were loaded and expire before the time, before. Models that have
+// already expired are removed.
+func (c *modelCache) expiring(before time.Time) []interface{} {
+	c.Lock()
+	defer c.Unlock()
+
+	var keys []interface{}
+	for k, e := range c.entries {
+		exp := e.loaded.Add(c.ttl)
+		switch {
+		case !exp.After(time.Now()):
+			delete(c.entries, k)
+		case e.hits > 0 && exp.Before(before):
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,284 @@
+package pgmodel
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// cachedModel is a model of the test.cached table.
+type cachedModel struct {
+	Base[cachedModel] `pgmodel:"test.cached"`
+	ID                int    `pg:"id,pk"`
+	Name              string `pg:"name"`
+}
+
+func TestGetCachedMissThenHit(t *testing.T) {
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		m := model.(*cachedModel)
+		m.ID = 1
+		m.Name = "one"
+		return testResult{returned: 1}, nil
+	}}
+
+	m := new(cachedModel)
+	res, err := GetCached(m, e, 1)
+	if err != nil {
+		t.Fatalf("miss: %v", err)
+	}
+	if res == nil || m.Name != "one" {
+		t.Fatalf("miss: got result %v and model %+v", res, m)
+	}
+
+	h := new(cachedModel)
+	res, err = GetCached(h, e, 1)
+	if err != nil {
+		t.Fatalf("hit: %v", err)
+	}
+	if res != nil || h.Name != "one" {
+		t.Fatalf("hit: got result %v and model %+v", res, h)
+	}
+	if n := e.count(); n != 1 {
+		t.Fatalf("got %d queries, want 1", n)
+	}
+}
+
+func TestGetCachedConcurrentMisses(t *testing.T) {
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+
+	release := make(chan struct{})
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		<-release
+		m := model.(*cachedModel)
+		m.ID = 2
+		m.Name = "two"
+		return testResult{returned: 1}, nil
+	}}
+
+	var wg sync.WaitGroup
+	ms := make([]*cachedModel, 5)
+	errs := make([]error, len(ms))
+	for i := range ms {
+		ms[i] = new(cachedModel)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = GetCached(ms[i], e, 2)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, m := range ms {
+		if errs[i] != nil || m.Name != "two" {
+			t.Errorf("call %d: got error %v and model %+v", i, errs[i], m)
+		}
+	}
+	if n := e.count(); n != 1 {
+		t.Errorf("got %d queries, want 1", n)
+	}
+}
+
+func TestGetCachedPanicReleasesWaiters(t *testing.T) {
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+
+	release := make(chan struct{})
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		<-release
+		panic("read failed")
+	}}
+
+	leader := make(chan struct{})
+	go func() {
+		defer close(leader)
+		defer func() { _ = recover() }()
+		_, _ = GetCached(new(cachedModel), e, 3)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	waiter := make(chan error)
+	go func() {
+		_, err := GetCached(new(cachedModel), e, 3)
+		waiter <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-leader
+
+	select {
+	case err := <-waiter:
+		if err == nil {
+			t.Fatal("got no error waiting on a panicked read")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter is still blocked after the read panicked")
+	}
+}
+
+func TestGetByPKsCached(t *testing.T) {
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		ms := model.(*[]*cachedModel)
+		*ms = append(*ms, &cachedModel{ID: 2, Name: "two"}, &cachedModel{ID: 1, Name: "one"})
+		return testResult{returned: 2}, nil
+	}}
+
+	// Misses are read with a single query and returned in the order of the keys
+	ms, err := GetByPKsCached[*cachedModel](e, []interface{}{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 3 || ms[0].Name != "one" || ms[1].Name != "two" || ms[2] != nil {
+		t.Fatalf("got models %v", ms)
+	}
+	if n := e.count(); n != 1 {
+		t.Fatalf("got %d queries, want 1", n)
+	}
+
+	// Cached models aren't read again
+	ms, err = GetByPKsCached[*cachedModel](e, []interface{}{2, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 2 || ms[0].Name != "two" || ms[1].Name != "one" {
+		t.Errorf("got models %v", ms)
+	}
+	if n := e.count(); n != 1 {
+		t.Errorf("got %d queries, want 1", n)
+	}
+}
+
+func TestInvalidateCaches(t *testing.T) {
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		ms := model.(*[]*cachedModel)
+		*ms = append(*ms, &cachedModel{ID: 1}, &cachedModel{ID: 2})
+		return testResult{returned: 2}, nil
+	}}
+	if err := WarmCache(&cachedModel{}, e, []interface{}{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := cacheFor(&cachedModel{})
+	if n := len(c.entries); n != 2 {
+		t.Fatalf("got %d cached models, want 2", n)
+	}
+	invalidateCaches(&cachedModel{})
+	if n := len(c.entries); n != 0 {
+		t.Fatalf("got %d cached models, want 0", n)
+	}
+}
+
+func TestGetCachedInvalidatedDuringRead(t *testing.T) {
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+
+	// The model is written while it's being read
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		m := model.(*cachedModel)
+		m.ID = 1
+		m.Name = "stale"
+		InvalidateCache(&cachedModel{ID: 1})
+		return testResult{returned: 1}, nil
+	}}
+	if _, err := GetCached(new(cachedModel), e, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stale read wasn't cached
+	c := cacheFor(&cachedModel{})
+	if n := len(c.entries); n != 0 {
+		t.Errorf("got %d cached models, want 0", n)
+	}
+	if n := len(c.reads); n != 0 {
+		t.Errorf("got %d reads in flight, want 0", n)
+	}
+}
+
+func TestWarmCacheInvalidatedDuringRead(t *testing.T) {
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+
+	// Every model is written while they're being read
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		ms := model.(*[]*cachedModel)
+		*ms = append(*ms, &cachedModel{ID: 1}, &cachedModel{ID: 2})
+		invalidateCaches(&cachedModel{})
+		return testResult{returned: 2}, nil
+	}}
+	if err := WarmCache(&cachedModel{}, e, []interface{}{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cacheFor(&cachedModel{}).entries); n != 0 {
+		t.Errorf("got %d cached models, want 0", n)
+	}
+}
+
+func TestSaveByKeyInvalidatesCache(t *testing.T) {
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		if ms, ok := model.(*[]*cachedModel); ok {
+			*ms = append(*ms, &cachedModel{ID: 1})
+		}
+		return testResult{affected: 1, returned: 1}, nil
+	}}
+	if err := WarmCache(&cachedModel{}, e, []interface{}{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveByKey(&cachedModel{ID: 1, Name: "one"}, e, "name"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cacheFor(&cachedModel{}).entries); n != 0 {
+		t.Errorf("got %d cached models, want 0", n)
+	}
+}
+
+func TestBatchWritesInvalidateCache(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.cached (id int PRIMARY KEY, name text)`,
+		`INSERT INTO test.cached VALUES (1, 'one'), (2, 'two')`,
+	)
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+	c := cacheFor(&cachedModel{})
+
+	for _, write := range []struct {
+		name string
+		fn   func() error
+	}{
+		{"SaveAll", func() error {
+			_, err := SaveAll([]PGModel{&cachedModel{ID: 1, Name: "uno"}, &cachedModel{ID: 2, Name: "dos"}}, tx)
+			return err
+		}},
+		{"CopyFrom", func() error {
+			_, err := CopyFrom([]PGModel{&cachedModel{ID: 1, Name: "one"}, &cachedModel{ID: 2, Name: "two"}}, tx)
+			return err
+		}},
+	} {
+		if err := WarmCache(&cachedModel{}, tx, []interface{}{1, 2}); err != nil {
+			t.Fatal(err)
+		}
+		if err := write.fn(); err != nil {
+			t.Fatalf("%s: %v", write.name, err)
+		}
+		if n := len(c.entries); n != 0 {
+			t.Errorf("%s: got %d cached models, want 0", write.name, n)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// MARK: Exported functions
+
+// SaveIf updates the model's existing row with the given executor only if the
+// stored value of column still equals expectedValue, and returns whether the
+// update was applied.
+//
+// Unlike Save, SaveIf never inserts a row. This makes it suitable for
+// compare-and-swap style state transitions, e.g.
+//
+//	job.State = "running"
+//	ok, _, err := pgmodel.SaveIf(job, tx, "state", "queued")
+//
+// Models implementing BeforeSaver have their hook called before the model's
+// values are read, and models implementing AfterSaver have their hook called
+// if the update was applied.
+func SaveIf(pm PGModel, t Executor, column string, expectedValue interface{}, opts ...QueryOption) (bool, *Result, error) {
+	return SaveIfContext(context.Background(), pm, t, column, expectedValue, opts...)
+}
+
+// SaveIfContext is identical to SaveIf but performs its queries with the
+// context, ctx, so that they can be cancelled or given a deadline.
+func SaveIfContext(ctx context.Context, pm PGModel, t Executor, column string, expectedValue interface{}, opts ...QueryOption) (bool, *Result, error) {
+	if err := validateColumns(pm, []string{column}); err != nil {
+		return false, nil, err
+	}
+	if err := beforeSave(ctx, pm, t); err != nil {
+		return false, nil, err
+	}
+
+	// Get everything once
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	tn := o.tableName(pm)
+	ev, _ := bindTime(pm, expectedValue)
+
+	// Create our inputs
+	tv := append(stampValues(pm, o.convertVariables(pm)), primaryKeyValues(pm)...)
+	tv = append(tv, ev)
+
+	// Perform the query
+	p := fmt.Sprintf("%s AND %s.%s = ?", keyPredicate(pm, tn), quoteIdent(tn), quoteIdent(column))
+	q := createUpdateQuery(pm, pm.NonPKColumns(), p, o)
+	defer InvalidateCache(pm)
+	res, err := runContext(o.context(), OperationSave, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return o.query(t, pm, q, tv)
+		})
+		if o.returning {
+			normalizeTimes(pm)
+		}
+		return res, err
+	})
+	if err != nil {
+		return false, nil, err
+	}
+	if res.RowsAffected() == 0 {
+		return false, newResult(res, q), nil
+	}
+	return true, newResult(res, q), afterSave(ctx, pm, t)
+}
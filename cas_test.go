@@ -0,0 +1,95 @@
+package pgmodel
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestSaveIf(t *testing.T) {
+	affected := 1
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return testResult{affected: affected}, nil
+	}}
+
+	m := &testModel{ID: 1, Name: "running"}
+	ok, _, err := SaveIf(m, e, "name", "queued")
+	if err != nil || !ok {
+		t.Fatalf("got %v, %v, want the update to be applied", ok, err)
+	}
+	q := squash(e.last().query)
+	if !strings.HasSuffix(q, `WHERE "models"."id" = ? AND "models"."name" = ?`) {
+		t.Errorf("got query %q", q)
+	}
+	if p := e.last().params; len(p) != 4 || p[2] != 1 || p[3] != "queued" {
+		t.Errorf("got parameters %v, want the key and expected value last", p)
+	}
+
+	// The update isn't applied if the stored value changed
+	affected = 0
+	if ok, _, err := SaveIf(m, e, "name", "queued"); err != nil || ok {
+		t.Errorf("got %v, %v, want the update not to be applied", ok, err)
+	}
+}
+
+func TestSaveIfContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	e := new(testExecutor)
+
+	m := &hookedModel{ID: 1, Name: " running "}
+	ok, _, err := SaveIfContext(ctx, m, e, "name", "queued", WithSchema("other"))
+	if err != nil || !ok {
+		t.Fatalf("got %v, %v, want the update to be applied", ok, err)
+	}
+	if q := e.last(); q.ctx.Value(key{}) != "request" || !strings.HasPrefix(squash(q.query), `UPDATE "other"."hooked"`) {
+		t.Errorf("got query %q performed with the wrong options or context", squash(q.query))
+	}
+	if want := []string{"BeforeSave", "AfterSave"}; !reflect.DeepEqual(m.calls, want) {
+		t.Errorf("got calls %v, want %v", m.calls, want)
+	}
+
+	// AfterSave isn't called if the update isn't applied
+	e.handle = func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return testResult{}, nil
+	}
+	m.calls = nil
+	if ok, _, err := SaveIf(m, e, "name", "queued"); err != nil || ok {
+		t.Fatalf("got %v, %v, want the update not to be applied", ok, err)
+	}
+	if want := []string{"BeforeSave"}; !reflect.DeepEqual(m.calls, want) {
+		t.Errorf("got calls %v, want %v", m.calls, want)
+	}
+}
+
+func TestSaveIfInvalidatesCache(t *testing.T) {
+	EnableCache(&cachedModel{}, time.Minute)
+	defer EnableCache(&cachedModel{}, 0)
+
+	name := "one"
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		if m, ok := model.(*cachedModel); ok && strings.HasPrefix(q, "SELECT") {
+			m.ID, m.Name = 1, name
+		}
+		return testResult{affected: 1, returned: 1}, nil
+	}}
+	if _, err := GetCached(new(cachedModel), e, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	name = "two"
+	if _, _, err := SaveIf(&cachedModel{ID: 1, Name: name}, e, "name", "one"); err != nil {
+		t.Fatal(err)
+	}
+	m := new(cachedModel)
+	if _, err := GetCached(m, e, 1); err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "two" {
+		t.Errorf("got cached name %q, want two", m.Name)
+	}
+}
@@ -0,0 +1,196 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// DefaultChangesLimit is the number of rows ChangesSince returns when no limit
+// is given.
+const DefaultChangesLimit = 1000
+
+// Watermark is a position in a table's change feed. Rows are ordered by their
+// updated time and then by their primary key, so a watermark identifies the
+// last row that was read.
+type Watermark struct {
+
+	// The updated time of the last row read.
+	Time time.Time
+
+	// The primary key value of the last row read, or nil if no rows have been
+	// read at Time.
+	Key interface{}
+}
+
+// ChangesOptions configures ChangesSince.
+type ChangesOptions struct {
+
+	// The indexed column holding each row's last updated time. Defaults to
+	// "updated_at".
+	Column string
+
+	// The watermark returned by a previous call. When set, rows after the
+	// watermark are returned and the since parameter is ignored.
+	After *Watermark
+
+	// The maximum number of rows to return. Defaults to DefaultChangesLimit.
+	Limit int
+
+	// Only rows updated at least this long ago are returned. Rows are
+	// timestamped when they are written but only become visible when their
+	// transaction commits, so a lag that exceeds the longest-running write
+	// transaction prevents rows from being committed behind the watermark.
+	Lag time.Duration
+}
+
+// MARK: Exported functions
+
+// ChangesSince gets the rows of a table updated at or after since, in order,
+// in to dst, which must be a pointer to a slice of models. It returns the
+// watermark to pass as ChangesOptions.After to get the next set of changes.
+//
+// If no rows were changed, the watermark that was passed in is returned.
+func ChangesSince(dst interface{}, t Executor, since time.Time, opts ChangesOptions, qopts ...QueryOption) (Watermark, error) {
+	return ChangesSinceContext(context.Background(), dst, t, since, opts, qopts...)
+}
+
+// ChangesSinceContext is identical to ChangesSince but performs its query with
+// the context, ctx, so that it can be cancelled or given a deadline.
+func ChangesSinceContext(ctx context.Context, dst interface{}, t Executor, since time.Time, opts ChangesOptions, qopts ...QueryOption) (Watermark, error) {
+	m, err := sliceModel(dst)
+	if err != nil {
+		return Watermark{}, err
+	}
+
+	// Apply the defaults
+	col := opts.Column
+	if col == "" {
+		col = "updated_at"
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultChangesLimit
+	}
+	w := Watermark{Time: since}
+	if opts.After != nil {
+		w = *opts.After
+	}
+
+	// Perform the query
+	o := newQueryOptions(qopts)
+	o.ctx = ctx
+	q, a := createChangesQuery(m, col, w, opts.Lag, limit, o)
+	_, err = runContext(o.context(), OperationGetMany, m, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryContext(o.context(), dst, q, a...)
+		})
+		normalizeTimes(dst)
+		return res, err
+	})
+	if err != nil {
+		return Watermark{}, err
+	}
+
+	// Find the watermark of the last row
+	rv := reflect.ValueOf(dst).Elem()
+	if rv.Len() == 0 {
+		return w, nil
+	}
+	last, ok := rv.Index(rv.Len() - 1).Interface().(PGModel)
+	if !ok {
+		last = rv.Index(rv.Len() - 1).Addr().Interface().(PGModel)
+	}
+	ut, err := timeValue(last, col)
+	if err != nil {
+		return Watermark{}, err
+	}
+	return Watermark{Time: ut, Key: last.PrimaryKeyValue()}, nil
+}
+
+// MARK: Non-exported functions
+
+// createChangesQuery creates a query for the rows of pm's table after the
+// watermark, w, and returns it with its parameters.
+func createChangesQuery(pm PGModel, col string, w Watermark, lag time.Duration, limit int, o *queryOptions) (string, []interface{}) {
+	// Get everything once
+	pk := quoteIdent(pm.PrimaryKey())
+	qn := o.qualifiedName(pm)
+	col = quoteIdent(col)
+
+	// Create the predicate
+	p := fmt.Sprintf("%s >= ?", col)
+	a := []interface{}{w.Time.UTC()}
+	if w.Key != nil {
+		p = fmt.Sprintf("(%s, %s) > (?, ?)", col, pk)
+		a = append(a, w.Key)
+	}
+	if lag > 0 {
+		p += fmt.Sprintf(" AND %s < now() - ?::interval", col)
+		a = append(a, fmt.Sprintf("%d microseconds", lag.Microseconds()))
+	}
+
+	// Create the query
+	return fmt.Sprintf(
+		`SELECT * FROM %s
+		WHERE %s
+		ORDER BY %s, %s
+		LIMIT %d`,
+		qn,
+		o.scoped(pm, p),
+		col,
+		pk,
+		limit,
+	), a
+}
+
+// sliceModel returns a new model of the element type of dst, which must be a
+// pointer to a slice of models.
+func sliceModel(dst interface{}) (PGModel, error) {
+	rt := reflect.TypeOf(dst)
+	if rt == nil || rt.Kind() != reflect.Ptr || rt.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("pgmodel: %T is not a pointer to a slice", dst)
+	}
+
+	et := rt.Elem().Elem()
+	if et.Kind() == reflect.Ptr {
+		et = et.Elem()
+	}
+	if pm, ok := reflect.New(et).Interface().(PGModel); ok {
+		return pm, nil
+	}
+	return nil, fmt.Errorf("pgmodel: %s does not implement PGModel", et)
+}
+
+// value returns pm's value for the column, c.
+func value(pm PGModel, c string) (interface{}, error) {
+	if c == pm.PrimaryKey() {
+		return pm.PrimaryKeyValue(), nil
+	}
+	for i, u := range pm.NonPKColumns() {
+		if u == c {
+			return pm.NonPKValues()[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pgmodel: %s is not a column of %s.%s", c, pm.SchemaName(), pm.TableName())
+}
+
+// timeValue returns pm's time.Time value for the column, c.
+func timeValue(pm PGModel, c string) (time.Time, error) {
+	v, err := value(pm, c)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch tv := v.(type) {
+	case time.Time:
+		return tv, nil
+	case *time.Time:
+		if tv != nil {
+			return *tv, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("pgmodel: %s.%s.%s is not a time", pm.SchemaName(), pm.TableName(), c)
+}
@@ -0,0 +1,62 @@
+package pgmodel
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateChangesQuery(t *testing.T) {
+	tm := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	q, a := createChangesQuery(&stampedModel{}, "updated_at", Watermark{Time: tm}, 0, 10, newQueryOptions(nil))
+	if want := `SELECT * FROM "test"."stamped" WHERE "updated_at" >= ? ORDER BY "updated_at", "id" LIMIT 10`; squash(q) != want {
+		t.Errorf("got query %q, want %q", squash(q), want)
+	}
+	if !reflect.DeepEqual(a, []interface{}{tm}) {
+		t.Errorf("got parameters %v", a)
+	}
+
+	q, a = createChangesQuery(&stampedModel{}, "updated_at", Watermark{Time: tm, Key: 7}, time.Second, 10, newQueryOptions(nil))
+	if want := `WHERE ("updated_at", "id") > (?, ?) AND "updated_at" < now() - ?::interval`; !reflect.DeepEqual(a, []interface{}{tm, 7, "1000000 microseconds"}) || !strings.Contains(squash(q), want) {
+		t.Errorf("got query %q with parameters %v", squash(q), a)
+	}
+}
+
+func TestChangesSinceContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	e := new(testExecutor)
+
+	var ms []*stampedModel
+	if _, err := ChangesSinceContext(ctx, &ms, e, time.Now(), ChangesOptions{}, WithSchema("other")); err != nil {
+		t.Fatal(err)
+	}
+	if q := e.last(); q.ctx.Value(key{}) != "request" || !strings.HasPrefix(squash(q.query), `SELECT * FROM "other"."stamped"`) {
+		t.Errorf("got query %q performed with the wrong options or context", squash(q.query))
+	}
+}
+
+func TestSliceModel(t *testing.T) {
+	if pm, err := sliceModel(&[]*stampedModel{}); err != nil || pm.TableName() != "stamped" {
+		t.Errorf("got %v, %v", pm, err)
+	}
+	if _, err := sliceModel([]*stampedModel{}); err == nil {
+		t.Error("expected an error for a slice that isn't a pointer")
+	}
+	if _, err := sliceModel(&[]string{}); err == nil {
+		t.Error("expected an error for a slice of non-models")
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	tm := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if v, err := timeValue(&stampedModel{UpdatedAt: tm}, "updated_at"); err != nil || !v.Equal(tm) {
+		t.Errorf("got %v, %v", v, err)
+	}
+	if _, err := timeValue(&stampedModel{}, "name"); err == nil {
+		t.Error("expected an error for a column that isn't a time")
+	}
+}
@@ -0,0 +1,145 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// CITextModel types declare which of their columns use the case-insensitive
+// citext type.
+//
+// Case-insensitive lookups and upserts on citext columns compare the columns
+// directly, letting Postgres use their indexes. Lookups and upserts on other
+// columns compare lower-cased values instead, and upserts require a unique
+// index on lower(column).
+type CITextModel interface {
+	CITextColumns() []string
+}
+
+// MARK: Exported functions
+
+// GetFold is identical to Get but compares the value of queryKey to queryValue
+// without regard to case.
+func GetFold(pm PGModel, t Executor, queryKey string, queryValue interface{}, opts ...QueryOption) (*Result, error) {
+	return GetFoldContext(context.Background(), pm, t, queryKey, queryValue, opts...)
+}
+
+// GetFoldContext is identical to GetFold but performs its queries with the
+// context, ctx, so that they can be cancelled or given a deadline.
+func GetFoldContext(ctx context.Context, pm PGModel, t Executor, queryKey string, queryValue interface{}, opts ...QueryOption) (*Result, error) {
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	q, a, err := createFoldGetQuery(pm, queryKey, queryValue, o)
+	if err != nil {
+		return nil, err
+	}
+	res, err := runContext(o.context(), OperationGet, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			res, err := o.queryOne(t, pm, q, a)
+			return o.checkRows(OperationGet, pm, t, queryKey, queryValue, res, err)
+		})
+		normalizeTimes(pm)
+		return res, err
+	})
+	if err == nil {
+		err = afterGet(ctx, pm, t)
+	}
+	return newResult(res, q), err
+}
+
+// SaveFold performs an upsert with the given executor that treats rows with
+// the same value in column, ignoring case, as conflicting. This is typically
+// used to upsert by a case-insensitive unique key such as an email address.
+//
+// Conflicting rows keep their primary key and have their other columns
+// updated. Like Save, models implementing BeforeSaver and AfterSaver have their
+// hooks called.
+func SaveFold(pm PGModel, t Executor, column string, opts ...QueryOption) (*Result, error) {
+	return SaveFoldContext(context.Background(), pm, t, column, opts...)
+}
+
+// SaveFoldContext is identical to SaveFold but performs its queries with the
+// context, ctx, so that they can be cancelled or given a deadline.
+func SaveFoldContext(ctx context.Context, pm PGModel, t Executor, column string, opts ...QueryOption) (*Result, error) {
+	if err := errPartial(pm, "SaveFold"); err != nil {
+		return nil, err
+	}
+	if err := beforeSave(ctx, pm, t); err != nil {
+		return nil, err
+	}
+	if err := assignID(pm); err != nil {
+		return nil, err
+	}
+
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	defer InvalidateCache(pm)
+	pkv := primaryKeyValues(pm)
+	npkv := stampValues(pm, convertVariables(pm))
+
+	// Create our inputs
+	tv := append(append([]interface{}{}, pkv...), npkv...)
+	if o.generatesKey(pm) {
+		tv = tv[len(pkv):]
+	}
+	tv = append(tv, npkv...)
+
+	// Perform the query
+	q := createFoldSaveQuery(pm, column, o)
+	res, err := runContext(o.context(), OperationSave, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return o.query(t, pm, q, tv)
+		})
+		if o.returning {
+			normalizeTimes(pm)
+		}
+		return res, err
+	})
+	if err == nil {
+		err = afterSave(ctx, pm, t)
+	}
+	return newResult(res, q), err
+}
+
+// MARK: Non-exported functions
+
+// createFoldGetQuery creates a get query comparing queryKey to queryValue
+// without regard to case and returns it with its parameters.
+func createFoldGetQuery(pm PGModel, queryKey string, queryValue interface{}, o *queryOptions) (string, []interface{}, error) {
+	k, err := queryKeyExpr(queryKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := fmt.Sprintf("lower(%s) = lower(?)", k)
+	if isCIText(pm, queryKey) {
+		p = fmt.Sprintf("%s = ?", k)
+	}
+	return createSelectQuery(pm, p, []interface{}{queryValue}, o)
+}
+
+// createFoldSaveQuery creates a save query resolving conflicts on column
+// without regard to case, applying the options, o.
+func createFoldSaveQuery(pm PGModel, column string, o *queryOptions) string {
+	ct := quoteIdent(column)
+	if !isCIText(pm, column) {
+		ct = fmt.Sprintf("(lower(%s))", quoteIdent(column))
+	}
+	return createUpsertQuery(pm, ct, pm.NonPKColumns(), "", o)
+}
+
+// isCIText returns whether pm declares column as a citext column.
+func isCIText(pm PGModel, column string) bool {
+	cm, ok := pm.(CITextModel)
+	if !ok {
+		return false
+	}
+	for _, c := range cm.CITextColumns() {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
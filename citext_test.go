@@ -0,0 +1,74 @@
+package pgmodel
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// foldModel is a model of the test.fold table that counts its hook calls.
+type foldModel struct {
+	Base[foldModel] `pgmodel:"test.fold"`
+	ID              int    `pg:"id,pk"`
+	Email           string `pg:"email"`
+
+	saves int
+	gets  int
+}
+
+func (m *foldModel) BeforeSave(ctx context.Context, t Executor) error {
+	m.saves++
+	return nil
+}
+
+func (m *foldModel) AfterSave(ctx context.Context, t Executor) error {
+	m.saves++
+	return nil
+}
+
+func (m *foldModel) AfterGet(ctx context.Context, t Executor) error {
+	m.gets++
+	return nil
+}
+
+func TestSaveFold(t *testing.T) {
+	m := &foldModel{ID: 1, Email: "A@example.com"}
+	e := new(testExecutor)
+	if _, err := SaveFold(m, e, "email", WithReturning()); err != nil {
+		t.Fatal(err)
+	}
+	if m.saves != 2 {
+		t.Errorf("called %d save hooks, want 2", m.saves)
+	}
+
+	q := squash(e.last().query)
+	for _, want := range []string{
+		`ON CONFLICT ((lower("email")))`,
+		`RETURNING`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query %q doesn't contain %q", q, want)
+		}
+	}
+}
+
+func TestGetFold(t *testing.T) {
+	m := &foldModel{}
+	e := new(testExecutor)
+	if _, err := GetFold(m, e, "email", "a@example.com", WithColumns("email")); err != nil {
+		t.Fatal(err)
+	}
+	if m.gets != 1 {
+		t.Errorf("called %d get hooks, want 1", m.gets)
+	}
+
+	q := squash(e.last().query)
+	for _, want := range []string{
+		`lower("email") = lower(?)`,
+		`SELECT "email" FROM`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query %q doesn't contain %q", q, want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// LSN is a position in the primary's write-ahead log, such as "0/16B3748".
+//
+// LSNs are used as read-your-writes consistency tokens: capturing the
+// primary's LSN after a write and passing it to Cluster.Reader ensures the
+// write is visible to subsequent reads.
+type LSN string
+
+// Cluster routes reads between a primary and its streaming replicas.
+type Cluster struct {
+
+	// The primary database, used for all writes.
+	Primary *pg.DB
+
+	// The replicas of the primary, used for reads.
+	Replicas []*pg.DB
+
+	// The maximum replication lag of a healthy replica. Monitor excludes
+	// replicas that lag further behind, or can't be reached, from reads until
+	// they recover. Zero allows any lag.
+	MaxLag time.Duration
+
+	// If set, called by Monitor after each check with the status of every
+	// replica.
+	OnCheck func([]ReplicaStatus)
+
+	// If set, called by Monitor after each check that finds no healthy
+	// replicas, at which point all reads are routed to the primary.
+	OnAllUnhealthy func([]ReplicaStatus)
+
+	// next is the index of the next replica to try.
+	next uint64
+
+	// unhealthy holds the replicas excluded by the last check.
+	mu        sync.RWMutex
+	unhealthy map[*pg.DB]bool
+}
+
+// ReplicaStatus describes the health of a replica at the time of a check.
+type ReplicaStatus struct {
+
+	// The replica.
+	Replica *pg.DB
+
+	// The time since the last transaction replayed by the replica, or zero if
+	// it has replayed everything it has received.
+	Lag time.Duration
+
+	// Whether the replica is used for reads.
+	Healthy bool
+
+	// The error checking the replica, if any.
+	Err error
+}
+
+// MARK: Exported functions
+
+// NewCluster returns a cluster with the primary and replicas.
+func NewCluster(primary *pg.DB, replicas ...*pg.DB) *Cluster {
+	return &Cluster{
+		Primary:  primary,
+		Replicas: replicas,
+	}
+}
+
+// WriteLSN returns the primary's current LSN. Calling it after a write's
+// transaction commits returns a token that can be passed to Reader so that
+// later reads observe the write.
+func (c *Cluster) WriteLSN(ctx context.Context) (LSN, error) {
+	var lsn string
+	if _, err := c.Primary.QueryOneContext(ctx, pg.Scan(&lsn), `SELECT pg_current_wal_lsn()::text`); err != nil {
+		return "", err
+	}
+	return LSN(lsn), nil
+}
+
+// Reader returns a database to read from that has replayed the primary's
+// write-ahead log at least as far as lsn. An empty lsn accepts any replica.
+//
+// Replicas are tried in turn, starting after the one returned by the previous
+// call, skipping those excluded by Monitor. If no replica has caught up, or a
+// replica can't be reached, the primary is returned.
+func (c *Cluster) Reader(ctx context.Context, lsn LSN) (*pg.DB, error) {
+	n := len(c.Replicas)
+	start := int(atomic.AddUint64(&c.next, 1))
+	for i := 0; i < n; i++ {
+		r := c.Replicas[(start+i)%n]
+		if !c.healthy(r) {
+			continue
+		}
+		if lsn == "" {
+			return r, nil
+		}
+
+		ok, err := replayed(ctx, r, lsn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if ok {
+			return r, nil
+		}
+	}
+	return c.Primary, nil
+}
+
+// CheckReplicas measures the lag of every replica, excludes the unhealthy
+// replicas from reads and returns their statuses.
+func (c *Cluster) CheckReplicas(ctx context.Context) []ReplicaStatus {
+	ss := make([]ReplicaStatus, len(c.Replicas))
+	unhealthy := make(map[*pg.DB]bool)
+	for i, r := range c.Replicas {
+		lag, err := replicaLag(ctx, r)
+		if err == nil && c.MaxLag > 0 && lag > c.MaxLag {
+			err = fmt.Errorf("pgmodel: replica lag of %v exceeds %v", lag, c.MaxLag)
+		}
+
+		ss[i] = ReplicaStatus{
+			Replica: r,
+			Lag:     lag,
+			Healthy: err == nil,
+			Err:     err,
+		}
+		if err != nil {
+			unhealthy[r] = true
+		}
+	}
+
+	c.mu.Lock()
+	c.unhealthy = unhealthy
+	c.mu.Unlock()
+	return ss
+}
+
+// Monitor checks the replicas with CheckReplicas every interval until ctx is
+// done, reporting the results to OnCheck and OnAllUnhealthy.
+func (c *Cluster) Monitor(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ss := c.CheckReplicas(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.OnCheck != nil {
+			c.OnCheck(ss)
+		}
+		if c.OnAllUnhealthy != nil && len(ss) > 0 && !anyHealthy(ss) {
+			c.OnAllUnhealthy(ss)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// MARK: Non-exported functions
+
+// healthy returns whether the replica, r, was healthy at the last check.
+func (c *Cluster) healthy(r *pg.DB) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.unhealthy[r]
+}
+
+// anyHealthy returns whether any of the statuses are healthy.
+func anyHealthy(ss []ReplicaStatus) bool {
+	for _, s := range ss {
+		if s.Healthy {
+			return true
+		}
+	}
+	return false
+}
+
+// replicaLag returns the time since the last transaction replayed by the
+// replica, r, or zero if it has replayed everything it has received.
+func replicaLag(ctx context.Context, r *pg.DB) (time.Duration, error) {
+	var s float64
+	_, err := r.QueryOneContext(ctx, pg.Scan(&s),
+		`SELECT CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`,
+	)
+	return time.Duration(s * float64(time.Second)), err
+}
+
+// replayed returns whether the replica, r, has replayed the write-ahead log at
+// least as far as lsn.
+func replayed(ctx context.Context, r *pg.DB, lsn LSN) (bool, error) {
+	var ok bool
+	_, err := r.QueryOneContext(ctx, pg.Scan(&ok),
+		`SELECT COALESCE(pg_last_wal_replay_lsn() >= ?::pg_lsn, false)`,
+		string(lsn),
+	)
+	return ok, err
+}
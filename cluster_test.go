@@ -0,0 +1,93 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReaderRotatesReplicas(t *testing.T) {
+	primary := unreachableDB(t)
+	a, b := unreachableDB(t), unreachableDB(t)
+	c := NewCluster(primary, a, b)
+
+	// Without a token, reads rotate between the replicas without checking them
+	first, err := c.Reader(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Reader(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || (first != a && first != b) || (second != a && second != b) {
+		t.Errorf("got readers %p and %p, want both replicas", first, second)
+	}
+}
+
+func TestReaderFallsBackToPrimary(t *testing.T) {
+	primary := unreachableDB(t)
+	c := NewCluster(primary, unreachableDB(t))
+
+	// Replicas that can't confirm they've replayed the token aren't used
+	r, err := c.Reader(context.Background(), "0/16B3748")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != primary {
+		t.Error("got a replica, want the primary")
+	}
+
+	// Without replicas, the primary is used
+	if r, err := NewCluster(primary).Reader(context.Background(), ""); err != nil || r != primary {
+		t.Errorf("got %p, %v, want the primary", r, err)
+	}
+}
+
+func TestReaderCancelled(t *testing.T) {
+	c := NewCluster(unreachableDB(t), unreachableDB(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Reader(ctx, "0/16B3748"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+}
+
+func TestCheckReplicas(t *testing.T) {
+	primary := unreachableDB(t)
+	replica := unreachableDB(t)
+	c := NewCluster(primary, replica)
+
+	ss := c.CheckReplicas(context.Background())
+	if len(ss) != 1 || ss[0].Replica != replica || ss[0].Healthy || ss[0].Err == nil {
+		t.Fatalf("got statuses %+v, want the replica to be unhealthy", ss)
+	}
+
+	// Unhealthy replicas are excluded from reads
+	if r, err := c.Reader(context.Background(), ""); err != nil || r != primary {
+		t.Errorf("got %p, %v, want the primary", r, err)
+	}
+}
+
+func TestMonitorReportsAllUnhealthy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var checks, alerts int
+	c := NewCluster(unreachableDB(t), unreachableDB(t))
+	c.OnCheck = func([]ReplicaStatus) {
+		checks++
+	}
+	c.OnAllUnhealthy = func([]ReplicaStatus) {
+		alerts++
+		cancel()
+	}
+
+	if err := c.Monitor(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if checks != 1 || alerts != 1 {
+		t.Errorf("got %d checks and %d alerts, want 1 and 1", checks, alerts)
+	}
+}
@@ -0,0 +1,459 @@
+// Command pgmodelgen generates implementations of the pgmodel.PGModel
+// interface for struct types, so that models don't need to write the
+// interface's methods by hand or derive them at run time with pgmodel.Base.
+//
+// Usage:
+//
+//	pgmodelgen -type Bar,Baz [-plural] [-prefix prefix] [-output file] [directory]
+//
+// For each type, columns are read from the struct's pg or pgmodel tags in the
+// same way as pgmodel.Base. The table is named by the pg tag of a go-pg style
+// tableName field, or is derived from the type's name if there is none.
+//
+// Embedded structs without a tagged name are flattened in to the model's
+// columns like they are by pgmodel.Base, as long as they're defined in the
+// same package. Other embedded fields are columns named by their tag or type,
+// and embedded structs of other packages must be given a column name by a tag.
+//
+// Names that aren't given by tags are derived with pgmodel.SnakeCase. The
+// -plural and -prefix flags pluralize and prefix the derived table names, like
+// pgmodel.PluralTables and pgmodel.PrefixedTables.
+//
+// The methods are written to <type>_pgmodel.go in the package's directory, or
+// to the file given by -output. It is intended to be run by go generate, e.g.
+//
+//	//go:generate pgmodelgen -type Bar
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/colinc86/pgmodel"
+)
+
+// column describes a struct field mapped to a column.
+type column struct {
+	name  string
+	field string
+	slice string
+}
+
+// model describes a struct type to generate methods for.
+type model struct {
+	name    string
+	schema  string
+	table   string
+	pk      column
+	columns []column
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("pgmodelgen: ")
+
+	types := flag.String("type", "", "comma-separated list of type names; required")
+	output := flag.String("output", "", "output file name; default <dir>/<type>_pgmodel.go")
+	pl := flag.Bool("plural", false, "pluralize derived table names")
+	prefix := flag.String("prefix", "", "prefix of derived table names")
+	flag.Parse()
+	if *types == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	ns := pgmodel.SnakeCase
+	if *pl {
+		ns = pgmodel.PluralTables(ns)
+	}
+	if *prefix != "" {
+		ns = pgmodel.PrefixedTables(*prefix, ns)
+	}
+
+	pkg, files, err := parseDir(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var ms []model
+	for _, n := range strings.Split(*types, ",") {
+		m, err := findModel(files, strings.TrimSpace(n), ns)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ms = append(ms, m)
+	}
+
+	src, err := generate(pkg, ms)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fn := *output
+	if fn == "" {
+		fn = filepath.Join(dir, pgmodel.SnakeCase.TableName(ms[0].name)+"_pgmodel.go")
+	}
+	if err := os.WriteFile(fn, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseDir parses the non-test Go files in dir and returns their package name
+// and syntax trees.
+func parseDir(dir string) (string, []*ast.File, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	for n, p := range pkgs {
+		var files []*ast.File
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+		return n, files, nil
+	}
+	return "", nil, nil
+}
+
+// findModel finds the struct type, name, in files and describes it, naming its
+// table and columns with the strategy, ns, where tags don't.
+func findModel(files []*ast.File, name string, ns pgmodel.NamingStrategy) (model, error) {
+	st, err := findStruct(files, name)
+	if err != nil {
+		return model{}, err
+	}
+	return newModel(files, name, st, ns)
+}
+
+// findStruct finds the definition of the struct type, name, in files.
+func findStruct(files []*ast.File, name string) (*ast.StructType, error) {
+	t := findType(files, name)
+	if t == nil {
+		return nil, fmt.Errorf("type %s not found", name)
+	}
+	st, ok := t.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type", name)
+	}
+	return st, nil
+}
+
+// findType finds the definition of the type, name, in files, or returns nil if
+// there is none.
+func findType(files []*ast.File, name string) ast.Expr {
+	for _, f := range files {
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gd.Specs {
+				if ts := s.(*ast.TypeSpec); ts.Name.Name == name {
+					return ts.Type
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// newModel describes the struct type, name, with the definition, st. Embedded
+// structs are looked up in files.
+func newModel(files []*ast.File, name string, st *ast.StructType, ns pgmodel.NamingStrategy) (model, error) {
+	m := model{
+		name:  name,
+		table: ns.TableName(name),
+	}
+
+	hasPK := false
+	if err := m.addFields(files, st, "", ns, &hasPK); err != nil {
+		return model{}, err
+	}
+
+	// Default to the id column
+	if !hasPK {
+		for i, c := range m.columns {
+			if c.name == "id" {
+				m.pk = c
+				m.columns = append(m.columns[:i:i], m.columns[i+1:]...)
+				hasPK = true
+				break
+			}
+		}
+	}
+	if !hasPK {
+		return model{}, fmt.Errorf("%s has no primary key", name)
+	}
+
+	return m, nil
+}
+
+// addFields adds the columns of the fields of st, accessed through the field
+// path, prefix, to m, flattening embedded structs without a tagged name.
+// hasPK is set once m's primary key has been found.
+func (m *model) addFields(files []*ast.File, st *ast.StructType, prefix string, ns pgmodel.NamingStrategy, hasPK *bool) error {
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			t, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(t)
+		}
+
+		// Flatten embedded structs of the same package, and name other
+		// embedded fields by their type
+		names := f.Names
+		if len(names) == 0 {
+			id, est, err := embeddedField(files, f.Type, tag)
+			if err != nil {
+				return fmt.Errorf("%sembedded field: %v", prefix, err)
+			}
+			if est != nil {
+				if err := m.addFields(files, est, prefix+id.Name+".", ns, hasPK); err != nil {
+					return err
+				}
+				continue
+			}
+			names = []*ast.Ident{id}
+		}
+
+		for _, fn := range names {
+			switch {
+			case fn.Name == "tableName" && prefix == "":
+				if tn, _ := tagOptions(tag.Get("pg")); tn != "" {
+					m.schema, m.table = splitTableName(strings.Trim(tn, `"`))
+				}
+				continue
+			case !fn.IsExported():
+				continue
+			}
+
+			n, pk := fieldColumn(fn.Name, tag, ns)
+			if n == "-" {
+				continue
+			}
+			c := column{
+				name:  n,
+				field: prefix + fn.Name,
+				slice: sliceElem(f.Type),
+			}
+			if pk && !*hasPK {
+				m.pk = c
+				*hasPK = true
+				continue
+			}
+			m.columns = append(m.columns, c)
+		}
+	}
+	return nil
+}
+
+// embeddedField returns the name of the embedded field of the type, t, with
+// the tag, tag, and its struct definition in files if it's to be flattened.
+func embeddedField(files []*ast.File, t ast.Expr, tag reflect.StructTag) (*ast.Ident, *ast.StructType, error) {
+	switch t := t.(type) {
+	case *ast.Ident:
+		if tagName(tag) != "" || types.Universe.Lookup(t.Name) != nil {
+			return t, nil, nil
+		}
+		et := findType(files, t.Name)
+		if et == nil {
+			return nil, nil, fmt.Errorf("type %s not found", t.Name)
+		}
+		st, _ := et.(*ast.StructType)
+		return t, st, nil
+	case *ast.SelectorExpr:
+		if tagName(tag) == "" {
+			return nil, nil, fmt.Errorf("can't flatten %s of another package; give it a column name with a pg or pgmodel tag", exprString(t))
+		}
+		return t.Sel, nil, nil
+	case *ast.StarExpr:
+		switch x := t.X.(type) {
+		case *ast.Ident:
+			return x, nil, nil
+		case *ast.SelectorExpr:
+			return x.Sel, nil, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("unsupported type %s", exprString(t))
+}
+
+// tagName returns the column name given by the tag, tag, if any.
+func tagName(tag reflect.StructTag) string {
+	n, _ := tagOptions(tag.Get("pgmodel"))
+	if n == "" {
+		n, _ = tagOptions(tag.Get("pg"))
+	}
+	return n
+}
+
+// sliceElem returns the element type of the slice type, t, or an empty string
+// if t isn't a slice.
+func sliceElem(t ast.Expr) string {
+	at, ok := t.(*ast.ArrayType)
+	if !ok || at.Len != nil {
+		return ""
+	}
+	if s := exprString(at.Elt); s != "" {
+		return s
+	}
+	return "interface{}"
+}
+
+// exprString returns the source of the expression, e, or an empty string if it
+// can't be formatted.
+func exprString(e ast.Expr) string {
+	var b bytes.Buffer
+	if err := format.Node(&b, token.NewFileSet(), e); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// generate returns the formatted source of the package, pkg, implementing the
+// PGModel methods for the models, ms.
+func generate(pkg string, ms []model) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by pgmodelgen; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+
+	// Find the imports of the ConvertSlice implementations
+	imports := map[string]bool{}
+	for _, m := range ms {
+		for _, c := range m.columns {
+			switch c.slice {
+			case "":
+			case "byte", "uint8":
+				imports["encoding/hex"] = true
+			case "string":
+				imports["strings"] = true
+			default:
+				imports["fmt"] = true
+				imports["strings"] = true
+			}
+		}
+	}
+	if len(imports) > 0 {
+		b.WriteString("import (\n")
+		for _, i := range []string{"encoding/hex", "fmt", "strings"} {
+			if imports[i] {
+				fmt.Fprintf(&b, "\t%q\n", i)
+			}
+		}
+		b.WriteString(")\n\n")
+	}
+
+	for _, m := range ms {
+		writeModel(&b, m)
+	}
+	return format.Source(b.Bytes())
+}
+
+// writeModel writes the PGModel methods of m to b.
+func writeModel(b *bytes.Buffer, m model) {
+	r := strings.ToLower(m.name[:1])
+	var cs, vs []string
+	for _, c := range m.columns {
+		cs = append(cs, strconv.Quote(c.name))
+		vs = append(vs, r+"."+c.field)
+	}
+
+	fmt.Fprintf(b, "// PrimaryKey returns the model's primary key.\n")
+	fmt.Fprintf(b, "func (%s %s) PrimaryKey() string {\n\treturn %q\n}\n\n", r, m.name, m.pk.name)
+	fmt.Fprintf(b, "// PrimaryKeyValue returns the value of the model's primary key.\n")
+	fmt.Fprintf(b, "func (%s %s) PrimaryKeyValue() interface{} {\n\treturn %s.%s\n}\n\n", r, m.name, r, m.pk.field)
+	fmt.Fprintf(b, "// SchemaName returns the name of the model's schema.\n")
+	fmt.Fprintf(b, "func (%s %s) SchemaName() string {\n\treturn %q\n}\n\n", r, m.name, m.schema)
+	fmt.Fprintf(b, "// TableName returns the name of the model's table.\n")
+	fmt.Fprintf(b, "func (%s %s) TableName() string {\n\treturn %q\n}\n\n", r, m.name, m.table)
+	fmt.Fprintf(b, "// ColumnCount returns the total number of columns in the table.\n")
+	fmt.Fprintf(b, "func (%s %s) ColumnCount() int {\n\treturn %d\n}\n\n", r, m.name, len(m.columns)+1)
+	fmt.Fprintf(b, "// NonPKColumns returns an array of non-primary key column names.\n")
+	fmt.Fprintf(b, "func (%s %s) NonPKColumns() []string {\n\treturn []string{%s}\n}\n\n", r, m.name, strings.Join(cs, ", "))
+	fmt.Fprintf(b, "// NonPKValues returns an array of non-primary key values.\n")
+	fmt.Fprintf(b, "func (%s %s) NonPKValues() []interface{} {\n\treturn []interface{}{%s}\n}\n\n", r, m.name, strings.Join(vs, ", "))
+
+	fmt.Fprintf(b, "// ConvertSlice converts the model's slice from column, c, to a string value.\n")
+	fmt.Fprintf(b, "func (%s %s) ConvertSlice(c string) string {\n\tswitch c {\n", r, m.name)
+	for _, c := range m.columns {
+		if c.slice == "" {
+			continue
+		}
+		fmt.Fprintf(b, "\tcase %q:\n", c.name)
+		switch c.slice {
+		case "byte", "uint8":
+			fmt.Fprintf(b, "\t\treturn `\\x` + hex.EncodeToString(%s.%s)\n", r, c.field)
+		case "string":
+			fmt.Fprintf(b, "\t\tvs := make([]string, len(%s.%s))\n", r, c.field)
+			fmt.Fprintf(b, "\t\tfor i, v := range %s.%s {\n", r, c.field)
+			fmt.Fprintf(b, "\t\t\tvs[i] = `\"` + strings.NewReplacer(`\\`, `\\\\`, `\"`, `\\\"`).Replace(v) + `\"`\n\t\t}\n")
+			fmt.Fprintf(b, "\t\treturn \"{\" + strings.Join(vs, \",\") + \"}\"\n")
+		default:
+			fmt.Fprintf(b, "\t\tvs := make([]string, len(%s.%s))\n", r, c.field)
+			fmt.Fprintf(b, "\t\tfor i, v := range %s.%s {\n", r, c.field)
+			fmt.Fprintf(b, "\t\t\tvs[i] = fmt.Sprint(v)\n\t\t}\n")
+			fmt.Fprintf(b, "\t\treturn \"{\" + strings.Join(vs, \",\") + \"}\"\n")
+		}
+	}
+	fmt.Fprintf(b, "\t}\n\treturn \"\"\n}\n\n")
+}
+
+// fieldColumn returns the column name of the field, n, with the tag, tag, and
+// whether it is the primary key. Fields without tagged names are named by the
+// strategy, ns.
+func fieldColumn(n string, tag reflect.StructTag, ns pgmodel.NamingStrategy) (string, bool) {
+	c, opts := tagOptions(tag.Get("pgmodel"))
+	if c == "" && len(opts) == 0 {
+		c, opts = tagOptions(tag.Get("pg"))
+	}
+	if c == "" {
+		c = ns.ColumnName(n)
+	}
+
+	for _, o := range opts {
+		if o == "pk" {
+			return c, true
+		}
+	}
+	return c, false
+}
+
+// tagOptions splits a struct tag value in to its name and options.
+func tagOptions(tag string) (string, []string) {
+	ps := strings.Split(tag, ",")
+	return ps[0], ps[1:]
+}
+
+// splitTableName splits the possibly qualified table name, n, in to its schema
+// and table names.
+func splitTableName(n string) (string, string) {
+	if i := strings.LastIndex(n, "."); i >= 0 {
+		return n[:i], n[i+1:]
+	}
+	return "", n
+}
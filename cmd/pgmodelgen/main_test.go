@@ -0,0 +1,226 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/colinc86/pgmodel"
+)
+
+func parseSource(t *testing.T, src string) []*ast.File {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "models.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []*ast.File{f}
+}
+
+func TestFindModelEmbeddedFields(t *testing.T) {
+	files := parseSource(t, `package models
+
+import "time"
+
+type Meta struct {
+	Note string
+}
+
+type Audit struct {
+	By string
+}
+
+type Bar struct {
+	tableName struct{} `+"`pg:\"app.bars\"`"+`
+
+	ID int `+"`pg:\",pk\"`"+`
+	Meta
+	*Audit
+	time.Time `+"`pg:\"created_at\"`"+`
+	Data []byte
+}
+`)
+
+	m, err := findModel(files, "Bar", pgmodel.SnakeCase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.schema != "app" || m.table != "bars" || m.pk.field != "ID" {
+		t.Errorf("got model %+v", m)
+	}
+
+	want := []column{
+		{name: "note", field: "Meta.Note"},
+		{name: "audit", field: "Audit"},
+		{name: "created_at", field: "Time"},
+		{name: "data", field: "Data", slice: "byte"},
+	}
+	if !reflect.DeepEqual(m.columns, want) {
+		t.Errorf("got columns %+v, want %+v", m.columns, want)
+	}
+}
+
+func TestFindModelRejectsUntaggedForeignEmbeddedStruct(t *testing.T) {
+	files := parseSource(t, `package models
+
+import "time"
+
+type Bar struct {
+	ID int
+	time.Time
+}
+`)
+
+	if _, err := findModel(files, "Bar", pgmodel.SnakeCase); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestGenerateBytea(t *testing.T) {
+	src, err := generate("models", []model{{
+		name:    "Bar",
+		table:   "bars",
+		pk:      column{name: "id", field: "ID"},
+		columns: []column{{name: "data", field: "Data", slice: "byte"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "return `\\x` + hex.EncodeToString(b.Data)") {
+		t.Errorf("got source:\n%s", src)
+	}
+}
+
+func TestFindModelNames(t *testing.T) {
+	files := parseSource(t, `package models
+
+type OrderItem struct {
+	ItemID   int    `+"`pgmodel:\",pk\"`"+`
+	Quantity int
+	Note     string `+"`pg:\"memo\"`"+`
+	Ignored  string `+"`pg:\"-\"`"+`
+	internal string
+}
+`)
+
+	m, err := findModel(files, "OrderItem", pgmodel.PrefixedTables("shop_", pgmodel.PluralTables(pgmodel.SnakeCase)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.schema != "" || m.table != "shop_order_items" {
+		t.Errorf("got table %q.%q, want the derived name", m.schema, m.table)
+	}
+	if m.pk != (column{name: "item_id", field: "ItemID"}) {
+		t.Errorf("got primary key %+v", m.pk)
+	}
+	want := []column{
+		{name: "quantity", field: "Quantity"},
+		{name: "memo", field: "Note"},
+	}
+	if !reflect.DeepEqual(m.columns, want) {
+		t.Errorf("got columns %+v, want %+v", m.columns, want)
+	}
+}
+
+func TestFindModelErrors(t *testing.T) {
+	files := parseSource(t, `package models
+
+type NoKey struct {
+	Name string
+}
+
+type NotStruct int
+`)
+
+	for _, n := range []string{"NoKey", "NotStruct", "Missing"} {
+		if _, err := findModel(files, n, pgmodel.SnakeCase); err == nil {
+			t.Errorf("%s: expected an error", n)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := generate("models", []model{{
+		name:   "Bar",
+		schema: "app",
+		table:  "bars",
+		pk:     column{name: "id", field: "ID"},
+		columns: []column{
+			{name: "name", field: "Name"},
+			{name: "tags", field: "Tags", slice: "string"},
+			{name: "scores", field: "Scores", slice: "int"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The source is valid and implements every method
+	f, err := parser.ParseFile(token.NewFileSet(), "bar_pgmodel.go", src, 0)
+	if err != nil {
+		t.Fatalf("got invalid source: %v\n%s", err, src)
+	}
+	var methods []string
+	for _, d := range f.Decls {
+		if fd, ok := d.(*ast.FuncDecl); ok {
+			methods = append(methods, fd.Name.Name)
+		}
+	}
+	want := []string{"PrimaryKey", "PrimaryKeyValue", "SchemaName", "TableName", "ColumnCount", "NonPKColumns", "NonPKValues", "ConvertSlice"}
+	if !reflect.DeepEqual(methods, want) {
+		t.Errorf("got methods %v, want %v", methods, want)
+	}
+
+	if strings.Contains(string(src), `"encoding/hex"`) {
+		t.Error("imported encoding/hex without a bytea column")
+	}
+	for _, s := range []string{
+		`return []string{"name", "tags", "scores"}`,
+		`return []interface{}{b.Name, b.Tags, b.Scores}`,
+		`return 4`,
+		`case "tags":`,
+		`vs[i] = fmt.Sprint(v)`,
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Errorf("source doesn't contain %q:\n%s", s, src)
+		}
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	dir := t.TempDir()
+	for n, src := range map[string]string{
+		"bar.go":      "package models\n\ntype Bar struct{ ID int }\n",
+		"bar_test.go": "package models_test\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, n), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Test files are ignored
+	pkg, files, err := parseDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg != "models" || len(files) != 1 {
+		t.Errorf("got package %q with %d files", pkg, len(files))
+	}
+}
+
+func TestSplitTableName(t *testing.T) {
+	for n, want := range map[string][2]string{
+		"bars":        {"", "bars"},
+		"app.bars":    {"app", "bars"},
+		"db.app.bars": {"db.app", "bars"},
+	} {
+		if s, tn := splitTableName(n); s != want[0] || tn != want[1] {
+			t.Errorf("%s: got %q, %q, want %q", n, s, tn, want)
+		}
+	}
+}
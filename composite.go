@@ -0,0 +1,74 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompositeKeyModel types are models whose primary key is made up of more than
+// one column, such as a join table keyed by (user_id, group_id).
+//
+// Save, SaveAll, Delete and the other functions that write rows identify the
+// row by all of the key's columns. Functions that query by a single column,
+// like Get, still use PrimaryKey and PrimaryKeyValue, which should return the
+// first of the key's columns and its value.
+type CompositeKeyModel interface {
+	PGModel
+
+	// The columns of the model's primary key.
+	PrimaryKeys() []string
+
+	// The values of the model's primary key in the same order as the columns
+	// defined by PrimaryKeys.
+	PrimaryKeyValues() []interface{}
+}
+
+// MARK: Non-exported functions
+
+// primaryKeys returns pm's primary key columns.
+func primaryKeys(pm KeyedModel) []string {
+	if cm, ok := pm.(CompositeKeyModel); ok {
+		return cm.PrimaryKeys()
+	}
+	return []string{pm.PrimaryKey()}
+}
+
+// primaryKeyValues returns pm's converted primary key values in the same order
+// as primaryKeys.
+func primaryKeyValues(pm PGModel) []interface{} {
+	cm, ok := pm.(CompositeKeyModel)
+	if !ok {
+		return []interface{}{convertVariable(pm, pm.PrimaryKeyValue(), pm.PrimaryKey())}
+	}
+
+	pks := cm.PrimaryKeys()
+	var cv []interface{}
+	for i, u := range cm.PrimaryKeyValues() {
+		cv = append(cv, convertVariable(pm, u, pks[i]))
+	}
+	return cv
+}
+
+// keyPredicate returns a predicate matching each of pm's primary key columns,
+// qualified by the table name, tn, if it isn't empty. Its parameters are the
+// primary key values.
+func keyPredicate(pm KeyedModel, tn string) string {
+	var ps []string
+	for _, u := range primaryKeys(pm) {
+		u = quoteIdent(u)
+		if tn != "" {
+			u = quoteIdent(tn) + "." + u
+		}
+		ps = append(ps, u+" = ?")
+	}
+	return strings.Join(ps, " AND ")
+}
+
+// keyOf returns a value identifying pm's primary key value that can be used as
+// a map key.
+func keyOf(pm PGModel) interface{} {
+	if cm, ok := pm.(CompositeKeyModel); ok {
+		return fmt.Sprintf("%#v", cm.PrimaryKeyValues())
+	}
+	return mapKey(pm.PrimaryKeyValue())
+}
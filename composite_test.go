@@ -0,0 +1,66 @@
+package pgmodel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// membershipModel is a model of the test.memberships join table keyed by
+// (user_id, group_id).
+type membershipModel struct {
+	Base[membershipModel] `pgmodel:"test.memberships"`
+	UserID                int    `pg:"user_id,pk"`
+	GroupID               int    `pg:"group_id"`
+	Role                  string `pg:"role"`
+}
+
+func (m *membershipModel) PrimaryKeys() []string {
+	return []string{"user_id", "group_id"}
+}
+
+func (m *membershipModel) PrimaryKeyValues() []interface{} {
+	return []interface{}{m.UserID, m.GroupID}
+}
+
+func (m *membershipModel) NonPKColumns() []string {
+	return []string{"role"}
+}
+
+func (m *membershipModel) NonPKValues() []interface{} {
+	return []interface{}{m.Role}
+}
+
+func TestCompositeKeySave(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Save(&membershipModel{UserID: 1, GroupID: 2, Role: "admin"}, e); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.Contains(q, `ON CONFLICT ("user_id", "group_id")`) {
+		t.Errorf("got query %q", q)
+	}
+	if p := e.last().params; len(p) < 2 || p[0] != 1 || p[1] != 2 {
+		t.Errorf("got parameters %v", p)
+	}
+}
+
+func TestCompositeKeyDelete(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Delete(&membershipModel{UserID: 1, GroupID: 2}, e); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasSuffix(q, `WHERE "user_id" = ? AND "group_id" = ?`) {
+		t.Errorf("got query %q", q)
+	}
+	if p := e.last().params; !reflect.DeepEqual(p, []interface{}{1, 2}) {
+		t.Errorf("got parameters %v", p)
+	}
+}
+
+func TestKeyOf(t *testing.T) {
+	a := keyOf(&membershipModel{UserID: 1, GroupID: 2})
+	b := keyOf(&membershipModel{UserID: 1, GroupID: 3})
+	if a == b {
+		t.Errorf("got the same key %v for different rows", a)
+	}
+}
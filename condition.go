@@ -0,0 +1,194 @@
+package pgmodel
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// Operator is a comparison used by a Condition.
+type Operator string
+
+const (
+	// Eq matches rows whose column equals the value.
+	Eq Operator = "="
+
+	// Ne matches rows whose column doesn't equal the value.
+	Ne Operator = "<>"
+
+	// Lt matches rows whose column is less than the value.
+	Lt Operator = "<"
+
+	// Le matches rows whose column is less than or equal to the value.
+	Le Operator = "<="
+
+	// Gt matches rows whose column is greater than the value.
+	Gt Operator = ">"
+
+	// Ge matches rows whose column is greater than or equal to the value.
+	Ge Operator = ">="
+
+	// In matches rows whose column equals an element of the value, which must
+	// be a slice.
+	In Operator = "IN"
+
+	// NotIn matches rows whose column equals none of the elements of the value,
+	// which must be a slice.
+	NotIn Operator = "NOT IN"
+
+	// Like matches rows whose column matches the LIKE pattern, value.
+	Like Operator = "LIKE"
+
+	// ILike matches rows whose column matches the case-insensitive LIKE
+	// pattern, value.
+	ILike Operator = "ILIKE"
+
+	// IsNull matches rows whose column is NULL. The value is ignored.
+	IsNull Operator = "IS NULL"
+
+	// IsNotNull matches rows whose column isn't NULL. The value is ignored.
+	IsNotNull Operator = "IS NOT NULL"
+)
+
+// Condition is a filter built from column comparisons joined by AND and OR. It
+// is a QueryOption that limits the results of GetMany and the other functions
+// that select rows by their options' predicates, e.g.
+//
+//	c := pgmodel.Where("status", pgmodel.In, []string{"open", "pending"}).
+//		And("created_at", pgmodel.Ge, since).
+//		Or("deleted_at", pgmodel.IsNull, nil)
+//	bs, _, err := pgmodel.GetMany[*Bar](t, "", nil, c)
+//
+// Comparisons are grouped from left to right, so the condition above matches
+// rows where (status IN (...) AND created_at >= since) OR deleted_at IS NULL.
+// Use AndGroup and OrGroup to group them differently.
+//
+// Columns are column names, which may be qualified, and are quoted. Values
+// are always passed as parameters. Operations given a condition with a column
+// that isn't a column name, such as an expression, or an operator that isn't
+// one of the Operator constants, return an error rather than performing a
+// query. A nil *Condition matches every row, so conditions can be built up
+// from one, e.g.
+//
+//	var c *pgmodel.Condition
+//	if name != "" {
+//		c = c.And("name", pgmodel.Eq, name)
+//	}
+type Condition struct {
+	p   string
+	a   []interface{}
+	err error
+}
+
+// MARK: Exported functions
+
+// Where returns a condition matching the rows whose column compares to value
+// with the operator, op.
+func Where(column string, op Operator, value interface{}) *Condition {
+	col, err := columnExpr(column)
+	if err != nil {
+		return &Condition{err: err}
+	}
+	p, a, err := op.predicate(col, value)
+	if err != nil {
+		return &Condition{err: err}
+	}
+	return &Condition{p: p, a: a}
+}
+
+// And returns a condition matching the rows matched by c and the comparison.
+func (c *Condition) And(column string, op Operator, value interface{}) *Condition {
+	return c.join("AND", Where(column, op, value))
+}
+
+// Or returns a condition matching the rows matched by c or the comparison.
+func (c *Condition) Or(column string, op Operator, value interface{}) *Condition {
+	return c.join("OR", Where(column, op, value))
+}
+
+// AndGroup returns a condition matching the rows matched by both c and g.
+func (c *Condition) AndGroup(g *Condition) *Condition {
+	return c.join("AND", g)
+}
+
+// OrGroup returns a condition matching the rows matched by either c or g.
+func (c *Condition) OrGroup(g *Condition) *Condition {
+	return c.join("OR", g)
+}
+
+// MARK: Non-exported functions
+
+// apply adds the condition to the options' predicates. A nil condition
+// matches every row.
+func (c *Condition) apply(o *queryOptions) {
+	if c != nil {
+		o.conditions = append(o.conditions, c)
+	}
+}
+
+// predicate returns the condition's predicate and its parameters, or the
+// condition's error.
+func (c *Condition) predicate() (string, []interface{}, error) {
+	if c.err != nil {
+		return "", nil, c.err
+	}
+	return "(" + c.p + ")", c.a, nil
+}
+
+// columnCondition returns a condition with the predicate created by fn from
+// the quoted column, and its parameters, a, or an error if the column isn't a
+// column name.
+func columnCondition(column string, fn func(col string) string, a ...interface{}) *Condition {
+	col, err := columnExpr(column)
+	if err != nil {
+		return &Condition{err: err}
+	}
+	return &Condition{p: fn(col), a: a}
+}
+
+// join returns a new condition joining c and g with the conjunction, conj. If
+// either is nil, the other is returned.
+func (c *Condition) join(conj string, g *Condition) *Condition {
+	if c == nil {
+		return g
+	}
+	if g == nil {
+		return c
+	}
+	if c.err != nil {
+		return c
+	}
+	if g.err != nil {
+		return g
+	}
+	a := make([]interface{}, 0, len(c.a)+len(g.a))
+	a = append(a, c.a...)
+	a = append(a, g.a...)
+	return &Condition{
+		p: fmt.Sprintf("(%s) %s (%s)", c.p, conj, g.p),
+		a: a,
+	}
+}
+
+// predicate returns the predicate comparing column to value with op, and its
+// parameters, or an error if op isn't one of the Operator constants.
+func (op Operator) predicate(column string, value interface{}) (string, []interface{}, error) {
+	switch op {
+	case IsNull, IsNotNull:
+		return fmt.Sprintf("%s %s", column, op), nil, nil
+	case In, NotIn:
+		// An empty list can't be written as IN ()
+		if rv := reflect.ValueOf(value); value == nil || rv.Kind() == reflect.Slice && rv.Len() == 0 {
+			if op == In {
+				return "FALSE", nil, nil
+			}
+			return "TRUE", nil, nil
+		}
+		return fmt.Sprintf("%s %s (?)", column, op), []interface{}{pg.In(value)}, nil
+	case Eq, Ne, Lt, Le, Gt, Ge, Like, ILike:
+		return fmt.Sprintf("%s %s ?", column, op), []interface{}{value}, nil
+	default:
+		return "", nil, fmt.Errorf("pgmodel: %q isn't an operator", string(op))
+	}
+}
@@ -0,0 +1,106 @@
+package pgmodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConditionPredicate(t *testing.T) {
+	c := Where("status", In, []string{"open", "pending"}).
+		And("created_at", Ge, 1).
+		Or("deleted_at", IsNull, nil)
+	p, a, err := c.predicate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `((("status" IN (?)) AND ("created_at" >= ?)) OR ("deleted_at" IS NULL))`; p != want {
+		t.Errorf("got predicate %q, want %q", p, want)
+	}
+	if len(a) != 2 || a[1] != 1 {
+		t.Errorf("got parameters %v", a)
+	}
+}
+
+func TestConditionEmptyIn(t *testing.T) {
+	for op, want := range map[Operator]string{In: "(FALSE)", NotIn: "(TRUE)"} {
+		p, a, err := Where("id", op, []int{}).predicate()
+		if err != nil || p != want || len(a) != 0 {
+			t.Errorf("%s: got %q, %v and %v", op, p, a, err)
+		}
+	}
+}
+
+func TestConditionRejectsExpressions(t *testing.T) {
+	for _, c := range []*Condition{
+		Where("true OR id", Eq, 1),
+		Where("id", Eq, 1).And("(SELECT secret FROM t)", Eq, 1),
+		Where("id", Eq, 1).OrGroup(Where("lower(a)", Eq, 1)),
+		BitSet("flags; --", 1),
+		XPathExists("1=1) OR (1", "/a"),
+	} {
+		if _, _, err := c.predicate(); err == nil {
+			t.Errorf("got no error for %+v", c)
+		}
+	}
+}
+
+func TestGetManyRejectsInvalidCondition(t *testing.T) {
+	e := new(testExecutor)
+	if _, _, err := GetMany[*testModel](e, "", nil, Where("1=1 OR id", Eq, 1)); err == nil {
+		t.Fatal("got no error")
+	}
+	if _, _, err := GetMany[*testModel](e, "", nil, WithSearch("name) OR (true", "q")); err == nil {
+		t.Fatal("got no error for an invalid search column")
+	}
+	if n := e.count(); n != 0 {
+		t.Fatalf("got %d queries, want 0", n)
+	}
+}
+
+func TestSelectInvalidCondition(t *testing.T) {
+	q := Select(&testModel{}, Where("id", Eq, 1)).Union(Select(&testModel{}, Where("x y", Eq, 1)))
+	e := new(testExecutor)
+	var ms []*testModel
+	if _, err := q.Scan(e, &ms); err == nil || e.count() != 0 {
+		t.Fatalf("got error %v after %d queries", err, e.count())
+	}
+	if !reflect.DeepEqual(ms, []*testModel(nil)) {
+		t.Fatalf("got models %v", ms)
+	}
+}
+
+func TestConditionRejectsOperators(t *testing.T) {
+	for _, op := range []Operator{"", "= 1 OR 1 =", "; DROP TABLE t; --", "@>"} {
+		if _, _, err := Where("id", op, 1).predicate(); err == nil {
+			t.Errorf("%q: got no error", op)
+		}
+	}
+
+	// The operator's error is kept when the condition is joined
+	if _, _, err := Where("id", Eq, 1).And("name", "OR TRUE OR", 1).predicate(); err == nil {
+		t.Error("got no error for a joined condition")
+	}
+	for _, op := range []Operator{Eq, Ne, Lt, Le, Gt, Ge, In, NotIn, Like, ILike, IsNull, IsNotNull} {
+		if _, _, err := Where("id", op, []int{1}).predicate(); err != nil {
+			t.Errorf("%s: %v", op, err)
+		}
+	}
+}
+
+func TestNilCondition(t *testing.T) {
+	var c *Condition
+	p, a, err := c.And("id", Eq, 1).Or("name", Eq, "a").predicate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `(("id" = ?) OR ("name" = ?))`; p != want || len(a) != 2 {
+		t.Errorf("got predicate %q with parameters %v, want %q", p, a, want)
+	}
+
+	if g := Where("id", Eq, 1).AndGroup(nil); g == nil || g.p != `"id" = ?` {
+		t.Errorf("got %+v joining a nil group", g)
+	}
+	if g := c.OrGroup(nil); g != nil {
+		t.Errorf("got %+v joining nil conditions, want nil", g)
+	}
+}
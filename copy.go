@@ -0,0 +1,136 @@
+package pgmodel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/go-pg/pg/v10/types"
+)
+
+// MARK: Exported functions
+
+// CopyFrom performs an upsert of every model in the given transaction by
+// streaming them in to a temporary table with the COPY protocol and then
+// merging the temporary table in to the models' table with a single
+// INSERT ... SELECT statement. All of the models must belong to the same
+// table.
+//
+// COPY avoids the cost of formatting and parsing a parameter per value, so
+// CopyFrom is much faster than SaveAll for loads of millions of rows. The
+// returned result is that of the merge. Like SaveAll, the duplicate policy can
+// be set with WithDuplicates.
+func CopyFrom(pms []PGModel, t *pg.Tx, opts ...QueryOption) (*Result, error) {
+	return CopyFromContext(context.Background(), pms, t, opts...)
+}
+
+// CopyFromContext is identical to CopyFrom but performs its queries with the
+// context, ctx, so that they can be cancelled or given a deadline. The COPY
+// itself can't be cancelled once it has started.
+func CopyFromContext(ctx context.Context, pms []PGModel, t *pg.Tx, opts ...QueryOption) (*Result, error) {
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	pms, err := prepareBatch(pms, t, o)
+	if err != nil {
+		return nil, err
+	}
+	if len(pms) == 0 {
+		return new(Result), nil
+	}
+	pm := pms[0]
+
+	tmp, err := CreateTempTableLikeContext(ctx, pm, t)
+	if err != nil {
+		return nil, err
+	}
+
+	// Let the timestamp columns be copied as NULL so that the merge sets them
+	cc, uc := timestampColumns(pm)
+	for _, c := range []string{cc, uc} {
+		if c == "" {
+			continue
+		}
+		if _, err := t.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL`, qualify("pg_temp", tmp), quoteIdent(c))); err != nil {
+			return nil, err
+		}
+	}
+
+	// Stream the models in to the temporary table
+	q := createCopyQuery(pm, tmp)
+	_, err = runContext(ctx, OperationSaveAll, pm, t, func() (orm.Result, error) {
+		r, w := io.Pipe()
+		go func() {
+			w.CloseWithError(writeCopyRows(w, pms))
+		}()
+		res, err := t.CopyFrom(r, q)
+		r.Close()
+		return res, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := MergeTempTableContext(ctx, pm, t, tmp)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.ExecContext(ctx, fmt.Sprintf(`DROP TABLE %s`, qualify("pg_temp", tmp))); err != nil {
+		return nil, err
+	}
+	return res, afterSaveAll(pms, t, o)
+}
+
+// MARK: Non-exported functions
+
+// createCopyQuery creates a query copying CSV rows of pm's columns in to the
+// temporary table, name.
+func createCopyQuery(pm PGModel, name string) string {
+	return fmt.Sprintf(
+		`COPY %s (%s) FROM STDIN WITH (FORMAT csv)`,
+		qualify("pg_temp", name),
+		quoteList(columns(pm)),
+	)
+}
+
+// writeCopyRows writes the values of the models to w as CSV rows.
+func writeCopyRows(w io.Writer, pms []PGModel) error {
+	bw := bufio.NewWriter(w)
+	var b []byte
+	for _, pm := range pms {
+		b = b[:0]
+		for i, v := range insertValues(pm) {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendCopyField(b, v)
+		}
+		b = append(b, '\n')
+		if _, err := bw.Write(b); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// appendCopyField appends the converted value, v, to b as a CSV field. NULL
+// values are written as unquoted empty fields and every other value is quoted,
+// so that empty strings remain distinct from NULL.
+func appendCopyField(b []byte, v interface{}) []byte {
+	tv := types.Append(nil, v, 0)
+	if tv == nil && reflect.Indirect(reflect.ValueOf(v)).Kind() != reflect.String {
+		return b
+	}
+
+	b = append(b, '"')
+	for _, c := range tv {
+		if c == '"' {
+			b = append(b, '"')
+		}
+		b = append(b, c)
+	}
+	return append(b, '"')
+}
@@ -0,0 +1,66 @@
+package pgmodel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestCreateCopyQuery(t *testing.T) {
+	want := `COPY "pg_temp"."tmp" ("id", "name", "tags") FROM STDIN WITH (FORMAT csv)`
+	if q := createCopyQuery(&testModel{}, "tmp"); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+}
+
+func TestWriteCopyRows(t *testing.T) {
+	var b bytes.Buffer
+	pms := []PGModel{
+		&testModel{ID: 1, Name: `say "hi"`, Tags: []string{"a", "b"}},
+		&testModel{ID: 2},
+	}
+	if err := writeCopyRows(&b, pms); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\"1\",\"say \"\"hi\"\"\",\"{\"\"a\"\",\"\"b\"\"}\"\n\"2\",\"\",\n"
+	if b.String() != want {
+		t.Errorf("got rows %q, want %q", b.String(), want)
+	}
+}
+
+func TestCopyFrom(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx, `INSERT INTO test.models (id, name) VALUES (1, 'old')`)
+
+	pms := []PGModel{
+		&testModel{ID: 1, Name: "new", Tags: []string{"a"}},
+		&testModel{ID: 2, Name: ""},
+	}
+	if _, err := CopyFrom(pms, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	if _, err := tx.QueryOne(pg.Scan(pg.Array(&names)), `SELECT array_agg(name ORDER BY id) FROM test.models`); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "new" || names[1] != "" {
+		t.Errorf("got names %q, want [new \"\"]", names)
+	}
+}
+
+func TestCopyFromContextCancelled(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CopyFromContext(ctx, []PGModel{&testModel{ID: 1}}, tx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,135 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// PartitionedModel types declare how their tables are range partitioned by
+// time, so that upcoming partitions can be created by SchedulePartitions.
+type PartitionedModel interface {
+	PGModel
+
+	// The range of times in each partition, which must be a whole number of
+	// seconds. Partitions start at multiples of the interval since the Unix
+	// epoch.
+	PartitionInterval() time.Duration
+}
+
+// MARK: Exported functions
+
+// ScheduleJob registers a pg_cron job that runs command on the cron schedule,
+// e.g. "*/5 * * * *", and returns the job's ID. Scheduling a job with the name
+// of an existing job replaces it.
+//
+// Jobs not covered by the other scheduling functions, such as pg_partman's
+// maintenance, can be registered with ScheduleJob directly.
+func ScheduleJob(t *pg.Tx, name string, schedule string, command string) (int64, error) {
+	var id int64
+	_, err := t.QueryOne(pg.Scan(&id), `SELECT cron.schedule(?, ?, ?)`, name, schedule, command)
+	return id, err
+}
+
+// UnscheduleJob removes the pg_cron job with the given name.
+func UnscheduleJob(t *pg.Tx, name string) error {
+	_, err := t.Exec(`SELECT cron.unschedule(?)`, name)
+	return err
+}
+
+// ScheduleRetention registers a pg_cron job that deletes up to batchSize of
+// pm's expired rows each time it runs, and returns the job's ID. The schedule
+// should be frequent enough for the batches to keep up with the table's rate
+// of expiry.
+//
+// The job is named RetentionJobName(pm).
+func ScheduleRetention(t *pg.Tx, pm RetainedModel, schedule string, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultPurgeBatchSize
+	}
+
+	c := fmt.Sprintf("now() - interval '%d microseconds'", pm.RetentionPeriod().Microseconds())
+	return ScheduleJob(t, RetentionJobName(pm), schedule, createPurgeQuery(pm, batchSize, c))
+}
+
+// RetentionJobName returns the name of the job registered for pm by
+// ScheduleRetention.
+func RetentionJobName(pm RetainedModel) string {
+	return fmt.Sprintf("pgmodel_retention_%s_%s", pm.SchemaName(), pm.TableName())
+}
+
+// SchedulePartitions registers a pg_cron job that creates the partition of pm's
+// table for the current time and the premake partitions after it, unless they
+// already exist, and returns the job's ID. The schedule should run the job at
+// least once per partition interval.
+//
+// Partitions are created in the table's schema and are named after the table
+// and the UTC start of their range, e.g. events_20240101000000. The job is
+// named PartitionJobName(pm).
+func SchedulePartitions(t *pg.Tx, pm PartitionedModel, schedule string, premake int) (int64, error) {
+	if pi := pm.PartitionInterval(); pi < time.Second || pi%time.Second != 0 {
+		return 0, fmt.Errorf("pgmodel: partition interval %v of %s.%s isn't a whole number of seconds", pi, pm.SchemaName(), pm.TableName())
+	}
+	if premake < 0 {
+		premake = 0
+	}
+	return ScheduleJob(t, PartitionJobName(pm), schedule, createPartitionCommand(pm, premake))
+}
+
+// PartitionJobName returns the name of the job registered for pm by
+// SchedulePartitions.
+func PartitionJobName(pm PartitionedModel) string {
+	return fmt.Sprintf("pgmodel_partitions_%s_%s", pm.SchemaName(), pm.TableName())
+}
+
+// ScheduleRefresh registers a pg_cron job that refreshes the materialized view
+// on the cron schedule, and returns the job's ID. Concurrent refreshes don't
+// block reads but require the view to have a unique index.
+//
+// The job is named pgmodel_refresh_ followed by the view's name.
+func ScheduleRefresh(t *pg.Tx, view string, schedule string, concurrently bool) (int64, error) {
+	c := "REFRESH MATERIALIZED VIEW " + view
+	if concurrently {
+		c = "REFRESH MATERIALIZED VIEW CONCURRENTLY " + view
+	}
+	return ScheduleJob(t, "pgmodel_refresh_"+view, schedule, c)
+}
+
+// MARK: Non-exported functions
+
+// createPartitionCommand creates a command that creates the partition of pm's
+// table for the current time and the premake partitions after it.
+func createPartitionCommand(pm PartitionedModel, premake int) string {
+	// Escape the identifiers for use in the format string
+	pn := "%I"
+	if sn := pm.SchemaName(); sn != "" {
+		pn = strings.ReplaceAll(quoteIdent(sn), "%", "%%") + ".%I"
+	}
+	f := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%%L) TO (%%L)",
+		pn,
+		strings.ReplaceAll(qualify(pm.SchemaName(), pm.TableName()), "%", "%%"),
+	)
+
+	// Create the command
+	s := int64(pm.PartitionInterval() / time.Second)
+	return fmt.Sprintf(
+		`DO $pgmodel$
+		DECLARE
+			s timestamptz;
+		BEGIN
+			FOR i IN 0..%d LOOP
+				s := to_timestamp((floor(extract(epoch FROM now()) / %d) + i) * %d);
+				EXECUTE format(%s, %s || to_char(s AT TIME ZONE 'UTC', 'YYYYMMDDHH24MISS'), s, s + %d * interval '1 second');
+			END LOOP;
+		END
+		$pgmodel$`,
+		premake,
+		s, s,
+		quoteLiteral(f),
+		quoteLiteral(pm.TableName()+"_"),
+		s,
+	)
+}
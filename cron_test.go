@@ -0,0 +1,61 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// shortPartitionModel is an eventModel with partitions that are too short to
+// be created by SchedulePartitions.
+type shortPartitionModel struct {
+	eventModel
+}
+
+func (m *shortPartitionModel) PartitionInterval() time.Duration {
+	return time.Millisecond
+}
+
+func TestCreatePartitionCommand(t *testing.T) {
+	c := squash(createPartitionCommand(&eventModel{}, 3))
+	for _, want := range []string{
+		`FOR i IN 0..3 LOOP`,
+		`s := to_timestamp((floor(extract(epoch FROM now()) / 3600) + i) * 3600);`,
+		`EXECUTE format('CREATE TABLE IF NOT EXISTS "test".%I PARTITION OF "test"."events" FOR VALUES FROM (%L) TO (%L)', 'events_' || `,
+		`s + 3600 * interval '1 second'`,
+	} {
+		if !strings.Contains(c, want) {
+			t.Errorf("command %q doesn't contain %q", c, want)
+		}
+	}
+}
+
+func TestSchedulePartitionsInvalidInterval(t *testing.T) {
+	if _, err := SchedulePartitions(nil, &shortPartitionModel{}, "0 * * * *", 1); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestPartitionCommandCreatesPartitions(t *testing.T) {
+	tx := testTx(t)
+	for _, q := range []string{
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.events (id int, created_at timestamptz NOT NULL) PARTITION BY RANGE (created_at)`,
+		createPartitionCommand(&eventModel{}, 2),
+		createPartitionCommand(&eventModel{}, 2),
+	} {
+		if _, err := tx.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var n int
+	if _, err := tx.QueryOne(pg.Scan(&n), `SELECT count(*) FROM pg_inherits WHERE inhparent = 'test.events'::regclass`); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("got %d partitions, want 3", n)
+	}
+}
@@ -0,0 +1,155 @@
+package pgmodel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// lifecycle tracks in-flight operations and transactions so that they can be
+// drained.
+var lifecycle = struct {
+	sync.Mutex
+	closed   bool
+	inFlight int
+
+	// Closed when the number of in-flight operations drops to zero while Drain
+	// is waiting.
+	idle chan struct{}
+
+	// The transactions begun by Begin that haven't been committed or rolled
+	// back.
+	txs map[*pg.Tx]struct{}
+}{
+	txs: make(map[*pg.Tx]struct{}),
+}
+
+// txHook is a query hook that ends the tracking of transactions when they're
+// committed or rolled back.
+type txHook struct{}
+
+// MARK: Exported functions
+
+// Drain stops the package from accepting new operations and waits for
+// in-flight operations to complete or for ctx to be done, whichever happens
+// first.
+//
+// Transactions begun by Begin or RunInTx are in flight until they're
+// committed or rolled back, and operations performed in them continue to be
+// accepted so that they can complete. Other operations started after Drain is
+// called return ErrClosed.
+func Drain(ctx context.Context) error {
+	lifecycle.Lock()
+	lifecycle.closed = true
+	if lifecycle.inFlight == 0 {
+		lifecycle.Unlock()
+		return nil
+	}
+	if lifecycle.idle == nil {
+		lifecycle.idle = make(chan struct{})
+	}
+	idle := lifecycle.idle
+	lifecycle.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close drains in-flight operations with Drain, closes db's prepared
+// statements and then closes db.
+//
+// The database is closed even if ctx is done before the operations complete,
+// in which case ctx's error is returned.
+func Close(ctx context.Context, db *pg.DB) error {
+	err := Drain(ctx)
+	ClosePreparedStatements(db)
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// BeforeQuery implements pg.QueryHook.
+func (txHook) BeforeQuery(ctx context.Context, e *pg.QueryEvent) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterQuery implements pg.QueryHook, ending the tracking of the event's
+// transaction if the event committed or rolled it back.
+func (txHook) AfterQuery(ctx context.Context, e *pg.QueryEvent) error {
+	if t, ok := e.DB.(*pg.Tx); ok && (e.Query == "COMMIT" || e.Query == "ROLLBACK") {
+		endTx(t)
+	}
+	return nil
+}
+
+// MARK: Non-exported functions
+
+// enter registers the start of an operation performed with the executor, t,
+// and returns false if the package is no longer accepting operations and t
+// isn't a transaction in flight.
+func enter(t Executor) bool {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+
+	if lifecycle.closed {
+		tx, ok := t.(*pg.Tx)
+		if !ok {
+			return false
+		}
+		if _, ok := lifecycle.txs[tx]; !ok {
+			return false
+		}
+	}
+	lifecycle.inFlight++
+	return true
+}
+
+// exit registers the end of an operation started with enter.
+func exit() {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+
+	lifecycle.inFlight--
+	if lifecycle.inFlight == 0 && lifecycle.idle != nil {
+		close(lifecycle.idle)
+		lifecycle.idle = nil
+	}
+}
+
+// tracking returns a copy of db with a txHook, so that the transactions begun
+// on it are tracked until they're committed or rolled back without adding the
+// hook to db, which isn't safe while db is in use.
+func tracking(db *pg.DB) *pg.DB {
+	// WithParam is the only way to copy db's hooks without changing its
+	// options, and no query refers to the parameter
+	tdb := db.WithParam("pgmodel_tracked", true)
+	tdb.AddQueryHook(txHook{})
+	return tdb
+}
+
+// beginTx tracks the transaction, t, which holds the operation registered by
+// the enter call of the Begin call that began it.
+func beginTx(t *pg.Tx) {
+	lifecycle.Lock()
+	defer lifecycle.Unlock()
+	lifecycle.txs[t] = struct{}{}
+}
+
+// endTx stops tracking the transaction, t, and registers the end of its
+// operation, if it's tracked.
+func endTx(t *pg.Tx) {
+	lifecycle.Lock()
+	_, ok := lifecycle.txs[t]
+	delete(lifecycle.txs, t)
+	lifecycle.Unlock()
+
+	if ok {
+		exit()
+	}
+}
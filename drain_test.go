@@ -0,0 +1,125 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// reopen accepts operations again after a test has drained the package.
+func reopen() {
+	lifecycle.Lock()
+	lifecycle.closed = false
+	lifecycle.Unlock()
+}
+
+func TestDrain(t *testing.T) {
+	defer reopen()
+
+	if !enter(nil) {
+		t.Fatal("an operation wasn't accepted before draining")
+	}
+
+	// Drain waits for the operation in flight
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// New operations are refused
+	e := new(testExecutor)
+	if _, err := Get(&testModel{}, e, "id", 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("got %v, want %v", err, ErrClosed)
+	}
+	if e.count() != 0 {
+		t.Errorf("performed %d queries after draining", e.count())
+	}
+
+	exit()
+	if err := Drain(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDrainWaitsForTransactions(t *testing.T) {
+	defer reopen()
+
+	// Track a transaction as Begin does
+	tx := new(pg.Tx)
+	if !enter(nil) {
+		t.Fatal("a transaction wasn't accepted before draining")
+	}
+	beginTx(tx)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Operations in the transaction are accepted, but not in others
+	if !enter(tx) {
+		t.Fatal("an operation in the transaction was refused")
+	}
+	exit()
+	if enter(new(pg.Tx)) {
+		t.Fatal("an operation in another transaction was accepted")
+	}
+
+	// Committing the transaction ends the drain
+	if err := (txHook{}).AfterQuery(context.Background(), &pg.QueryEvent{DB: tx, Query: "COMMIT"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Drain(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDrainCommitsTransactions(t *testing.T) {
+	defer reopen()
+	db := testDB(t)
+	tx, err := Begin(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createModelsTable(t, tx)
+
+	drained := make(chan error)
+	go func() {
+		drained <- Drain(context.Background())
+	}()
+
+	// The transaction can still be used and committed while draining
+	time.Sleep(10 * time.Millisecond)
+	if _, err := Save(&testModel{ID: 1, Name: "one"}, tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get(&testModel{}, db, "id", 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("got %v, want %v", err, ErrClosed)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-drained; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCloseClosesPreparedStatements(t *testing.T) {
+	defer reopen()
+	db := unreachableDB(t)
+	EnablePreparedStatements(db)
+	if err := Close(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	prepared.RLock()
+	defer prepared.RUnlock()
+	if _, ok := prepared.dbs[db]; ok {
+		t.Error("prepared statements are still enabled")
+	}
+}
@@ -0,0 +1,101 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// DuplicateSet is a group of rows that share the same values in a set of
+// columns.
+type DuplicateSet struct {
+
+	// The shared values, as text, in the order of the columns that were
+	// grouped.
+	Values []string `pg:",array"`
+
+	// The primary key values of the rows, as text, in ascending order.
+	Keys []string `pg:",array"`
+}
+
+// MARK: Exported functions
+
+// FindDuplicates groups the rows of pm's table by the given columns and
+// returns each group containing more than one row.
+func FindDuplicates(pm PGModel, t Executor, columns ...string) ([]DuplicateSet, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("pgmodel: FindDuplicates requires at least one column")
+	}
+	if err := validateColumns(pm, columns); err != nil {
+		return nil, err
+	}
+
+	// Create arrays to join
+	var vm []string
+	for _, c := range columns {
+		vm = append(vm, fmt.Sprintf("%s::text", quoteIdent(c)))
+	}
+
+	var ds []DuplicateSet
+	_, err := t.Query(&ds, fmt.Sprintf(
+		`SELECT ARRAY[%s] AS values, array_agg(%s::text ORDER BY %s) AS keys
+		FROM %s
+		GROUP BY %s
+		HAVING count(*) > 1`,
+		strings.Join(vm, ", "),
+		quoteIdent(pm.PrimaryKey()),
+		quoteIdent(pm.PrimaryKey()),
+		qualify(pm.SchemaName(), pm.TableName()),
+		quoteList(columns),
+	))
+	return ds, err
+}
+
+// MergeRows merges the rows with the primary key values, losers, in to the
+// winner's row. Every relation whose parent is the winner's table has its
+// child rows repointed from the losers to the winner, and then the losers are
+// deleted.
+//
+// Callers should perform MergeRows in a transaction that is rolled back if it
+// fails, so that a merge is never partially applied.
+func MergeRows(t *pg.Tx, winner PGModel, losers []interface{}, relations ...Relation) error {
+	if len(losers) == 0 {
+		return nil
+	}
+
+	// Get everything once
+	pk := quoteIdent(winner.PrimaryKey())
+	sn := winner.SchemaName()
+	tn := winner.TableName()
+	qn := qualify(sn, tn)
+
+	// Repoint the children
+	for _, r := range relations {
+		if r.Parent.SchemaName() != sn || r.Parent.TableName() != tn {
+			continue
+		}
+		pc := r.parentColumn()
+
+		_, err := t.Exec(fmt.Sprintf(
+			`UPDATE %s
+			SET %s = (SELECT %s FROM %s WHERE %s = ?)
+			WHERE %s IN (SELECT %s FROM %s WHERE %s IN (?))`,
+			qualify(r.Child.SchemaName(), r.Child.TableName()),
+			quoteIdent(r.Column), quoteIdent(pc), qn, pk,
+			quoteIdent(r.Column), quoteIdent(pc), qn, pk,
+		), winner.PrimaryKeyValue(), pg.In(losers))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Delete the losers
+	_, err := t.Exec(fmt.Sprintf(
+		`DELETE FROM %s
+		WHERE %s IN (?)`,
+		qn,
+		pk,
+	), pg.In(losers))
+	return err
+}
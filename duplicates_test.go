@@ -0,0 +1,64 @@
+package pgmodel
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestFindDuplicatesQuery(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := FindDuplicates(&testModel{}, e, "name"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `SELECT ARRAY["name"::text] AS values, array_agg("id"::text ORDER BY "id") AS keys FROM "test"."models" GROUP BY "name" HAVING count(*) > 1`
+	if q := squash(e.last().query); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+
+	// Columns are required and validated
+	if _, err := FindDuplicates(&testModel{}, e); err == nil {
+		t.Error("expected an error without columns")
+	}
+	if _, err := FindDuplicates(&testModel{}, e, "missing"); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+	if n := e.count(); n != 1 {
+		t.Errorf("got %d queries, want 1", n)
+	}
+}
+
+func TestMergeRows(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx,
+		`CREATE TABLE test.children (id int PRIMARY KEY, parent_id int)`,
+		`INSERT INTO test.models (id, name) VALUES (1, 'one'), (2, 'one'), (3, 'one'), (4, 'four')`,
+		`INSERT INTO test.children VALUES (1, 2), (2, 3), (3, 4)`,
+	)
+
+	ds, err := FindDuplicates(&testModel{}, tx, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DuplicateSet{{Values: []string{"one"}, Keys: []string{"1", "2", "3"}}}
+	if !reflect.DeepEqual(ds, want) {
+		t.Fatalf("got duplicates %+v, want %+v", ds, want)
+	}
+
+	if err := MergeRows(tx, &testModel{ID: 1}, []interface{}{2, 3}, (&childModel{}).Relations()...); err != nil {
+		t.Fatal(err)
+	}
+
+	var models, children int
+	if _, err := tx.QueryOne(pg.Scan(&models, &children),
+		`SELECT (SELECT count(*) FROM test.models), (SELECT count(*) FROM test.children WHERE parent_id = 1)`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if models != 2 || children != 2 {
+		t.Errorf("got %d models and %d repointed children, want 2 and 2", models, children)
+	}
+}
@@ -0,0 +1,197 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// EffectiveDatedModel types are models whose rows are versions of an entity,
+// each valid during the period in a tstzrange column, such as the prices of a
+// product over time, e.g.
+//
+//	func (p *Price) ValidityColumn() string   { return "valid_during" }
+//	func (p *Price) EntityColumns() []string { return []string{"product_id"} }
+//
+// Each version has its own primary key value. The periods of an entity's
+// versions shouldn't overlap, which an exclusion constraint on the entity and
+// validity columns guarantees.
+type EffectiveDatedModel interface {
+	PGModel
+
+	// The tstzrange column holding the period during which each version is
+	// valid.
+	ValidityColumn() string
+
+	// The columns identifying the entity that rows are versions of.
+	EntityColumns() []string
+}
+
+// MARK: Exported functions
+
+// GetEffectiveAt gets the version of pm's entity that was valid at the time,
+// at, with the given executor and scans it in to pm. The entity is identified
+// by the values of pm's entity columns.
+//
+// Like Get, an error is returned if no version, or more than one version, was
+// valid at the time.
+func GetEffectiveAt(pm EffectiveDatedModel, t Executor, at time.Time, opts ...QueryOption) (*Result, error) {
+	ps, pa, err := entityPredicate(pm)
+	if err != nil {
+		return nil, err
+	}
+	p := fmt.Sprintf("%s AND %s @> ?::timestamptz", ps, quoteIdent(pm.ValidityColumn()))
+	pa = append(pa, convertVariable(pm, at, pm.ValidityColumn()))
+
+	o := newQueryOptions(opts)
+	q, a, err := createSelectQuery(pm, p, pa, o)
+	if err != nil {
+		return nil, err
+	}
+	res, err := runContext(o.context(), OperationGet, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryOneContext(o.context(), pm, q, a...)
+		})
+		normalizeTimes(pm)
+		return res, err
+	})
+	if err == nil {
+		err = afterGet(o.context(), pm, t)
+	}
+	return newResult(res, q), err
+}
+
+// SaveNewVersion inserts pm as the version of its entity that is valid from
+// the time, from, onwards in the given transaction, ending the validity of
+// the entity's current version at the same time. The value of pm's validity
+// column is ignored.
+//
+// The current version is the version whose period has no upper bound. An
+// error is returned, and nothing is changed, if it became valid at or after
+// from. The changes are made inside a savepoint, so if the insert fails, such
+// as by violating an exclusion constraint, the current version is left valid
+// and the transaction can continue to be used.
+//
+// If the model has an ID generator set by SetIDGenerator and its primary key
+// value is empty, a new value is generated before the query is performed.
+func SaveNewVersion(pm EffectiveDatedModel, t *pg.Tx, from time.Time) (*Result, error) {
+	return SaveNewVersionContext(context.Background(), pm, t, from)
+}
+
+// SaveNewVersionContext is identical to SaveNewVersion but performs its
+// queries with the context, ctx, so that they can be cancelled or given a
+// deadline.
+func SaveNewVersionContext(ctx context.Context, pm EffectiveDatedModel, t *pg.Tx, from time.Time) (*Result, error) {
+	if err := errPartial(pm, "SaveNewVersion"); err != nil {
+		return nil, err
+	}
+	if err := beforeSave(ctx, pm, t); err != nil {
+		return nil, err
+	}
+	ps, pa, err := entityPredicate(pm)
+	if err != nil {
+		return nil, err
+	}
+	if err := assignID(pm); err != nil {
+		return nil, err
+	}
+
+	vc := quoteIdent(pm.ValidityColumn())
+	f := convertVariable(pm, from, pm.ValidityColumn())
+	qn := qualify(pm.SchemaName(), pm.TableName())
+	defer InvalidateCache(pm)
+
+	var res orm.Result
+	q := createNewVersionQuery(pm)
+	err = savepoint(t, func() error {
+		// Check that the current version became valid before the new one
+		var later bool
+		if _, err := t.QueryOneContext(ctx, pg.Scan(&later), fmt.Sprintf(
+			`SELECT EXISTS (SELECT 1 FROM %s WHERE %s AND upper_inf(%s) AND lower(%s) >= ?::timestamptz)`,
+			qn, ps, vc, vc,
+		), append(append([]interface{}{}, pa...), f)...); err != nil {
+			return err
+		}
+		if later {
+			return fmt.Errorf("pgmodel: the current version of %s.%s became valid at or after %s", pm.SchemaName(), pm.TableName(), from)
+		}
+
+		// End the current version
+		if _, err := t.ExecContext(ctx, fmt.Sprintf(
+			`UPDATE %s SET %s = tstzrange(lower(%s), ?::timestamptz) WHERE %s AND upper_inf(%s)`,
+			qn, vc, vc, ps, vc,
+		), append([]interface{}{f}, pa...)...); err != nil {
+			return err
+		}
+
+		// Insert the new version
+		v := append(primaryKeyValues(pm), stampValues(pm, convertVariables(pm))...)
+		for i, c := range columns(pm) {
+			if c == pm.ValidityColumn() {
+				v[i] = f
+			}
+		}
+		var err error
+		res, err = runContext(ctx, OperationSave, pm, t, func() (orm.Result, error) {
+			return t.QueryContext(ctx, pm, q, v...)
+		})
+		return err
+	})
+	if err == nil {
+		err = afterSave(ctx, pm, t)
+	}
+	return newResult(res, q), err
+}
+
+// MARK: Non-exported functions
+
+// entityPredicate returns a predicate matching the versions of pm's entity,
+// along with its parameters.
+func entityPredicate(pm EffectiveDatedModel) (string, []interface{}, error) {
+	ec := pm.EntityColumns()
+	if len(ec) == 0 {
+		return "", nil, fmt.Errorf("pgmodel: %s.%s has no entity columns", pm.SchemaName(), pm.TableName())
+	}
+	if err := validateColumns(pm, append([]string{pm.ValidityColumn()}, ec...)); err != nil {
+		return "", nil, err
+	}
+
+	var ps []string
+	var pa []interface{}
+	for _, c := range ec {
+		v, _ := columnValue(pm, c)
+		ps = append(ps, fmt.Sprintf("%s = ?", quoteIdent(c)))
+		pa = append(pa, convertVariable(pm, v, c))
+	}
+	return strings.Join(ps, " AND "), pa, nil
+}
+
+// createNewVersionQuery creates a query inserting pm as a version that is
+// valid from its validity column's parameter onwards.
+func createNewVersionQuery(pm EffectiveDatedModel) string {
+	// Get everything once
+	c := columns(pm)
+
+	// Create arrays to join
+	var im []string
+	for _, u := range c {
+		if u == pm.ValidityColumn() {
+			im = append(im, "tstzrange(?::timestamptz, NULL)")
+		} else {
+			im = append(im, insertValue(pm, u))
+		}
+	}
+
+	// Create the query
+	return fmt.Sprintf(
+		`INSERT INTO %s (%s)
+		VALUES (%s)`,
+		qualify(pm.SchemaName(), pm.TableName()),
+		quoteList(c),
+		strings.Join(im, ", "),
+	)
+}
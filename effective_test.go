@@ -0,0 +1,79 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// priceModel is a model of the test.prices table whose rows are versions of
+// the prices of products.
+type priceModel struct {
+	Base[priceModel] `pgmodel:"test.prices"`
+	ID               int    `pg:"id,pk"`
+	ProductID        int    `pg:"product_id"`
+	Amount           int    `pg:"amount"`
+	ValidDuring      string `pg:"valid_during"`
+}
+
+func (p *priceModel) ValidityColumn() string  { return "valid_during" }
+func (p *priceModel) EntityColumns() []string { return []string{"product_id"} }
+
+func TestNewVersionQuery(t *testing.T) {
+	q := squash(createNewVersionQuery(&priceModel{}))
+	if want := `INSERT INTO "test"."prices" ("id", "product_id", "amount", "valid_during") VALUES (?, ?, ?, tstzrange(?::timestamptz, NULL))`; q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+}
+
+func TestGetEffectiveAtQuery(t *testing.T) {
+	e := new(testExecutor)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := GetEffectiveAt(&priceModel{ProductID: 7}, e, at); err != nil {
+		t.Fatal(err)
+	}
+	q := squash(e.last().query)
+	if !strings.Contains(q, `"product_id" = ? AND "valid_during" @> ?::timestamptz`) {
+		t.Errorf("got query %q", q)
+	}
+	if p := e.last().params; len(p) != 2 || p[0] != 7 {
+		t.Errorf("got parameters %v, want the entity's product_id first", p)
+	}
+}
+
+func TestSaveNewVersion(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.prices (id int PRIMARY KEY, product_id int, amount int, valid_during tstzrange)`,
+	)
+
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := jan.AddDate(0, 1, 0)
+	if _, err := SaveNewVersion(&priceModel{ID: 1, ProductID: 7, Amount: 100}, tx, jan); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveNewVersion(&priceModel{ID: 2, ProductID: 7, Amount: 120}, tx, feb); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each version is valid during its own period
+	for at, want := range map[time.Time]int{jan.AddDate(0, 0, 1): 100, feb.AddDate(0, 0, 1): 120} {
+		p := &priceModel{ProductID: 7}
+		if _, err := GetEffectiveAt(p, tx, at); err != nil {
+			t.Fatal(err)
+		}
+		if p.Amount != want {
+			t.Errorf("got amount %d at %s, want %d", p.Amount, at, want)
+		}
+	}
+
+	// Versions can't start before the current version, and the transaction
+	// can continue to be used
+	if _, err := SaveNewVersion(&priceModel{ID: 3, ProductID: 7, Amount: 90}, tx, jan); err == nil {
+		t.Error("expected an error")
+	}
+	if _, err := GetEffectiveAt(&priceModel{ProductID: 7}, tx, feb); err != nil {
+		t.Error(err)
+	}
+}
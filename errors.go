@@ -0,0 +1,125 @@
+package pgmodel
+
+import (
+	"errors"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// foreignKeyViolation is the SQLSTATE of foreign key constraint violations.
+const foreignKeyViolation = "23503"
+
+var (
+	// ErrClosed is returned by operations started after Drain or Close.
+	ErrClosed = errors.New("pgmodel: closed")
+
+	// ErrConflict is matched by the *Error returned when a write violates a
+	// unique constraint.
+	ErrConflict = errors.New("pgmodel: unique constraint violation")
+
+	// ErrDuplicateKey is wrapped by the errors returned from SaveAll when a batch
+	// contains models with the same primary key value.
+	ErrDuplicateKey = errors.New("pgmodel: duplicate key in batch")
+
+	// ErrExclusionViolation is matched by the *ExclusionError returned when a
+	// write conflicts with an existing row on an exclusion constraint.
+	ErrExclusionViolation = errors.New("pgmodel: exclusion constraint violation")
+
+	// ErrForeignKey is matched by the *Error returned when a write violates a
+	// foreign key constraint, such as a row referencing a missing parent or a
+	// delete of a referenced row.
+	ErrForeignKey = errors.New("pgmodel: foreign key constraint violation")
+
+	// ErrNotFound is matched by the *Error returned when a query, such as Get,
+	// matches no rows.
+	ErrNotFound = errors.New("pgmodel: not found")
+
+	// ErrNotTransaction is returned by operations given session settings, such
+	// as WithSearchPath, with an Executor that isn't a *pg.Tx. Settings are set
+	// locally to a transaction, so they have no effect outside of one.
+	ErrNotTransaction = errors.New("pgmodel: session settings require a transaction")
+
+	// ErrOverlap is returned by InsertIfNoOverlap when the model's range
+	// overlaps the range of an existing row with the same key.
+	ErrOverlap = errors.New("pgmodel: range overlaps an existing row")
+
+	// ErrRowExists is returned by Insert when a row with the model's primary key
+	// value already exists.
+	ErrRowExists = errors.New("pgmodel: row already exists")
+)
+
+// Error is returned by operations in place of the errors returned by go-pg, so
+// that callers can tell failures apart with errors.Is and the sentinel errors
+// ErrNotFound, ErrConflict and ErrForeignKey rather than by their messages. It
+// unwraps to the go-pg error, so errors.Is(err, pg.ErrNoRows) continues to
+// work, and its message is the go-pg error's message.
+type Error struct {
+
+	// The SQLSTATE of the error returned by the database, such as 23505. It's
+	// empty for errors that weren't returned by the database, such as
+	// pg.ErrNoRows.
+	Code string
+
+	// The schema, table and constraint names the database reported, if any.
+	Schema     string
+	Table      string
+	Constraint string
+
+	// The error returned by go-pg.
+	Err error
+}
+
+// Error returns the message of the go-pg error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Is returns true if target is the sentinel error describing e.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return errors.Is(e.Err, pg.ErrNoRows)
+	case ErrConflict:
+		return e.Code == uniqueViolation
+	case ErrForeignKey:
+		return e.Code == foreignKeyViolation
+	default:
+		return false
+	}
+}
+
+// Unwrap returns the error returned by go-pg.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// MARK: Non-exported functions
+
+// wrapError returns err as an *ExclusionError if it's an exclusion constraint
+// violation, as an *Error if it's a database error or pg.ErrNoRows, or
+// unchanged otherwise.
+func wrapError(err error) error {
+	var e *Error
+	var ee *ExclusionError
+	switch {
+	case err == nil, errors.As(err, &e), errors.As(err, &ee):
+		return err
+	case errors.Is(err, pg.ErrNoRows):
+		return &Error{Err: err}
+	}
+
+	var pe pg.Error
+	if !errors.As(err, &pe) {
+		return err
+	}
+	if pe.Field('C') == exclusionViolation {
+		return exclusionError(err)
+	}
+	return &Error{
+		Code:       pe.Field('C'),
+		Schema:     pe.Field('s'),
+		Table:      pe.Field('t'),
+		Constraint: pe.Field('n'),
+		Err:        err,
+	}
+}
@@ -0,0 +1,57 @@
+package pgmodel
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestWrapError(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		want error
+	}{
+		{testPGError{'C': "23505", 'n': "models_name_key"}, ErrConflict},
+		{testPGError{'C': "23503"}, ErrForeignKey},
+		{pg.ErrNoRows, ErrNotFound},
+		{testPGError{'C': exclusionViolation}, ErrExclusionViolation},
+	} {
+		err := wrapError(c.err)
+		if !errors.Is(err, c.want) {
+			t.Errorf("got error %v for %v, want it to match %v", err, c.err, c.want)
+		}
+		var pe pg.Error
+		if c.err != pg.ErrNoRows && !errors.As(err, &pe) {
+			t.Errorf("got error %v for %v, want it to unwrap to the pg.Error", err, c.err)
+		}
+	}
+
+	// The database's fields are kept
+	var e *Error
+	if err := wrapError(testPGError{'C': "23505", 't': "models", 'n': "models_name_key"}); !errors.As(err, &e) || e.Table != "models" || e.Constraint != "models_name_key" {
+		t.Errorf("got error %+v", err)
+	}
+
+	// Other errors are unchanged, and errors aren't wrapped twice
+	other := errors.New("other")
+	if err := wrapError(other); err != other {
+		t.Errorf("got error %v, want it unchanged", err)
+	}
+	if err := wrapError(e); err != error(e) {
+		t.Errorf("got error %v, want it unchanged", err)
+	}
+	if errors.Is(e, ErrNotFound) {
+		t.Error("a conflict matched ErrNotFound")
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return nil, pg.ErrNoRows
+	}}
+	if _, err := Get(&testModel{}, e, "id", 1); !errors.Is(err, ErrNotFound) || !errors.Is(err, pg.ErrNoRows) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+}
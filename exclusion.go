@@ -0,0 +1,234 @@
+package pgmodel
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// exclusionViolation is the SQLSTATE of exclusion constraint violations.
+const exclusionViolation = "23P01"
+
+// exclusionDetail matches the detail of an exclusion constraint violation,
+// capturing the columns and values of the new row and of the existing row it
+// conflicts with.
+var exclusionDetail = regexp.MustCompile(`^Key \((.+?)\)=\((.*)\) conflicts with existing key \((.+?)\)=\((.*)\)\.?$`)
+
+// ExclusionModel types are models whose tables have EXCLUDE constraints, such
+// as bookings of a room that mustn't overlap, e.g.
+//
+//	func (b *Booking) Exclusions() []pgmodel.Exclusion {
+//		return []pgmodel.Exclusion{{
+//			Name: "bookings_room_id_during_excl",
+//			Elements: []pgmodel.ExclusionElement{
+//				{Expression: "room_id", Operator: "="},
+//				{Expression: "during", Operator: "&&"},
+//			},
+//		}}
+//	}
+type ExclusionModel interface {
+	KeyedModel
+
+	// The exclusion constraints of the model's table.
+	Exclusions() []Exclusion
+}
+
+// Exclusion describes an EXCLUDE constraint, which rejects rows that conflict
+// with an existing row on every one of its elements.
+type Exclusion struct {
+
+	// The name of the constraint.
+	Name string
+
+	// The index method of the constraint. It defaults to gist.
+	Using string
+
+	// The elements that rows are compared by.
+	Elements []ExclusionElement
+
+	// An optional predicate limiting the rows the constraint applies to, such
+	// as "NOT cancelled". It's used as given.
+	Where string
+}
+
+// ExclusionElement is a column or expression of an exclusion constraint and
+// the operator rows are compared with.
+type ExclusionElement struct {
+
+	// The column or expression, such as "tstzrange(starts_at, ends_at)". Column
+	// names are quoted and expressions are used as given.
+	Expression string
+
+	// The operator that must not be true of the new row and an existing row,
+	// such as "=" or "&&".
+	Operator string
+}
+
+// ExclusionError is returned by operations that write a row conflicting with
+// an existing row on an exclusion constraint. It matches
+// ErrExclusionViolation with errors.Is and unwraps to the pg.Error returned by
+// the database.
+type ExclusionError struct {
+
+	// The schema, table and name of the violated constraint.
+	Schema     string
+	Table      string
+	Constraint string
+
+	// The values of the constraint's elements in the new row and in the existing
+	// row it conflicts with, keyed by element, such as the conflicting range of
+	// a booking. They're nil if the database's description of the violation
+	// couldn't be parsed.
+	Key         map[string]string
+	Conflicting map[string]string
+
+	// The error returned by the database.
+	Err error
+}
+
+// Error returns a description of the violation.
+func (e *ExclusionError) Error() string {
+	return fmt.Sprintf("pgmodel: row conflicts with an existing row of %s.%s on %s: %v", e.Schema, e.Table, e.Constraint, e.Err)
+}
+
+// Is returns true if target is ErrExclusionViolation.
+func (e *ExclusionError) Is(target error) bool {
+	return target == ErrExclusionViolation
+}
+
+// Unwrap returns the error returned by the database.
+func (e *ExclusionError) Unwrap() error {
+	return e.Err
+}
+
+// MARK: Exported functions
+
+// CreateExclusions adds each of pm's exclusion constraints that doesn't
+// already exist to its table in the given transaction. Constraints are matched
+// by name, so changes to an existing constraint aren't applied.
+//
+// Elements compared by equality, such as the room of a booking, require the
+// btree_gist extension with the gist index method.
+func CreateExclusions(pm ExclusionModel, t *pg.Tx) error {
+	qn := qualify(pm.SchemaName(), pm.TableName())
+	for _, e := range pm.Exclusions() {
+		if e.Name == "" || len(e.Elements) == 0 {
+			return fmt.Errorf("pgmodel: exclusion constraints require a name and at least one element")
+		}
+
+		var exists bool
+		if _, err := t.QueryOne(pg.Scan(&exists),
+			`SELECT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = ?::regclass AND conname = ?)`,
+			qn, e.Name,
+		); err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := t.Exec(createExclusionQuery(qn, e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MARK: Non-exported functions
+
+// createExclusionQuery creates a query adding the exclusion constraint, e, to
+// the table with the qualified name, qn.
+func createExclusionQuery(qn string, e Exclusion) string {
+	u := e.Using
+	if u == "" {
+		u = "gist"
+	}
+
+	var els []string
+	for _, el := range e.Elements {
+		els = append(els, fmt.Sprintf("%s WITH %s", quoteExpr(el.Expression), el.Operator))
+	}
+
+	var w string
+	if e.Where != "" {
+		w = "WHERE (" + e.Where + ")"
+	}
+
+	return fmt.Sprintf(
+		`ALTER TABLE %s
+		ADD CONSTRAINT %s
+		EXCLUDE USING %s (%s)
+		%s`,
+		qn,
+		quoteIdent(e.Name),
+		u,
+		strings.Join(els, ", "),
+		w,
+	)
+}
+
+// exclusionError returns err as an *ExclusionError if it's an exclusion
+// constraint violation, or err unchanged if it isn't.
+func exclusionError(err error) error {
+	var pe pg.Error
+	if !errors.As(err, &pe) || pe.Field('C') != exclusionViolation {
+		return err
+	}
+
+	e := &ExclusionError{
+		Schema:     pe.Field('s'),
+		Table:      pe.Field('t'),
+		Constraint: pe.Field('n'),
+		Err:        err,
+	}
+	if m := exclusionDetail.FindStringSubmatch(pe.Field('D')); m != nil {
+		e.Key = splitKey(m[1], m[2])
+		e.Conflicting = splitKey(m[3], m[4])
+	}
+	return e
+}
+
+// splitKey returns the values of a key described by the database, such as
+// the values, (1, ["2024-01-01 10:00:00+00","2024-01-01 11:00:00+00")), of the
+// columns, (room_id, during), keyed by column, or nil if the number of values
+// doesn't match the number of columns.
+func splitKey(columns string, values string) map[string]string {
+	cs := splitTopLevel(columns)
+	vs := splitTopLevel(values)
+	if len(cs) != len(vs) {
+		return nil
+	}
+
+	m := make(map[string]string, len(cs))
+	for i, c := range cs {
+		m[c] = vs[i]
+	}
+	return m
+}
+
+// splitTopLevel splits s on the commas that aren't inside quotes, brackets or
+// parentheses, such as the bounds of a range.
+func splitTopLevel(s string) []string {
+	var ps []string
+	var depth int
+	var quoted bool
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			ps = append(ps, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(ps, strings.TrimSpace(s[start:]))
+}
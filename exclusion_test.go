@@ -0,0 +1,60 @@
+package pgmodel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testPGError is a pg.Error with the given fields.
+type testPGError map[byte]string
+
+func (e testPGError) Error() string            { return "ERROR: " + e['M'] }
+func (e testPGError) Field(f byte) string      { return e[f] }
+func (e testPGError) IntegrityViolation() bool { return strings.HasPrefix(e['C'], "23") }
+
+func TestCreateExclusionQuery(t *testing.T) {
+	q := createExclusionQuery(`"test"."bookings"`, Exclusion{
+		Name: "bookings_room_id_during_excl",
+		Elements: []ExclusionElement{
+			{Expression: "room_id", Operator: "="},
+			{Expression: "tstzrange(starts_at, ends_at)", Operator: "&&"},
+		},
+		Where: "NOT cancelled",
+	})
+	want := `ALTER TABLE "test"."bookings" ADD CONSTRAINT "bookings_room_id_during_excl" EXCLUDE USING gist ("room_id" WITH =, tstzrange(starts_at, ends_at) WITH &&) WHERE (NOT cancelled)`
+	if q := squash(q); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+}
+
+func TestExclusionError(t *testing.T) {
+	pe := testPGError{
+		'C': exclusionViolation,
+		's': "test",
+		't': "bookings",
+		'n': "bookings_room_id_during_excl",
+		'D': `Key (room_id, during)=(1, ["2024-01-01 10:00:00+00","2024-01-01 11:00:00+00")) conflicts with existing key (room_id, during)=(1, ["2024-01-01 10:30:00+00","2024-01-01 12:00:00+00")).`,
+	}
+	err := exclusionError(fmt.Errorf("wrapped: %w", pe))
+
+	var ee *ExclusionError
+	if !errors.As(err, &ee) || !errors.Is(err, ErrExclusionViolation) {
+		t.Fatalf("got error %v, want an *ExclusionError", err)
+	}
+	if ee.Constraint != "bookings_room_id_during_excl" || ee.Table != "bookings" {
+		t.Errorf("got error %+v", ee)
+	}
+	want := map[string]string{"room_id": "1", "during": `["2024-01-01 10:30:00+00","2024-01-01 12:00:00+00")`}
+	if !reflect.DeepEqual(ee.Conflicting, want) {
+		t.Errorf("got conflicting key %v, want %v", ee.Conflicting, want)
+	}
+
+	// Other errors are unchanged
+	other := testPGError{'C': "23505"}
+	if err := exclusionError(other); !reflect.DeepEqual(err, other) {
+		t.Errorf("got error %v, want it unchanged", err)
+	}
+}
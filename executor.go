@@ -0,0 +1,27 @@
+package pgmodel
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Executor types perform queries. Executor is satisfied by *pg.DB, *pg.Tx and
+// *pg.Conn, and by any other orm.DB, so that functions which don't need a
+// transaction, such as Get, can be called without one.
+type Executor interface {
+	Exec(query interface{}, params ...interface{}) (orm.Result, error)
+	ExecContext(c context.Context, query interface{}, params ...interface{}) (orm.Result, error)
+	Query(model interface{}, query interface{}, params ...interface{}) (orm.Result, error)
+	QueryContext(c context.Context, model interface{}, query interface{}, params ...interface{}) (orm.Result, error)
+	QueryOne(model interface{}, query interface{}, params ...interface{}) (orm.Result, error)
+	QueryOneContext(c context.Context, model interface{}, query interface{}, params ...interface{}) (orm.Result, error)
+}
+
+var (
+	_ Executor = (*pg.DB)(nil)
+	_ Executor = (*pg.Tx)(nil)
+	_ Executor = (*pg.Conn)(nil)
+	_ Executor = orm.DB(nil)
+)
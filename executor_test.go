@@ -0,0 +1,55 @@
+package pgmodel
+
+import (
+	"errors"
+	"testing"
+)
+
+// executorModel is a model of the pgmodel_executor_test.items table.
+type executorModel struct {
+	Base[executorModel] `pgmodel:"pgmodel_executor_test.items"`
+	ID                  int    `pg:"id,pk"`
+	Name                string `pg:"name"`
+}
+
+func TestExecutorWithoutTransaction(t *testing.T) {
+	db := testDB(t)
+	testExec(t, db,
+		`DROP SCHEMA IF EXISTS pgmodel_executor_test CASCADE`,
+		`CREATE SCHEMA pgmodel_executor_test`,
+		`CREATE TABLE pgmodel_executor_test.items (id int PRIMARY KEY, name text)`,
+	)
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DROP SCHEMA pgmodel_executor_test CASCADE`)
+	})
+
+	conn := db.Conn()
+	defer conn.Close()
+
+	for i, e := range []Executor{db, conn} {
+		m := &executorModel{ID: i + 1, Name: "saved"}
+		if _, err := Save(m, e); err != nil {
+			t.Fatalf("%T: %v", e, err)
+		}
+
+		got := new(executorModel)
+		if _, err := Get(got, e, "id", m.ID); err != nil || got.Name != "saved" {
+			t.Errorf("%T: got %+v, %v", e, got, err)
+		}
+		if _, err := Delete(m, e); err != nil {
+			t.Errorf("%T: %v", e, err)
+		}
+	}
+}
+
+func TestSettingsWithoutTransaction(t *testing.T) {
+	db := unreachableDB(t)
+
+	// The settings are rejected before connecting
+	if _, err := Save(&testModel{ID: 1}, db, WithSearchPath("public")); !errors.Is(err, ErrNotTransaction) {
+		t.Errorf("got %v, want %v", err, ErrNotTransaction)
+	}
+	if _, err := Get(&testModel{}, db, "id", 1, WithApplicationName("test")); !errors.Is(err, ErrNotTransaction) {
+		t.Errorf("got %v, want %v", err, ErrNotTransaction)
+	}
+}
@@ -0,0 +1,162 @@
+package pgmodel
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// FilterableModel types declare the filters that external callers, such as
+// the query parameters of an API request, may apply to their rows with
+// ParseFilter. Columns that aren't declared can't be filtered.
+type FilterableModel interface {
+	KeyedModel
+
+	// The model's filters keyed by the names callers use for them.
+	Filters() map[string]Filter
+}
+
+// Filter exposes a column to ParseFilter.
+type Filter struct {
+
+	// The column that's filtered, which may be qualified.
+	Column string
+
+	// The operators callers may use. Only Eq is allowed if none are given.
+	Operators []Operator
+}
+
+// FilterError is returned by ParseFilter when a filter isn't allowed or its
+// value is invalid.
+type FilterError struct {
+
+	// The name of the filter as given by the caller.
+	Name string
+
+	// A description of the problem.
+	Problem string
+}
+
+// Error returns a message describing the filter and its problem.
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("pgmodel: invalid filter %q: %s", e.Name, e.Problem)
+}
+
+// filterOperators are the operators of the filter names accepted by
+// ParseFilter, e.g. "age[ge]".
+var filterOperators = map[string]Operator{
+	"eq":    Eq,
+	"ne":    Ne,
+	"lt":    Lt,
+	"le":    Le,
+	"gt":    Gt,
+	"ge":    Ge,
+	"in":    In,
+	"nin":   NotIn,
+	"like":  Like,
+	"ilike": ILike,
+	"null":  IsNull,
+}
+
+// MARK: Exported functions
+
+// ParseFilter converts the values, such as the query parameters of a request,
+// in to a condition on the filters declared by pm, e.g.
+//
+//	c, err := pgmodel.ParseFilter(new(Bar), r.URL.Query())
+//	...
+//	bs, _, err := pgmodel.GetMany[*Bar](t, "", nil, c)
+//
+// Each value's name is a filter's name, comparing with Eq, or the name
+// followed by an operator in brackets, such as "age[ge]". The operators are
+// eq, ne, lt, le, gt, ge, in, nin, like, ilike and null. The values of in and
+// nin are comma separated lists, and the value of null is "true" or "false".
+// Every value must match for a row to match the condition.
+//
+// A *FilterError is returned for names that aren't declared by pm, operators
+// that aren't allowed by their filter, and invalid values. The condition is
+// nil if there are no values.
+func ParseFilter(pm FilterableModel, values url.Values) (*Condition, error) {
+	fs := pm.Filters()
+
+	// Sort the names so that the condition's parameters are deterministic
+	var names []string
+	for n := range values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var c *Condition
+	for _, n := range names {
+		fn, on := splitFilterName(n)
+		f, ok := fs[fn]
+		if !ok {
+			return nil, &FilterError{Name: n, Problem: "unknown filter"}
+		}
+		op, ok := filterOperators[on]
+		if !ok {
+			return nil, &FilterError{Name: n, Problem: fmt.Sprintf("unknown operator %q", on)}
+		}
+		if !f.allows(op) {
+			return nil, &FilterError{Name: n, Problem: fmt.Sprintf("operator %q isn't allowed", on)}
+		}
+
+		for _, v := range values[n] {
+			w, err := f.where(n, op, v)
+			if err != nil {
+				return nil, err
+			}
+			if c == nil {
+				c = w
+			} else {
+				c = c.AndGroup(w)
+			}
+		}
+	}
+	return c, nil
+}
+
+// MARK: Non-exported functions
+
+// splitFilterName splits the filter name, n, in to the name of its filter and
+// the name of its operator.
+func splitFilterName(n string) (string, string) {
+	if i := strings.IndexByte(n, '['); i >= 0 && strings.HasSuffix(n, "]") {
+		return n[:i], n[i+1 : len(n)-1]
+	}
+	return n, "eq"
+}
+
+// allows returns true if the filter allows the operator, op.
+func (f Filter) allows(op Operator) bool {
+	if len(f.Operators) == 0 {
+		return op == Eq
+	}
+	for _, u := range f.Operators {
+		if u == op {
+			return true
+		}
+	}
+	return false
+}
+
+// where returns the condition comparing the filter's column to the value, v,
+// given for the filter named n.
+func (f Filter) where(n string, op Operator, v string) (*Condition, error) {
+	switch op {
+	case In, NotIn:
+		return Where(f.Column, op, strings.Split(v, ",")), nil
+	case IsNull:
+		switch v {
+		case "true":
+			return Where(f.Column, IsNull, nil), nil
+		case "false":
+			return Where(f.Column, IsNotNull, nil), nil
+		default:
+			return nil, &FilterError{Name: n, Problem: fmt.Sprintf("value %q isn't true or false", v)}
+		}
+	default:
+		return Where(f.Column, op, v), nil
+	}
+}
@@ -0,0 +1,60 @@
+package pgmodel
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+// filteredModel is a model of the test.models table with filters.
+type filteredModel struct {
+	Base[filteredModel] `pgmodel:"test.models"`
+	ID                  int    `pg:"id,pk"`
+	Name                string `pg:"name"`
+}
+
+func (m *filteredModel) Filters() map[string]Filter {
+	return map[string]Filter{
+		"id":   {Column: "id", Operators: []Operator{Eq, In, Gt}},
+		"name": {Column: "name", Operators: []Operator{ILike, IsNull}},
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	c, err := ParseFilter(&filteredModel{}, url.Values{
+		"id[in]":     {"1,2"},
+		"name[null]": {"false"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, a, err := c.predicate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `(("id" IN (?)) AND ("name" IS NOT NULL))`; p != want {
+		t.Errorf("got predicate %q, want %q", p, want)
+	}
+	if len(a) != 1 {
+		t.Errorf("got parameters %v", a)
+	}
+
+	// No values give no condition
+	if c, err := ParseFilter(&filteredModel{}, nil); c != nil || err != nil {
+		t.Errorf("got %v, %v, want no condition", c, err)
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for n, v := range map[string]string{
+		"secret":      "1",
+		"id[between]": "1",
+		"name":        "a",
+		"name[null]":  "maybe",
+	} {
+		var fe *FilterError
+		if _, err := ParseFilter(&filteredModel{}, url.Values{n: {v}}); !errors.As(err, &fe) || fe.Name != n {
+			t.Errorf("%s: got error %v, want a *FilterError", n, err)
+		}
+	}
+}
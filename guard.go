@@ -0,0 +1,190 @@
+package pgmodel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// MARK: Exported functions
+
+// WithExpectRows makes DeleteWhere and UpdateWhere roll back their changes and
+// return a *RowCountError when they affect a number of rows other than n,
+// e.g. to delete exactly one user's sessions by a predicate that should only
+// match them.
+func WithExpectRows(n int) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.expectRows = &n
+	})
+}
+
+// DeleteWhere deletes the rows of pm's table matching the condition, c, in the
+// given transaction. The condition is required so that a table can't be
+// emptied by mistake. The rows of SoftDeletable models are marked as deleted
+// instead of being removed from the table.
+//
+// With WithExpectRows or WithMaxRows, the deletion is performed in a savepoint
+// and rolled back if it affects an unexpected number of rows, and a
+// *RowCountError is returned.
+func DeleteWhere(pm KeyedModel, t *pg.Tx, c *Condition, opts ...QueryOption) (*Result, error) {
+	o := newQueryOptions(opts)
+	q, a, err := createDeleteWhereQuery(pm, c, o)
+	if err != nil {
+		return nil, err
+	}
+	defer invalidateCaches(pm)
+	return o.guard(OperationDelete, pm, t, q, a)
+}
+
+// UpdateWhere sets the columns of the rows of pm's table matching the
+// condition, c, to the values in set, keyed by column, in the given
+// transaction. The condition is required so that every row of a table can't
+// be updated by mistake.
+//
+// With WithExpectRows or WithMaxRows, the update is performed in a savepoint
+// and rolled back if it affects an unexpected number of rows, and a
+// *RowCountError is returned.
+func UpdateWhere(pm PGModel, t *pg.Tx, c *Condition, set map[string]interface{}, opts ...QueryOption) (*Result, error) {
+	o := newQueryOptions(opts)
+	q, a, err := createUpdateWhereQuery(pm, c, set, o)
+	if err != nil {
+		return nil, err
+	}
+	defer invalidateCaches(pm)
+	return o.guard(OperationSave, pm, t, q, a)
+}
+
+// MARK: Non-exported functions
+
+// createDeleteWhereQuery creates a query deleting the rows of pm's table that
+// match the condition, c, and returns it with its parameters.
+func createDeleteWhereQuery(pm KeyedModel, c *Condition, o *queryOptions) (string, []interface{}, error) {
+	if c == nil {
+		return "", nil, fmt.Errorf("pgmodel: DeleteWhere requires a condition")
+	}
+
+	c.apply(o)
+	ps, a, err := o.predicates(pm)
+	if err != nil {
+		return "", nil, err
+	}
+	if dc := o.deletedAtColumn(pm); dc != "" {
+		return fmt.Sprintf(
+			`UPDATE %s
+			SET %s = now()
+			WHERE %s`,
+			o.qualifiedName(pm),
+			quoteIdent(dc),
+			strings.Join(ps, " AND "),
+		), a, nil
+	}
+	return fmt.Sprintf(
+		`DELETE FROM %s
+		WHERE %s`,
+		o.qualifiedName(pm),
+		strings.Join(ps, " AND "),
+	), a, nil
+}
+
+// createUpdateWhereQuery creates a query setting the columns of the rows of
+// pm's table that match the condition, c, to the values in set, and returns
+// it with its parameters.
+func createUpdateWhereQuery(pm PGModel, c *Condition, set map[string]interface{}, o *queryOptions) (string, []interface{}, error) {
+	if c == nil {
+		return "", nil, fmt.Errorf("pgmodel: UpdateWhere requires a condition")
+	}
+	if len(set) == 0 {
+		return "", nil, fmt.Errorf("pgmodel: UpdateWhere requires at least one column")
+	}
+
+	// Sort the columns so that the query is deterministic
+	var sc []string
+	for u := range set {
+		sc = append(sc, u)
+	}
+	sort.Strings(sc)
+	if err := validateColumns(pm, sc); err != nil {
+		return "", nil, err
+	}
+
+	// Updated columns are set to the time of the transaction instead
+	_, uc := timestampColumns(pm)
+	var sm []string
+	var a []interface{}
+	for _, u := range sc {
+		if u != uc {
+			sm = append(sm, fmt.Sprintf("%s = ?", quoteIdent(u)))
+			a = append(a, convertVariable(pm, set[u], u))
+		}
+	}
+	if uc != "" {
+		sm = append(sm, fmt.Sprintf("%s = now()", quoteIdent(uc)))
+	}
+
+	c.apply(o)
+	ps, pa, err := o.predicates(pm)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf(
+		`UPDATE %s
+		SET %s
+		WHERE %s`,
+		o.qualifiedName(pm),
+		strings.Join(sm, ", "),
+		strings.Join(ps, " AND "),
+	), append(a, pa...), nil
+}
+
+// guard performs the operation, op, with the query, q, and its parameters, a,
+// rolling it back and returning a *RowCountError if it affects more rows than
+// the options allow.
+func (o *queryOptions) guard(op Operation, pm TableDescriber, t *pg.Tx, q string, a []interface{}) (*Result, error) {
+	exec := func() (orm.Result, error) {
+		return runContext(o.context(), op, pm, t, func() (orm.Result, error) {
+			return o.withSettings(t, func() (orm.Result, error) {
+				return t.ExecContext(o.context(), q, a...)
+			})
+		})
+	}
+	if o.expectRows == nil && o.maxRows <= 0 {
+		res, err := exec()
+		return newResult(res, q), err
+	}
+
+	var res orm.Result
+	err := savepoint(t, func() error {
+		var err error
+		if res, err = exec(); err != nil {
+			return err
+		}
+		return o.checkAffected(op, pm, res.RowsAffected())
+	})
+	return newResult(res, q), err
+}
+
+// checkAffected returns a *RowCountError if n rows affected by the operation,
+// op, on pm's table differs from the expected number of rows, or exceeds the
+// maximum.
+func (o *queryOptions) checkAffected(op Operation, pm TableDescriber, n int) error {
+	e := &RowCountError{
+		Operation: op,
+		Schema:    o.schemaName(pm),
+		Table:     o.tableName(pm),
+		Rows:      n,
+	}
+	switch {
+	case o.expectRows != nil && n != *o.expectRows:
+		e.Max = *o.expectRows
+		e.Exact = true
+		return e
+	case o.maxRows > 0 && n > o.maxRows:
+		e.Max = o.maxRows
+		return e
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package pgmodel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestCreateUpdateWhereQuery(t *testing.T) {
+	q, a, err := createUpdateWhereQuery(&stampedModel{}, Where("id", Lt, 10), map[string]interface{}{
+		"name":       "n",
+		"updated_at": "ignored",
+	}, new(queryOptions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE "test"."stamped" SET "name" = ?, "updated_at" = now() WHERE ("id" < ?)`
+	if q := squash(q); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+	if len(a) != 2 || a[0] != "n" || a[1] != 10 {
+		t.Errorf("got parameters %v", a)
+	}
+
+	// A condition and columns are required
+	if _, _, err := createUpdateWhereQuery(&stampedModel{}, nil, map[string]interface{}{"name": "n"}, new(queryOptions)); err == nil {
+		t.Error("expected an error without a condition")
+	}
+	if _, _, err := createUpdateWhereQuery(&stampedModel{}, Where("id", Eq, 1), nil, new(queryOptions)); err == nil {
+		t.Error("expected an error without columns")
+	}
+}
+
+func TestDeleteWhereExpectRows(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx, `INSERT INTO test.models (id, name) VALUES (1, 'a'), (2, 'a'), (3, 'b')`)
+
+	// Unexpected deletions are rolled back
+	var rce *RowCountError
+	if _, err := DeleteWhere(&testModel{}, tx, Where("name", Eq, "a"), WithExpectRows(1)); !errors.As(err, &rce) || rce.Rows != 2 {
+		t.Fatalf("got error %v, want a *RowCountError for 2 rows", err)
+	}
+	var n int
+	if _, err := tx.QueryOne(pg.Scan(&n), `SELECT count(*) FROM test.models`); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("got %d rows after rolling back, want 3", n)
+	}
+
+	res, err := UpdateWhere(&testModel{}, tx, Where("name", Eq, "b"), map[string]interface{}{"name": "c"}, WithExpectRows(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsAffected() != 1 || !strings.HasPrefix(squash(res.SQL()), "UPDATE") {
+		t.Errorf("got %d rows affected by %q", res.RowsAffected(), res.SQL())
+	}
+}
+
+func TestCheckAffected(t *testing.T) {
+	o := newQueryOptions([]QueryOption{WithExpectRows(1)})
+	if err := o.checkAffected(OperationDelete, &testModel{}, 1); err != nil {
+		t.Errorf("got error %v for the expected rows", err)
+	}
+	var rce *RowCountError
+	if err := o.checkAffected(OperationDelete, &testModel{}, 0); !errors.As(err, &rce) || !rce.Exact || rce.Max != 1 {
+		t.Errorf("got error %v, want an exact *RowCountError", err)
+	}
+
+	o = newQueryOptions([]QueryOption{WithMaxRows(2)})
+	if err := o.checkAffected(OperationSave, &testModel{}, 2); err != nil {
+		t.Errorf("got error %v within the maximum", err)
+	}
+	if err := o.checkAffected(OperationSave, &testModel{}, 3); !errors.As(err, &rce) || rce.Exact || rce.Rows != 3 {
+		t.Errorf("got error %v, want a *RowCountError", err)
+	}
+}
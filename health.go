@@ -0,0 +1,111 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// HealthError is returned by Health when the database is reachable but one or
+// more checks failed.
+type HealthError struct {
+
+	// Descriptions of each failed check.
+	Problems []string
+}
+
+// Error returns the problems joined in to a single message.
+func (e *HealthError) Error() string {
+	return "pgmodel: unhealthy: " + strings.Join(e.Problems, "; ")
+}
+
+// MARK: Exported functions
+
+// Health verifies that db is reachable, that its search_path resolves to an
+// existing schema, and that the schema and table of each model exist. It is
+// suitable for use in readiness probes.
+//
+// If the database can't be reached, the error from the connection attempt is
+// returned. Otherwise, all failed checks are reported in a *HealthError.
+func Health(ctx context.Context, db *pg.DB, models ...PGModel) error {
+	if err := db.Ping(ctx); err != nil {
+		return err
+	}
+
+	var problems []string
+
+	// Check the search_path
+	var cs *string
+	if _, err := db.QueryOneContext(ctx, pg.Scan(&cs), `SELECT current_schema()`); err != nil {
+		return err
+	}
+	if cs == nil {
+		problems = append(problems, "search_path does not contain an existing schema")
+	}
+
+	// Check each model, only reporting a missing schema once
+	missing := make(map[string]bool)
+	for _, pm := range models {
+		sn := pm.SchemaName()
+		tn := pm.TableName()
+
+		if _, ok := missing[sn]; !ok {
+			exists, err := schemaExists(ctx, db, sn)
+			if err != nil {
+				return err
+			}
+			missing[sn] = !exists
+			if !exists {
+				problems = append(problems, fmt.Sprintf("schema %s does not exist", sn))
+			}
+		}
+		if missing[sn] {
+			continue
+		}
+
+		exists, err := tableExists(ctx, db, sn, tn)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			problems = append(problems, fmt.Sprintf("table %s.%s does not exist", sn, tn))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &HealthError{Problems: problems}
+	}
+	return nil
+}
+
+// MARK: Non-exported functions
+
+// schemaExists returns whether the schema, sn, exists.
+func schemaExists(ctx context.Context, db *pg.DB, sn string) (bool, error) {
+	var exists bool
+	_, err := db.QueryOneContext(ctx, pg.Scan(&exists),
+		`SELECT EXISTS (
+			SELECT 1 FROM pg_catalog.pg_namespace
+			WHERE nspname = ?
+		)`,
+		sn,
+	)
+	return exists, err
+}
+
+// tableExists returns whether the table, tn, exists in the schema, sn.
+func tableExists(ctx context.Context, db *pg.DB, sn string, tn string) (bool, error) {
+	var exists bool
+	_, err := db.QueryOneContext(ctx, pg.Scan(&exists),
+		`SELECT EXISTS (
+			SELECT 1 FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = ? AND c.relname = ?
+		)`,
+		sn,
+		tn,
+	)
+	return exists, err
+}
@@ -0,0 +1,39 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// missingModel is a model of a table in a schema that doesn't exist.
+type missingModel struct {
+	Base[missingModel] `pgmodel:"pgmodel_missing.missing"`
+	ID                 int `pg:"id,pk"`
+}
+
+func TestHealthError(t *testing.T) {
+	err := &HealthError{Problems: []string{"a", "b"}}
+	if got := err.Error(); got != "pgmodel: unhealthy: a; b" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestHealthUnreachable(t *testing.T) {
+	err := Health(context.Background(), unreachableDB(t), &testModel{})
+	var he *HealthError
+	if err == nil || errors.As(err, &he) {
+		t.Errorf("got %v, want the connection error", err)
+	}
+}
+
+func TestHealthMissingSchema(t *testing.T) {
+	err := Health(context.Background(), testDB(t), &missingModel{}, &missingModel{})
+	var he *HealthError
+	if !errors.As(err, &he) {
+		t.Fatalf("got %v, want a *HealthError", err)
+	}
+	if len(he.Problems) != 1 || he.Problems[0] != "schema pgmodel_missing does not exist" {
+		t.Errorf("got problems %v", he.Problems)
+	}
+}
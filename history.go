@@ -0,0 +1,174 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+const (
+	// HistorySuffix is appended to a model's table name to name its history
+	// table.
+	HistorySuffix = "_history"
+
+	// HistoryRangeColumn is the tstzrange column of a history table holding the
+	// period during which each row version was current.
+	HistoryRangeColumn = "valid_during"
+)
+
+// MARK: Exported functions
+
+// EnableHistory creates a history table for pm's table, if one doesn't already
+// exist, and installs triggers that record every version of every row in it.
+//
+// The history table has the same columns as the model's table plus
+// HistoryRangeColumn. Inserts and updates add a version that is valid from the
+// time of the transaction, and updates and deletes end the previous version's
+// validity at the same time.
+//
+// Columns added to the model's table must also be added to its history table,
+// before HistoryRangeColumn, for the triggers to continue working.
+func EnableHistory(pm PGModel, t *pg.Tx) error {
+	// Get everything once
+	pk := pm.PrimaryKey()
+	sn := pm.SchemaName()
+	tn := pm.TableName()
+	htn := tn + HistorySuffix
+	qn := qualify(sn, tn)
+	hqn := qualify(sn, htn)
+	fn := qualify(sn, htn+"_fn")
+
+	qs := []string{
+		fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s (
+				LIKE %s,
+				%s tstzrange NOT NULL
+			)`,
+			hqn,
+			qn,
+			HistoryRangeColumn,
+		),
+		fmt.Sprintf(
+			`CREATE INDEX IF NOT EXISTS %s ON %s (%s)`,
+			quoteIdent(htn+"_"+pk+"_idx"),
+			hqn,
+			quoteIdent(pk),
+		),
+		fmt.Sprintf(
+			`CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+			BEGIN
+				IF TG_OP IN ('UPDATE', 'DELETE') THEN
+					UPDATE %s
+					SET %s = tstzrange(lower(%s), now())
+					WHERE %s = OLD.%s AND upper_inf(%s);
+				END IF;
+				IF TG_OP IN ('INSERT', 'UPDATE') THEN
+					INSERT INTO %s SELECT NEW.*, tstzrange(now(), NULL);
+				END IF;
+				RETURN NULL;
+			END
+			$$ LANGUAGE plpgsql`,
+			fn,
+			hqn,
+			HistoryRangeColumn, HistoryRangeColumn,
+			quoteIdent(pk), quoteIdent(pk), HistoryRangeColumn,
+			hqn,
+		),
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS %s ON %s`, quoteIdent(htn), qn),
+		fmt.Sprintf(
+			`CREATE TRIGGER %s
+			AFTER INSERT OR UPDATE OR DELETE ON %s
+			FOR EACH ROW EXECUTE PROCEDURE %s()`,
+			quoteIdent(htn),
+			qn,
+			fn,
+		),
+	}
+
+	for _, q := range qs {
+		if _, err := t.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisableHistory removes the triggers installed by EnableHistory from pm's
+// table. The history table and its contents are kept.
+func DisableHistory(pm PGModel, t *pg.Tx) error {
+	// Get everything once
+	sn := pm.SchemaName()
+	tn := pm.TableName()
+	htn := tn + HistorySuffix
+
+	qs := []string{
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS %s ON %s`, quoteIdent(htn), qualify(sn, tn)),
+		fmt.Sprintf(`DROP FUNCTION IF EXISTS %s()`, qualify(sn, htn+"_fn")),
+	}
+
+	for _, q := range qs {
+		if _, err := t.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAsOf gets the version of pm's row, identified by its primary key value,
+// that was current at the time, ts, from its history table.
+//
+// Like Get, an error is returned if there was no such version.
+func GetAsOf(pm PGModel, t Executor, ts time.Time) (*Result, error) {
+	return GetAsOfContext(context.Background(), pm, t, ts)
+}
+
+// GetAsOfContext is identical to GetAsOf but performs its query with the
+// context, ctx, so that it can be cancelled or given a deadline.
+func GetAsOfContext(ctx context.Context, pm PGModel, t Executor, ts time.Time) (*Result, error) {
+	q := createHistoryQuery(pm, fmt.Sprintf("AND %s @> ?::timestamptz", HistoryRangeColumn))
+	res, err := runContext(ctx, OperationGet, pm, t, func() (orm.Result, error) {
+		res, err := t.QueryOneContext(ctx, pm, q, pm.PrimaryKeyValue(), ts.UTC())
+		normalizeTimes(pm)
+		return res, err
+	})
+	return newResult(res, q), err
+}
+
+// GetHistory gets every version of pm's row, identified by its primary key
+// value, from its history table in to dst, which must be a pointer to a slice
+// of models. Versions are ordered from oldest to newest.
+func GetHistory(dst interface{}, pm PGModel, t Executor) (*Result, error) {
+	return GetHistoryContext(context.Background(), dst, pm, t)
+}
+
+// GetHistoryContext is identical to GetHistory but performs its query with the
+// context, ctx, so that it can be cancelled or given a deadline.
+func GetHistoryContext(ctx context.Context, dst interface{}, pm PGModel, t Executor) (*Result, error) {
+	q := createHistoryQuery(pm, fmt.Sprintf("ORDER BY lower(%s)", HistoryRangeColumn))
+	res, err := runContext(ctx, OperationGetMany, pm, t, func() (orm.Result, error) {
+		res, err := t.QueryContext(ctx, dst, q, pm.PrimaryKeyValue())
+		normalizeTimes(dst)
+		return res, err
+	})
+	return newResult(res, q), err
+}
+
+// MARK: Non-exported functions
+
+// createHistoryQuery creates a query selecting the model's columns from the
+// versions of its row in its history table, followed by the clause, c.
+func createHistoryQuery(pm PGModel, c string) string {
+	// Create the query
+	return fmt.Sprintf(
+		`SELECT %s FROM %s
+		WHERE %s = ?
+		%s`,
+		quoteList(columns(pm)),
+		qualify(pm.SchemaName(), pm.TableName()+HistorySuffix),
+		quoteIdent(pm.PrimaryKey()),
+		c,
+	)
+}
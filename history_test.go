@@ -0,0 +1,110 @@
+package pgmodel
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// createModelsTable creates the test.models table of testModel.
+func createModelsTable(t *testing.T, e Executor) {
+	t.Helper()
+	testExec(t, e,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.models (id int PRIMARY KEY, name text, tags text[])`,
+	)
+}
+
+func TestEnableHistory(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	if err := EnableHistory(&testModel{}, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &testModel{ID: 1, Name: "one"}
+	if _, err := Save(m, tx); err != nil {
+		t.Fatal(err)
+	}
+	m.Name = "two"
+	if _, err := Save(m, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	var versions, current int
+	if _, err := tx.QueryOne(pg.Scan(&versions, &current),
+		`SELECT count(*), count(*) FILTER (WHERE upper_inf(valid_during)) FROM test.models_history`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if versions != 2 || current != 1 {
+		t.Errorf("got %d versions with %d current, want 2 with 1 current", versions, current)
+	}
+
+	// Disabling history stops recording versions
+	if err := DisableHistory(&testModel{}, tx); err != nil {
+		t.Fatal(err)
+	}
+	m.Name = "three"
+	if _, err := Save(m, tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.QueryOne(pg.Scan(&versions), `SELECT count(*) FROM test.models_history`); err != nil {
+		t.Fatal(err)
+	}
+	if versions != 2 {
+		t.Errorf("got %d versions after disabling history, want 2", versions)
+	}
+}
+
+func TestGetAsOf(t *testing.T) {
+	e := new(testExecutor)
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 60*60))
+	if _, err := GetAsOf(&testModel{ID: 1}, e, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `SELECT "id", "name", "tags" FROM "test"."models_history" WHERE "id" = ? AND valid_during @> ?::timestamptz`
+	if q := squash(e.last().query); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+	if p := e.last().params; len(p) != 2 || p[0] != 1 || p[1] != ts.UTC() {
+		t.Errorf("got parameters %v", p)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	e := new(testExecutor)
+	var ms []*testModel
+	if _, err := GetHistory(&ms, &testModel{ID: 1}, e); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasSuffix(q, `WHERE "id" = ? ORDER BY lower(valid_during)`) {
+		t.Errorf("got query %q", q)
+	}
+}
+
+func TestHistoryContextVariants(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	e := new(testExecutor)
+
+	if _, err := GetAsOfContext(ctx, &testModel{ID: 1}, e, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	var ms []*testModel
+	if _, err := GetHistoryContext(ctx, &ms, &testModel{ID: 1}, e); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range e.queries {
+		if q.ctx.Value(key{}) != "request" {
+			t.Errorf("query %q wasn't performed with the context", squash(q.query))
+		}
+	}
+	if e.count() != 2 {
+		t.Errorf("got %d queries, want 2", e.count())
+	}
+}
@@ -0,0 +1,120 @@
+package pgmodel
+
+import (
+	"context"
+	"reflect"
+)
+
+// BeforeSaver types are models with a hook called before the model's values
+// are read by each function that writes a model's row: Save, SaveFold, SaveIf,
+// SaveIdempotent, SaveWithUniqueSlug, SaveByKey, Insert, InsertIfNoOverlap,
+// SaveIgnore, Update, UpdateByPK and SaveNewVersion, and for each model by
+// SaveAll, SaveMany, SaveAllConcurrent and CopyFrom, along with their Context
+// variants. It's used such as to set an updated_at column or normalize fields.
+// If the hook returns an error, nothing is saved and the error is returned.
+//
+// Functions that write rows without a model, such as UpdateWhere, AddMoney
+// and MergeTempTable, don't call the hook.
+type BeforeSaver interface {
+	BeforeSave(ctx context.Context, t Executor) error
+}
+
+// AfterSaver types are models with a hook called after the model's row is
+// written by the functions that call BeforeSave, such as to record a domain
+// event with the same executor. It isn't called if the row wasn't written,
+// such as when Insert returns ErrRowExists, SaveIgnore leaves an existing row
+// unchanged or a batch's duplicate policy skips the model. If the hook returns
+// an error, it's returned along with the save's result.
+//
+// SaveAllConcurrent calls BeforeSave with its database and AfterSave with the
+// transaction that wrote the model's chunk.
+type AfterSaver interface {
+	AfterSave(ctx context.Context, t Executor) error
+}
+
+// BeforeDeleter types are models with a hook called by Delete before the
+// model is deleted. If the hook returns an error, the model isn't deleted and
+// the error is returned.
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context, t Executor) error
+}
+
+// AfterDeleter types are models with a hook called by Delete after the model
+// is deleted. If the hook returns an error, it's returned along with the
+// deletion's result.
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context, t Executor) error
+}
+
+// AfterGetter types are models with a hook called by Get, GetMany and
+// GetManyInto for each model they scan, such as to derive fields from the
+// model's columns. If the hook returns an error, it's returned along with the
+// query's result.
+type AfterGetter interface {
+	AfterGet(ctx context.Context, t Executor) error
+}
+
+// MARK: Non-exported functions
+
+// beforeSave calls pm's BeforeSave hook, if it has one.
+func beforeSave(ctx context.Context, pm PGModel, t Executor) error {
+	if h, ok := pm.(BeforeSaver); ok {
+		return h.BeforeSave(ctx, t)
+	}
+	return nil
+}
+
+// afterSave calls pm's AfterSave hook, if it has one.
+func afterSave(ctx context.Context, pm PGModel, t Executor) error {
+	if h, ok := pm.(AfterSaver); ok {
+		return h.AfterSave(ctx, t)
+	}
+	return nil
+}
+
+// beforeDelete calls pm's BeforeDelete hook, if it has one.
+func beforeDelete(ctx context.Context, pm PGModel, t Executor) error {
+	if h, ok := pm.(BeforeDeleter); ok {
+		return h.BeforeDelete(ctx, t)
+	}
+	return nil
+}
+
+// afterDelete calls pm's AfterDelete hook, if it has one.
+func afterDelete(ctx context.Context, pm PGModel, t Executor) error {
+	if h, ok := pm.(AfterDeleter); ok {
+		return h.AfterDelete(ctx, t)
+	}
+	return nil
+}
+
+// afterGet calls the AfterGet hook of v, or of each element of v if it's a
+// pointer to a slice, stopping at the first error.
+func afterGet(ctx context.Context, v interface{}, t Executor) error {
+	if h, ok := v.(AfterGetter); ok {
+		return h.AfterGet(ctx, t)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return nil
+	}
+	rv = rv.Elem()
+	for i := 0; i < rv.Len(); i++ {
+		e := rv.Index(i)
+		switch e.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if e.IsNil() {
+				continue
+			}
+		default:
+			e = e.Addr()
+		}
+		if h, ok := e.Interface().(AfterGetter); ok {
+			if err := h.AfterGet(ctx, t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// hookedModel is a model of the test.hooked table that records its hook
+// calls.
+type hookedModel struct {
+	Base[hookedModel] `pgmodel:"test.hooked"`
+	ID                int    `pg:"id,pk"`
+	Name              string `pg:"name"`
+	Slug              string `pg:"-"`
+
+	calls []string
+	err   error
+}
+
+func (m *hookedModel) BeforeSave(ctx context.Context, t Executor) error {
+	m.calls = append(m.calls, "BeforeSave")
+	m.Name = strings.TrimSpace(m.Name)
+	return m.err
+}
+
+func (m *hookedModel) AfterSave(ctx context.Context, t Executor) error {
+	m.calls = append(m.calls, "AfterSave")
+	return nil
+}
+
+func (m *hookedModel) BeforeDelete(ctx context.Context, t Executor) error {
+	m.calls = append(m.calls, "BeforeDelete")
+	return m.err
+}
+
+func (m *hookedModel) AfterDelete(ctx context.Context, t Executor) error {
+	m.calls = append(m.calls, "AfterDelete")
+	return nil
+}
+
+func (m *hookedModel) AfterGet(ctx context.Context, t Executor) error {
+	m.Slug = strings.ToLower(m.Name)
+	return nil
+}
+
+func TestSaveAndDeleteHooks(t *testing.T) {
+	e := new(testExecutor)
+	m := &hookedModel{ID: 1, Name: " One "}
+	if _, err := Save(m, e); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Delete(m, e); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"BeforeSave", "AfterSave", "BeforeDelete", "AfterDelete"}; !reflect.DeepEqual(m.calls, want) {
+		t.Errorf("got calls %v, want %v", m.calls, want)
+	}
+
+	// BeforeSave is called before the model's values are read
+	if p := e.count(); p != 2 {
+		t.Fatalf("got %d queries, want 2", p)
+	}
+	if a := e.queries[0].params; len(a) < 2 || a[1] != "One" {
+		t.Errorf("got parameters %v, want the trimmed name", a)
+	}
+}
+
+func TestBeforeHookErrorsStopQueries(t *testing.T) {
+	e := new(testExecutor)
+	want := errors.New("invalid")
+	m := &hookedModel{ID: 1, err: want}
+	if _, err := Save(m, e); err != want {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+	if _, err := Delete(m, e); err != want {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+	if n := e.count(); n != 0 {
+		t.Errorf("got %d queries, want none", n)
+	}
+	if want := []string{"BeforeSave", "BeforeDelete"}; !reflect.DeepEqual(m.calls, want) {
+		t.Errorf("got calls %v, want %v", m.calls, want)
+	}
+}
+
+func TestAfterGetHooks(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		switch m := model.(type) {
+		case *hookedModel:
+			m.Name = "One"
+		case *[]*hookedModel:
+			*m = append(*m, &hookedModel{Name: "One"}, nil, &hookedModel{Name: "Two"})
+		}
+		return testResult{returned: 1}, nil
+	}}
+
+	m := new(hookedModel)
+	if _, err := Get(m, e, "id", 1); err != nil {
+		t.Fatal(err)
+	}
+	if m.Slug != "one" {
+		t.Errorf("got slug %q, want one", m.Slug)
+	}
+
+	ms, _, err := GetMany[*hookedModel](e, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 3 || ms[0].Slug != "one" || ms[2].Slug != "two" {
+		t.Errorf("got models %+v", ms)
+	}
+}
+
+// writes are the functions writing a model's row with an executor, keyed by
+// name.
+var writes = map[string]func(m *hookedModel, e Executor) error{
+	"Insert": func(m *hookedModel, e Executor) error {
+		_, err := Insert(m, e)
+		return err
+	},
+	"SaveIgnore": func(m *hookedModel, e Executor) error {
+		_, err := SaveIgnore(m, e)
+		return err
+	},
+	"UpdateByPK": func(m *hookedModel, e Executor) error {
+		_, err := UpdateByPK(m, e)
+		return err
+	},
+	"SaveByKey": func(m *hookedModel, e Executor) error {
+		_, err := SaveByKey(m, e, "name")
+		return err
+	},
+	"Update": func(m *hookedModel, e Executor) error {
+		_, err := Update(m, e, "name")
+		return err
+	},
+	"SaveIf": func(m *hookedModel, e Executor) error {
+		_, _, err := SaveIf(m, e, "name", "a")
+		return err
+	},
+}
+
+func TestWriteHooks(t *testing.T) {
+	for name, write := range writes {
+		e := new(testExecutor)
+		m := &hookedModel{ID: 1, Name: " One "}
+		if err := write(m, e); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if want := []string{"BeforeSave", "AfterSave"}; !reflect.DeepEqual(m.calls, want) {
+			t.Errorf("%s: got calls %v, want %v", name, m.calls, want)
+		}
+
+		// AfterSave isn't called if no row was written, which upserts always do
+		if name == "SaveByKey" {
+			continue
+		}
+		e.handle = func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+			return testResult{}, nil
+		}
+		m.calls = nil
+		_ = write(m, e)
+		if want := []string{"BeforeSave"}; !reflect.DeepEqual(m.calls, want) {
+			t.Errorf("%s: got calls %v without a row written, want %v", name, m.calls, want)
+		}
+	}
+}
+
+func TestBeforeHookErrorsStopWrites(t *testing.T) {
+	want := errors.New("invalid")
+	for name, write := range writes {
+		e := new(testExecutor)
+		m := &hookedModel{ID: 1, err: want}
+		if err := write(m, e); err != want {
+			t.Errorf("%s: got error %v, want %v", name, err, want)
+		}
+		if n := e.count(); n != 0 {
+			t.Errorf("%s: got %d queries, want none", name, n)
+		}
+	}
+}
+
+func TestBatchHooks(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.hooked (id int PRIMARY KEY, name text)`,
+	)
+
+	for name, save := range map[string]func(pms []PGModel) (*Result, error){
+		"SaveAll": func(pms []PGModel) (*Result, error) {
+			return SaveAll(pms, tx)
+		},
+		"SaveAll with savepoints": func(pms []PGModel) (*Result, error) {
+			return SaveAll(pms, tx, WithSavepoints())
+		},
+		"CopyFrom": func(pms []PGModel) (*Result, error) {
+			return CopyFrom(pms, tx)
+		},
+	} {
+		ms := []*hookedModel{{ID: 1, Name: " one "}, {ID: 2, Name: " two "}}
+		if _, err := save([]PGModel{ms[0], ms[1]}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, m := range ms {
+			if want := []string{"BeforeSave", "AfterSave"}; !reflect.DeepEqual(m.calls, want) {
+				t.Errorf("%s: got calls %v, want %v", name, m.calls, want)
+			}
+		}
+
+		// BeforeSave is called before the models' values are read
+		m := new(hookedModel)
+		if _, err := Get(m, tx, "id", 2); err != nil {
+			t.Fatal(err)
+		}
+		if m.Name != "two" {
+			t.Errorf("%s: got name %q, want the trimmed name", name, m.Name)
+		}
+	}
+}
+
+func TestSaveAllConcurrentHooks(t *testing.T) {
+	db := testDB(t)
+	testExec(t, db,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`DROP TABLE IF EXISTS test.hooked`,
+		`CREATE TABLE test.hooked (id int PRIMARY KEY, name text)`,
+	)
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DROP TABLE IF EXISTS test.hooked`)
+	})
+
+	var pms []PGModel
+	var ms []*hookedModel
+	for i := 1; i <= 10; i++ {
+		m := &hookedModel{ID: i, Name: "a"}
+		ms = append(ms, m)
+		pms = append(pms, m)
+	}
+	if _, err := SaveAllConcurrent(context.Background(), db, pms, WithChunkSize(3), WithWorkers(2)); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ms {
+		if want := []string{"BeforeSave", "AfterSave"}; !reflect.DeepEqual(m.calls, want) {
+			t.Errorf("got calls %v for model %d, want %v", m.calls, m.ID, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package pgmodel
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// IdempotencyTable is the table SaveIdempotent records processed keys in. It
+// is created by CreateIdempotencyTable.
+const IdempotencyTable = "public.pgmodel_idempotency_keys"
+
+// MARK: Exported functions
+
+// CreateIdempotencyTable creates IdempotencyTable if it doesn't exist. It should
+// be called once, e.g. when migrating the database, before SaveIdempotent is
+// used.
+func CreateIdempotencyTable(t Executor) error {
+	_, err := t.Exec(fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+			key text PRIMARY KEY,
+			table_name text NOT NULL,
+			primary_key text,
+			created_at timestamptz NOT NULL DEFAULT now()
+		)`,
+		IdempotencyTable,
+	))
+	return err
+}
+
+// SaveIdempotent saves pm in the given transaction at most once for the
+// idempotency key, key, so that a save can be safely retried after a network
+// failure leaves the outcome of its commit unknown.
+//
+// The key is recorded in IdempotencyTable in the same transaction as the save.
+// If the key has already been recorded, nothing is saved and pm is instead
+// loaded with the row that was saved when the key was first processed. The
+// returned bool is true if pm was saved by this call.
+//
+// Concurrent calls with the same key wait for each other, so only one of them
+// saves.
+//
+// IdempotencyTable must have been created with CreateIdempotencyTable.
+func SaveIdempotent(pm PGModel, t *pg.Tx, key string) (bool, *Result, error) {
+	// Record the table the key is used for
+	qn := pm.TableName()
+	if sn := pm.SchemaName(); sn != "" {
+		qn = sn + "." + qn
+	}
+
+	// Claim the key
+	cres, err := t.Exec(fmt.Sprintf(
+		`INSERT INTO %s (key, table_name)
+		VALUES (?, ?)
+		ON CONFLICT (key) DO NOTHING`,
+		IdempotencyTable,
+	), key, qn)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Save the model the first time the key is seen
+	if cres.RowsAffected() > 0 {
+		res, err := Save(pm, t)
+		if err != nil {
+			return false, nil, err
+		}
+		_, err = t.Exec(fmt.Sprintf(
+			`UPDATE %s SET primary_key = ?::text WHERE key = ?`,
+			IdempotencyTable,
+		), convertVariable(pm, pm.PrimaryKeyValue(), pm.PrimaryKey()), key)
+		if err != nil {
+			return false, nil, err
+		}
+		return true, res, nil
+	}
+
+	// Otherwise load the row that was saved
+	var k struct {
+		TableName  string
+		PrimaryKey string
+	}
+	_, err = t.QueryOne(&k, fmt.Sprintf(
+		`SELECT table_name, primary_key FROM %s WHERE key = ?`,
+		IdempotencyTable,
+	), key)
+	if err != nil {
+		return false, nil, err
+	}
+	if k.TableName != qn {
+		return false, nil, fmt.Errorf("pgmodel: idempotency key %q was used for %s", key, k.TableName)
+	}
+
+	res, err := Get(pm, t, quoteIdent(pm.PrimaryKey()), k.PrimaryKey)
+	return false, res, err
+}
@@ -0,0 +1,70 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateIdempotencyTable(t *testing.T) {
+	e := new(testExecutor)
+	if err := CreateIdempotencyTable(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.count() != 1 {
+		t.Fatalf("performed %d queries, want 1", e.count())
+	}
+	if q := squash(e.last().query); !strings.HasPrefix(q, "CREATE TABLE IF NOT EXISTS "+IdempotencyTable+" (") {
+		t.Errorf("got %q", q)
+	}
+}
+
+func TestSaveIdempotent(t *testing.T) {
+	tx := testTx(t)
+	if err := CreateIdempotencyTable(tx); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE IF NOT EXISTS test.models (id int PRIMARY KEY, name text, tags text[])`,
+	} {
+		if _, err := tx.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saved, _, err := SaveIdempotent(&testModel{ID: 1, Name: "first"}, tx, "pgmodel-test-key")
+	if err != nil || !saved {
+		t.Fatalf("got %v, %v on the first save", saved, err)
+	}
+
+	m := &testModel{ID: 1, Name: "second"}
+	saved, _, err = SaveIdempotent(m, tx, "pgmodel-test-key")
+	if err != nil || saved {
+		t.Fatalf("got %v, %v on the second save", saved, err)
+	}
+	if m.Name != "first" {
+		t.Errorf("got %q, want the first save's row", m.Name)
+	}
+}
+
+func TestSaveIdempotentOtherTable(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx, `CREATE TABLE test.cached (id int PRIMARY KEY, name text)`)
+	if err := CreateIdempotencyTable(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	if saved, _, err := SaveIdempotent(&testModel{ID: 1, Name: "first"}, tx, "pgmodel-test-key"); err != nil || !saved {
+		t.Fatalf("got %v, %v on the first save", saved, err)
+	}
+
+	// The key can't be reused for another table
+	saved, _, err := SaveIdempotent(&cachedModel{ID: 1, Name: "other"}, tx, "pgmodel-test-key")
+	if err == nil || saved {
+		t.Fatalf("got %v, %v, want an error reusing the key", saved, err)
+	}
+	if !strings.Contains(err.Error(), "test.models") {
+		t.Errorf("got %v, want the key's table", err)
+	}
+}
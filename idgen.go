@@ -0,0 +1,227 @@
+package pgmodel
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// IDGenerator types create new primary key values.
+type IDGenerator interface {
+
+	// Returns a new, unique primary key value.
+	NewID() (interface{}, error)
+}
+
+// PrimaryKeySetter types can have their primary key value set. Models must
+// implement PrimaryKeySetter to have IDs generated for them.
+type PrimaryKeySetter interface {
+
+	// Sets the value of the model's primary key.
+	SetPrimaryKeyValue(v interface{}) error
+}
+
+// generators holds the ID generators set by SetIDGenerator.
+var generators = struct {
+	sync.RWMutex
+	models map[reflect.Type]IDGenerator
+}{
+	models: make(map[reflect.Type]IDGenerator),
+}
+
+// MARK: Exported functions
+
+// SetIDGenerator sets the generator used to create primary key values for
+// models of pm's type. When such a model is saved with an empty (nil or zero)
+// primary key value, a new value is created with g and set with the model's
+// SetPrimaryKeyValue method before the query is performed.
+//
+// A nil generator removes the model's generator.
+func SetIDGenerator(pm PGModel, g IDGenerator) {
+	generators.Lock()
+	defer generators.Unlock()
+
+	rt := reflect.TypeOf(pm)
+	if g != nil {
+		generators.models[rt] = g
+	} else {
+		delete(generators.models, rt)
+	}
+}
+
+// MARK: Non-exported functions
+
+// assignID sets a new primary key value on pm if it has an ID generator and
+// its primary key value is empty.
+func assignID(pm PGModel) error {
+	generators.RLock()
+	g, ok := generators.models[reflect.TypeOf(pm)]
+	generators.RUnlock()
+
+	if !ok || !isEmpty(pm.PrimaryKeyValue()) {
+		return nil
+	}
+
+	s, ok := pm.(PrimaryKeySetter)
+	if !ok {
+		return fmt.Errorf("pgmodel: %T has an ID generator but does not implement PrimaryKeySetter", pm)
+	}
+
+	id, err := g.NewID()
+	if err != nil {
+		return err
+	}
+	return s.SetPrimaryKeyValue(id)
+}
+
+// isEmpty returns whether v is nil or the zero value of its type.
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv.IsZero()
+}
+
+// MARK: ULID
+
+// crockford is the Crockford base32 alphabet used to encode ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator creates lexicographically sortable, 26 character ULID strings.
+//
+// IDs created within the same millisecond increment the random component of
+// the previous ID, so IDs from a single generator are strictly increasing.
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	entropy io.Reader
+	ms      uint64
+	last    [16]byte
+}
+
+// NewULIDGenerator returns a ULID generator that reads its random component
+// from crypto/rand.
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{entropy: rand.Reader}
+}
+
+// NewID returns a new ULID string.
+func (g *ULIDGenerator) NewID() (interface{}, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	if ms <= g.ms {
+		// Increment the random component of the previous ID
+		i := len(g.last) - 1
+		for ; i >= 6; i-- {
+			g.last[i]++
+			if g.last[i] != 0 {
+				break
+			}
+		}
+		if i < 6 {
+			return nil, errors.New("pgmodel: ULID random component overflow")
+		}
+	} else {
+		g.ms = ms
+		for i := 0; i < 6; i++ {
+			g.last[i] = byte(ms >> (40 - 8*i))
+		}
+		if _, err := io.ReadFull(g.entropy, g.last[6:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return encodeULID(g.last), nil
+}
+
+// encodeULID encodes the 128-bit ULID, u, in Crockford base32.
+func encodeULID(u [16]byte) string {
+	var b [26]byte
+	var acc uint
+	var bits uint
+	var n int
+
+	// The leading 2 bits are padding
+	acc, bits = 0, 2
+	for _, c := range u {
+		acc = acc<<8 | uint(c)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			b[n] = crockford[(acc>>bits)&31]
+			n++
+		}
+	}
+	return string(b[:])
+}
+
+// MARK: Snowflake
+
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeMaxNode      = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// SnowflakeEpoch is the epoch that Snowflake IDs count milliseconds from,
+// 2020-01-01T00:00:00Z.
+var SnowflakeEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// SnowflakeGenerator creates time-ordered int64 Snowflake IDs made of a 41-bit
+// millisecond timestamp, a 10-bit node number and a 12-bit sequence.
+//
+// Each process generating IDs for the same table must use a distinct node
+// number.
+type SnowflakeGenerator struct {
+	mu   sync.Mutex
+	node int64
+	ms   int64
+	seq  int64
+}
+
+// NewSnowflakeGenerator returns a Snowflake generator for the given node
+// number, which must be between 0 and 1023.
+func NewSnowflakeGenerator(node int64) (*SnowflakeGenerator, error) {
+	if node < 0 || node > snowflakeMaxNode {
+		return nil, fmt.Errorf("pgmodel: snowflake node %d is out of range", node)
+	}
+	return &SnowflakeGenerator{node: node}, nil
+}
+
+// NewID returns a new int64 Snowflake ID.
+func (g *SnowflakeGenerator) NewID() (interface{}, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := time.Since(SnowflakeEpoch).Milliseconds()
+	if ms < g.ms {
+		// Don't move backwards if the clock does
+		ms = g.ms
+	}
+
+	if ms == g.ms {
+		g.seq = (g.seq + 1) & snowflakeMaxSequence
+		if g.seq == 0 {
+			// The sequence is exhausted so wait for the next millisecond
+			for ms <= g.ms {
+				time.Sleep(time.Millisecond / 10)
+				ms = time.Since(SnowflakeEpoch).Milliseconds()
+			}
+		}
+	} else {
+		g.seq = 0
+	}
+	g.ms = ms
+
+	return ms<<(snowflakeNodeBits+snowflakeSequenceBits) | g.node<<snowflakeSequenceBits | g.seq, nil
+}
@@ -0,0 +1,89 @@
+package pgmodel
+
+import (
+	"testing"
+)
+
+// generatedModel is a model of the test.generated table with a string primary
+// key set by an ID generator.
+type generatedModel struct {
+	Base[generatedModel] `pgmodel:"test.generated"`
+	ID                   string `pg:"id,pk"`
+	Name                 string `pg:"name"`
+}
+
+func (m *generatedModel) SetPrimaryKeyValue(v interface{}) error {
+	m.ID = v.(string)
+	return nil
+}
+
+func TestULIDGenerator(t *testing.T) {
+	g := NewULIDGenerator()
+	var last string
+	for i := 0; i < 1000; i++ {
+		v, err := g.NewID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := v.(string)
+		if len(id) != 26 {
+			t.Fatalf("got %q, want 26 characters", id)
+		}
+		if id <= last {
+			t.Fatalf("got %q after %q, want increasing IDs", id, last)
+		}
+		last = id
+	}
+}
+
+func TestSnowflakeGenerator(t *testing.T) {
+	if _, err := NewSnowflakeGenerator(snowflakeMaxNode + 1); err == nil {
+		t.Error("expected an error for an out of range node")
+	}
+
+	g, err := NewSnowflakeGenerator(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last int64
+	for i := 0; i < 10000; i++ {
+		v, err := g.NewID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := v.(int64)
+		if id <= last {
+			t.Fatalf("got %d after %d, want increasing IDs", id, last)
+		}
+		if n := id >> snowflakeSequenceBits & snowflakeMaxNode; n != 7 {
+			t.Fatalf("got node %d, want 7", n)
+		}
+		last = id
+	}
+}
+
+func TestSaveAssignsID(t *testing.T) {
+	SetIDGenerator(&generatedModel{}, NewULIDGenerator())
+	defer SetIDGenerator(&generatedModel{}, nil)
+
+	m := &generatedModel{Name: "one"}
+	e := new(testExecutor)
+	if _, err := Save(m, e); err != nil {
+		t.Fatal(err)
+	}
+	if m.ID == "" {
+		t.Fatal("no ID was assigned")
+	}
+	if p := e.last().params; len(p) == 0 || p[0] != m.ID {
+		t.Errorf("got parameters %v, want the assigned ID first", p)
+	}
+
+	// Models with IDs keep them
+	m = &generatedModel{ID: "kept"}
+	if _, err := Save(m, e); err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != "kept" {
+		t.Errorf("got ID %q, want kept", m.ID)
+	}
+}
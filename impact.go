@@ -0,0 +1,108 @@
+package pgmodel
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// Impact describes the rows a destructive operation would affect.
+type Impact struct {
+
+	// The operation that would be performed.
+	Operation Operation
+
+	// The schema and table names of the operation's model.
+	Schema string
+	Table  string
+
+	// The number of rows the operation would affect.
+	Rows int
+
+	// Whether Rows is the query planner's estimate rather than an exact count.
+	Estimated bool
+}
+
+// MARK: Exported functions
+
+// WithPlanEstimate makes EstimateImpact use the query planner's estimate of
+// the number of rows instead of counting them. The estimate is returned
+// immediately, even for tables too large to count interactively, but is only
+// as accurate as the table's statistics.
+func WithPlanEstimate() QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.planEstimate = true
+	})
+}
+
+// EstimateImpact returns the number of rows of pm's table that the operation,
+// op, would affect if it were performed with the condition, c, by DeleteWhere
+// or UpdateWhere, without changing any rows. It's intended for dry runs in
+// interactive tooling and change approval workflows, e.g.
+//
+//	i, err := pgmodel.EstimateImpact(new(Session), t, pgmodel.OperationDelete, c)
+//	...
+//	fmt.Printf("this will delete %d sessions\n", i.Rows)
+//
+// The op must be OperationDelete or OperationSave, for updates.
+func EstimateImpact(pm KeyedModel, t Executor, op Operation, c *Condition, opts ...QueryOption) (*Impact, error) {
+	if op != OperationDelete && op != OperationSave {
+		return nil, fmt.Errorf("pgmodel: can't estimate the impact of %s", op)
+	}
+	if c == nil {
+		return nil, fmt.Errorf("pgmodel: EstimateImpact requires a condition")
+	}
+
+	o := newQueryOptions(opts)
+	c.apply(o)
+	ps, a, err := o.predicates(pm)
+	if err != nil {
+		return nil, err
+	}
+	i := &Impact{
+		Operation: op,
+		Schema:    o.schemaName(pm),
+		Table:     o.tableName(pm),
+		Estimated: o.planEstimate,
+	}
+
+	if !o.planEstimate {
+		q := fmt.Sprintf(
+			`SELECT count(*) FROM %s
+			WHERE %s`,
+			o.qualifiedName(pm),
+			strings.Join(ps, " AND "),
+		)
+		if _, err := t.QueryOneContext(o.context(), pg.Scan(&i.Rows), q, a...); err != nil {
+			return nil, err
+		}
+		return i, nil
+	}
+
+	// Read the estimate from the plan's root node
+	q := fmt.Sprintf(
+		`EXPLAIN (FORMAT JSON) SELECT 1 FROM %s
+		WHERE %s`,
+		o.qualifiedName(pm),
+		strings.Join(ps, " AND "),
+	)
+	var p string
+	if _, err := t.QueryOneContext(o.context(), pg.Scan(&p), q, a...); err != nil {
+		return nil, err
+	}
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		}
+	}
+	if err := json.Unmarshal([]byte(p), &plans); err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("pgmodel: EXPLAIN returned no plan")
+	}
+	i.Rows = int(plans[0].Plan.Rows)
+	return i, nil
+}
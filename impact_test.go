@@ -0,0 +1,51 @@
+package pgmodel
+
+import "testing"
+
+func TestEstimateImpactCounts(t *testing.T) {
+	e := new(testExecutor)
+	i, err := EstimateImpact(&testModel{}, e, OperationDelete, Where("name", Eq, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Estimated || i.Schema != "test" || i.Table != "models" {
+		t.Errorf("got impact %+v", i)
+	}
+	if q := squash(e.last().query); q != `SELECT count(*) FROM "test"."models" WHERE ("name" = ?)` {
+		t.Errorf("got query %q", q)
+	}
+
+	// Only deletions and updates can be estimated, and they need a condition
+	if _, err := EstimateImpact(&testModel{}, e, OperationGet, Where("name", Eq, "a")); err == nil {
+		t.Error("expected an error for a get")
+	}
+	if _, err := EstimateImpact(&testModel{}, e, OperationSave, nil); err == nil {
+		t.Error("expected an error without a condition")
+	}
+}
+
+func TestEstimateImpactPlan(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx,
+		`INSERT INTO test.models (id, name) SELECT i, 'a' FROM generate_series(1, 100) i`,
+		`ANALYZE test.models`,
+	)
+
+	i, err := EstimateImpact(&testModel{}, tx, OperationSave, Where("name", Eq, "a"), WithPlanEstimate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !i.Estimated || i.Rows != 100 {
+		t.Errorf("got impact %+v, want an estimate of 100 rows", i)
+	}
+
+	// Nothing is changed
+	n, err := EstimateImpact(&testModel{}, tx, OperationDelete, Where("name", Eq, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Rows != 100 {
+		t.Errorf("got %d rows, want 100", n.Rows)
+	}
+}
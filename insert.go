@@ -0,0 +1,126 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// MARK: Exported functions
+
+// Insert inserts pm as a new row with the given executor, returning
+// ErrRowExists, rather than updating the row, if a row with the same primary
+// key value already exists.
+//
+// If the model has an ID generator set by SetIDGenerator and its primary key
+// value is empty, a new value is generated before the query is performed.
+func Insert(pm PGModel, t Executor, opts ...QueryOption) (*Result, error) {
+	res, err := insert(pm, t, "Insert", quoteList(primaryKeys(pm)), opts)
+	if err == nil && res.RowsAffected() == 0 {
+		err = ErrRowExists
+	}
+	return res, err
+}
+
+// SaveIgnore inserts pm as a new row with the given executor, leaving the
+// existing row unchanged if pm conflicts with any of the table's unique
+// constraints, including its primary key. The result's RowsAffected is 1 if
+// the row was inserted and 0 if it already existed, so that pipelines that
+// ingest events more than once can tell new events from repeated ones.
+//
+// If the model has an ID generator set by SetIDGenerator and its primary key
+// value is empty, a new value is generated before the query is performed.
+func SaveIgnore(pm PGModel, t Executor, opts ...QueryOption) (*Result, error) {
+	return insert(pm, t, "SaveIgnore", "", opts)
+}
+
+// UpdateByPK updates pm's existing row with the given executor, returning an
+// error matching ErrNotFound and pg.ErrNoRows, rather than inserting the row,
+// if no row has pm's primary key value.
+func UpdateByPK(pm PGModel, t Executor, opts ...QueryOption) (*Result, error) {
+	o := newQueryOptions(opts)
+	if err := beforeSave(o.context(), pm, t); err != nil {
+		return nil, err
+	}
+	defer InvalidateCache(pm)
+	res, err := update(pm, t, primaryKeyValues(pm), convertVariables(pm), o)
+	switch {
+	case err != nil:
+	case res.RowsAffected() == 0:
+		err = wrapError(pg.ErrNoRows)
+	default:
+		err = afterSave(o.context(), pm, t)
+	}
+	return res, err
+}
+
+// MARK: Non-exported functions
+
+// insert inserts pm with the given executor, doing nothing if it conflicts
+// with the conflict target, ct, or with any unique constraint if ct is empty.
+// The name of the calling operation, op, is used in errors.
+func insert(pm PGModel, t Executor, op string, ct string, opts []QueryOption) (*Result, error) {
+	if err := errPartial(pm, op); err != nil {
+		return nil, err
+	}
+	o := newQueryOptions(opts)
+	if err := beforeSave(o.context(), pm, t); err != nil {
+		return nil, err
+	}
+	if err := assignID(pm); err != nil {
+		return nil, err
+	}
+
+	defer InvalidateCache(pm)
+	q := createInsertQuery(pm, ct, o)
+	v := append(primaryKeyValues(pm), stampValues(pm, convertVariables(pm))...)
+	if o.generatesKey(pm) {
+		v = v[1:]
+	}
+	res, err := runContext(o.context(), OperationSave, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryContext(o.context(), pm, q, v...)
+		})
+		if o.returning {
+			normalizeTimes(pm)
+		}
+		return res, err
+	})
+	if err == nil && res.RowsAffected() > 0 {
+		err = afterSave(o.context(), pm, t)
+	}
+	return newResult(res, q), err
+}
+
+// createInsertQuery creates a query inserting pm that does nothing if it
+// conflicts with the options' constraint or the conflict target, ct, or with
+// any unique constraint if neither was given.
+func createInsertQuery(pm PGModel, ct string, o *queryOptions) string {
+	// Get everything once
+	c := columns(pm)
+
+	// Create arrays to join
+	var im []string
+	for _, u := range c {
+		im = append(im, insertValue(pm, u))
+	}
+	if o.generatesKey(pm) {
+		im[0] = "DEFAULT"
+	}
+
+	// Create the query
+	return fmt.Sprintf(
+		`INSERT INTO %s (%s)
+		VALUES (%s)
+		ON CONFLICT %s
+		DO NOTHING
+		%s`,
+		o.qualifiedName(pm),
+		quoteList(c),
+		strings.Join(im, ", "),
+		o.conflictTarget(ct),
+		o.returningClause(pm),
+	)
+}
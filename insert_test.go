@@ -0,0 +1,86 @@
+package pgmodel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// affectingExecutor returns a testExecutor answering every query with n rows
+// affected.
+func affectingExecutor(n int) *testExecutor {
+	return &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return testResult{affected: n}, nil
+	}}
+}
+
+func TestInsert(t *testing.T) {
+	e := affectingExecutor(1)
+	if _, err := Insert(&testModel{ID: 1, Name: "one"}, e); err != nil {
+		t.Fatal(err)
+	}
+	want := `INSERT INTO "test"."models" ("id", "name", "tags") VALUES (?, ?, ?) ON CONFLICT ("id") DO NOTHING`
+	if q := squash(e.last().query); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+
+	// Existing rows aren't updated
+	if _, err := Insert(&testModel{ID: 1}, affectingExecutor(0)); !errors.Is(err, ErrRowExists) {
+		t.Errorf("got error %v, want ErrRowExists", err)
+	}
+}
+
+func TestUpdateByPK(t *testing.T) {
+	e := affectingExecutor(1)
+	if _, err := UpdateByPK(&testModel{ID: 1, Name: "one"}, e); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasPrefix(q, `UPDATE "test"."models" SET`) {
+		t.Errorf("got query %q, want an update", q)
+	}
+
+	// Missing rows aren't inserted
+	if _, err := UpdateByPK(&testModel{ID: 1}, affectingExecutor(0)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestSaveIgnore(t *testing.T) {
+	e := affectingExecutor(0)
+	res, err := SaveIgnore(&testModel{ID: 1, Name: "one"}, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Repeated rows aren't errors, but aren't reported as inserted
+	if res.RowsAffected() != 0 {
+		t.Errorf("got %d rows affected, want 0", res.RowsAffected())
+	}
+	if q := squash(e.last().query); !strings.HasSuffix(q, `ON CONFLICT DO NOTHING`) {
+		t.Errorf("got query %q, want a conflict on any constraint", q)
+	}
+
+	// Partial models can't be inserted
+	if _, err := SaveIgnore(&summaryModel{ID: 1}, e); err == nil {
+		t.Error("expected an error for a partial model")
+	}
+}
+
+func TestWithConflictConstraint(t *testing.T) {
+	e := affectingExecutor(1)
+	if _, err := Insert(&testModel{ID: 1}, e, WithConflictConstraint("models_name_excl")); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.Contains(q, `ON CONFLICT ON CONSTRAINT "models_name_excl" DO NOTHING`) {
+		t.Errorf("got query %q", q)
+	}
+
+	if _, err := Save(&testModel{ID: 1}, e, WithConflictConstraint("models_pkey")); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.Contains(q, `ON CONFLICT ON CONSTRAINT "models_pkey" DO UPDATE`) {
+		t.Errorf("got query %q", q)
+	}
+}
@@ -0,0 +1,180 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// ConflictModel types upsert against a unique constraint other than their
+// primary key, such as a natural key of (tenant_id, email). Save resolves
+// conflicts on the model's conflict columns as SaveByKey does, so conflicting
+// rows have their primary key updated along with the rest of their columns.
+type ConflictModel interface {
+	PGModel
+
+	// The columns of the unique constraint or index upserts conflict on.
+	ConflictColumns() []string
+}
+
+// MARK: Exported functions
+
+// SaveByKey performs an upsert with the given executor that resolves
+// conflicts on the unique keyColumns instead of the primary key. The primary
+// key is treated as just another column, so conflicting rows have their
+// primary key updated along with the rest of their non-key columns.
+//
+// This is useful for tables whose rows are identified by external keys, such
+// as a provider's ID. The table must have a unique constraint or index on
+// exactly the key columns.
+func SaveByKey(pm PGModel, t Executor, keyColumns ...string) (*Result, error) {
+	if err := errPartial(pm, "SaveByKey"); err != nil {
+		return nil, err
+	}
+	if err := validateColumns(pm, keyColumns); err != nil {
+		return nil, err
+	}
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("pgmodel: SaveByKey requires at least one key column")
+	}
+	ctx := context.Background()
+	if err := beforeSave(ctx, pm, t); err != nil {
+		return nil, err
+	}
+	if err := assignID(pm); err != nil {
+		return nil, err
+	}
+	defer InvalidateCache(pm)
+	res, err := saveByKey(pm, t, keyColumns, new(queryOptions))
+	if err == nil {
+		err = afterSave(ctx, pm, t)
+	}
+	return res, err
+}
+
+// GetByKey gets the single row whose columns equal the values in key. Every
+// key must be a column of the model.
+//
+// Like Get, an error is returned if no rows or more than one row match.
+func GetByKey(pm PGModel, t Executor, key map[string]interface{}, opts ...QueryOption) (*Result, error) {
+	return GetByKeyContext(context.Background(), pm, t, key, opts...)
+}
+
+// GetByKeyContext is identical to GetByKey but performs its query with the
+// context, ctx, so that it can be cancelled or given a deadline.
+func GetByKeyContext(ctx context.Context, pm PGModel, t Executor, key map[string]interface{}, opts ...QueryOption) (*Result, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("pgmodel: GetByKey requires at least one key column")
+	}
+
+	// Sort the columns so that the query is stable
+	kc := make([]string, 0, len(key))
+	for c := range key {
+		kc = append(kc, c)
+	}
+	sort.Strings(kc)
+	if err := validateColumns(pm, kc); err != nil {
+		return nil, err
+	}
+
+	// Create the predicate
+	var ps []string
+	var pa []interface{}
+	for _, c := range kc {
+		ps = append(ps, fmt.Sprintf("%s = ?", quoteIdent(c)))
+		pa = append(pa, key[c])
+	}
+
+	// Perform the query
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	q, a, err := createSelectQuery(pm, strings.Join(ps, " AND "), pa, o)
+	if err != nil {
+		return nil, err
+	}
+	res, err := runContext(o.context(), OperationGet, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryOneContext(o.context(), pm, q, a...)
+		})
+		normalizeTimes(pm)
+		return res, err
+	})
+	return newResult(res, q), err
+}
+
+// MARK: Non-exported functions
+
+// saveByKey performs an upsert of pm that resolves conflicts on the unique
+// keyColumns, or on the options' constraint if one was given, applying the
+// settings of the options, o.
+func saveByKey(pm PGModel, t Executor, keyColumns []string, o *queryOptions) (*Result, error) {
+	// Get everything once
+	c := columns(pm)
+	v := append(primaryKeyValues(pm), stampValues(pm, o.convertVariables(pm))...)
+
+	// Update every column that isn't a key
+	km := make(map[string]bool, len(keyColumns))
+	for _, k := range keyColumns {
+		km[k] = true
+	}
+
+	// Primary keys generated by the database are neither inserted nor updated
+	gk := o.generatesKey(pm)
+	tv := append([]interface{}{}, v...)
+	if gk {
+		tv = tv[1:]
+	}
+	var sc []string
+	for i, u := range c {
+		if km[u] || gk && i == 0 {
+			continue
+		}
+		sc = append(sc, u)
+		tv = append(tv, v[i])
+	}
+
+	// Perform the query
+	q := createUpsertQuery(pm, quoteList(keyColumns), sc, "", o)
+	res, err := runContext(o.context(), OperationSave, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryContext(o.context(), pm, q, tv...)
+		})
+		if o.returning {
+			normalizeTimes(pm)
+		}
+		return res, err
+	})
+	return newResult(res, q), err
+}
+
+// columns returns all of pm's columns, starting with its primary key columns.
+func columns(pm PGModel) []string {
+	return append(primaryKeys(pm), pm.NonPKColumns()...)
+}
+
+// values returns all of pm's converted values in the same order as columns.
+func values(pm PGModel) []interface{} {
+	return append(primaryKeyValues(pm), convertVariables(pm)...)
+}
+
+// validateColumns returns an error if any of cs are not columns of pm.
+func validateColumns(pm PGModel, cs []string) error {
+	cm := make(map[string]bool)
+	for _, c := range columns(pm) {
+		cm[c] = true
+	}
+
+	var unknown []string
+	for _, c := range cs {
+		if !cm[c] {
+			unknown = append(unknown, c)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("pgmodel: %s are not columns of %s.%s", strings.Join(unknown, ", "), pm.SchemaName(), pm.TableName())
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package pgmodel
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSaveByKey(t *testing.T) {
+	e := new(testExecutor)
+	m := &testModel{ID: 1, Name: "one", Tags: []string{"a"}}
+	if _, err := SaveByKey(m, e, "name"); err != nil {
+		t.Fatal(err)
+	}
+
+	q := squash(e.last().query)
+	for _, want := range []string{
+		`INSERT INTO "test"."models" ("id", "name", "tags")`,
+		`ON CONFLICT ("name") DO UPDATE SET "id" = ?, "tags" = ?`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query %q doesn't contain %q", q, want)
+		}
+	}
+	if p := e.last().params; len(p) != 5 || p[3] != 1 {
+		t.Errorf("got parameters %v, want the row and its updated columns", p)
+	}
+}
+
+func TestSaveByKeyInvalidColumns(t *testing.T) {
+	e := new(testExecutor)
+	for _, kc := range [][]string{nil, {"email"}} {
+		if _, err := SaveByKey(&testModel{ID: 1}, e, kc...); err == nil {
+			t.Errorf("%v: expected an error", kc)
+		}
+	}
+	if e.count() != 0 {
+		t.Errorf("performed %d queries, want 0", e.count())
+	}
+}
+
+func TestGetByKey(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := GetByKey(&testModel{}, e, map[string]interface{}{"name": "one", "id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	q := squash(e.last().query)
+	if !strings.HasSuffix(q, `WHERE "id" = ? AND "name" = ?`) {
+		t.Errorf("got query %q, want the key columns in sorted order", q)
+	}
+	if p := e.last().params; len(p) != 2 || p[0] != 1 || p[1] != "one" {
+		t.Errorf("got parameters %v", p)
+	}
+
+	if _, err := GetByKey(&testModel{}, e, map[string]interface{}{"email": "a"}); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+}
+
+// emailModel is a model of the test.models table that upserts on its name.
+type emailModel struct {
+	Base[emailModel] `pgmodel:"test.models"`
+	ID               int    `pg:"id,pk"`
+	Name             string `pg:"name"`
+}
+
+func (m *emailModel) ConflictColumns() []string {
+	return []string{"name"}
+}
+
+func TestSaveConflictModel(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Save(&emailModel{ID: 1, Name: "one"}, e); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.Contains(q, `ON CONFLICT ("name") DO UPDATE SET "id" = ?`) {
+		t.Errorf("got query %q, want a conflict on the name", q)
+	}
+
+	// A constraint given to Save takes precedence
+	if _, err := Save(&emailModel{ID: 1, Name: "one"}, e, WithConflictConstraint("models_name_key")); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.Contains(q, `ON CONFLICT ON CONSTRAINT "models_name_key"`) {
+		t.Errorf("got query %q, want a conflict on the constraint", q)
+	}
+}
+
+func TestGetByKeyContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	e := new(testExecutor)
+
+	if _, err := GetByKeyContext(ctx, &testModel{}, e, map[string]interface{}{"name": "a"}, WithSchema("other")); err != nil {
+		t.Fatal(err)
+	}
+	if q := e.last(); q.ctx.Value(key{}) != "request" || !strings.HasPrefix(squash(q.query), `SELECT * FROM "other"."models"`) {
+		t.Errorf("got query %q performed with the wrong options or context", squash(q.query))
+	}
+}
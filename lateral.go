@@ -0,0 +1,113 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lateral describes a LATERAL subquery selecting the rows of a child table
+// that reference each row of a query, such as the three latest comments of
+// each post. The child rows are aggregated in to a JSON array and selected as
+// the column, Name, so that they're scanned in to a slice field of the model,
+// e.g.
+//
+//	type Post struct {
+//		pgmodel.Base[Post] `pgmodel:"blog.posts"`
+//		ID                 int        `pg:"id,pk"`
+//		LatestComments     []*Comment `pgmodel:"-" pg:"latest_comments"`
+//	}
+//
+// The field must not be one of the model's columns, which the pgmodel tag of
+// "-" ensures for Base, and the child struct should have json tags matching
+// its columns so that the rows are decoded in to its fields.
+type Lateral struct {
+
+	// The name of the column the child rows are selected as.
+	Name string
+
+	// The child model and its column referencing the parent.
+	Child  KeyedModel
+	Column string
+
+	// The referenced column of the parent. It defaults to the parent's primary
+	// key.
+	ParentColumn string
+
+	// The ordering of the child rows, such as "created_at DESC", and the
+	// maximum number of child rows of each parent row. The rows aren't ordered
+	// if OrderBy is empty, or limited if Limit isn't positive.
+	OrderBy string
+	Limit   int
+}
+
+// MARK: Exported functions
+
+// WithLateral adds the child rows described by l to each row selected by
+// GetMany, Get and the other functions that select models.
+func WithLateral(l Lateral) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.laterals = append(o.laterals, l)
+	})
+}
+
+// MARK: Non-exported functions
+
+// lateralList returns the select list, sl, with the columns of the options'
+// laterals appended. The laterals' columns are already selected by "*".
+func (o *queryOptions) lateralList(sl string) string {
+	if sl == "*" || len(o.laterals) == 0 {
+		return sl
+	}
+
+	cs := []string{sl}
+	for _, l := range o.laterals {
+		cs = append(cs, quoteIdent(l.Name))
+	}
+	return strings.Join(cs, ", ")
+}
+
+// lateralJoins returns the LATERAL subqueries of the options joined to the
+// rows of pm's table, or an empty string if there are none.
+func (o *queryOptions) lateralJoins(pm KeyedModel) string {
+	qn := o.qualifiedName(pm)
+
+	var js []string
+	for i, l := range o.laterals {
+		pc := l.ParentColumn
+		if pc == "" {
+			pc = pm.PrimaryKey()
+		}
+
+		var oc, lc string
+		if l.OrderBy != "" {
+			oc = "ORDER BY " + l.OrderBy
+		}
+		if l.Limit > 0 {
+			lc = fmt.Sprintf("LIMIT %d", l.Limit)
+		}
+
+		// The child table is aliased so that it may be the parent's table
+		ca := quoteIdent(fmt.Sprintf("pgmodel_child_%d", i))
+		js = append(js, fmt.Sprintf(
+			`CROSS JOIN LATERAL (
+				SELECT COALESCE(json_agg(c), '[]'::json) AS %s FROM (
+					SELECT * FROM %s AS %s
+					WHERE %s.%s = %s.%s
+					%s
+					%s
+				) c
+			) AS %s`,
+			quoteIdent(l.Name),
+			qualify(l.Child.SchemaName(), l.Child.TableName()),
+			ca,
+			ca,
+			quoteIdent(l.Column),
+			qn,
+			quoteIdent(pc),
+			oc,
+			lc,
+			quoteIdent(fmt.Sprintf("pgmodel_lateral_%d", i)),
+		))
+	}
+	return strings.Join(js, "\n")
+}
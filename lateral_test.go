@@ -0,0 +1,41 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithLateral(t *testing.T) {
+	e := new(testExecutor)
+	l := Lateral{
+		Name:    "latest_children",
+		Child:   &childModel{},
+		Column:  "parent_id",
+		OrderBy: "id DESC",
+		Limit:   3,
+	}
+	if _, _, err := GetMany[*testModel](e, "name", "a", WithLateral(l), WithColumns(`"models"."id"`)); err != nil {
+		t.Fatal(err)
+	}
+
+	q := squash(e.last().query)
+	for _, want := range []string{
+		`SELECT "models"."id", "latest_children" FROM "test"."models"`,
+		`CROSS JOIN LATERAL ( SELECT COALESCE(json_agg(c), '[]'::json) AS "latest_children" FROM ( SELECT * FROM "test"."children" AS "pgmodel_child_0"`,
+		`WHERE "pgmodel_child_0"."parent_id" = "test"."models"."id" ORDER BY id DESC LIMIT 3 ) c ) AS "pgmodel_lateral_0"`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("got query %q, want it to contain %q", q, want)
+		}
+	}
+}
+
+func TestLateralList(t *testing.T) {
+	o := newQueryOptions([]QueryOption{WithLateral(Lateral{Name: "kids"})})
+	if sl := o.lateralList("*"); sl != "*" {
+		t.Errorf("got %q, want *", sl)
+	}
+	if sl := o.lateralList(`"id"`); sl != `"id", "kids"` {
+		t.Errorf("got %q", sl)
+	}
+}
@@ -0,0 +1,97 @@
+package pgmodel
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// limits holds the semaphores bounding concurrent operations.
+var limits = struct {
+	sync.RWMutex
+	global chan struct{}
+	models map[reflect.Type]chan struct{}
+}{
+	models: make(map[reflect.Type]chan struct{}),
+}
+
+// MARK: Exported functions
+
+// SetConcurrencyLimit limits the number of concurrent Save, SaveAll and
+// GetMany operations across all models to n. A value of n <= 0 removes the
+// limit.
+//
+// Operations that are already waiting on the previous limit are unaffected.
+// Operations performed with a context, such as by SaveContext, stop waiting
+// and return the context's error when it's done.
+func SetConcurrencyLimit(n int) {
+	limits.Lock()
+	defer limits.Unlock()
+	limits.global = newSemaphore(n)
+}
+
+// SetModelConcurrencyLimit limits the number of concurrent Save, SaveAll and
+// GetMany operations for models of pm's type to n. A value of n <= 0 removes
+// the limit.
+//
+// Model limits are applied in addition to the limit set by
+// SetConcurrencyLimit.
+func SetModelConcurrencyLimit(pm PGModel, n int) {
+	limits.Lock()
+	defer limits.Unlock()
+
+	rt := reflect.TypeOf(pm)
+	if s := newSemaphore(n); s != nil {
+		limits.models[rt] = s
+	} else {
+		delete(limits.models, rt)
+	}
+}
+
+// MARK: Non-exported functions
+
+// newSemaphore returns a semaphore with n slots, or nil if n <= 0.
+func newSemaphore(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
+
+// acquire blocks until the model and global limits allow another operation on
+// pm and returns a function that releases the acquired slots, or returns ctx's
+// error if it's done first.
+func acquire(ctx context.Context, pm TableDescriber) (func(), error) {
+	limits.RLock()
+	g, m := limits.global, limits.models[reflect.TypeOf(pm)]
+	limits.RUnlock()
+
+	// Take the model slot first so that waiting on a busy model doesn't hold a
+	// global slot
+	if m != nil {
+		select {
+		case m <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if g != nil {
+		select {
+		case g <- struct{}{}:
+		case <-ctx.Done():
+			if m != nil {
+				<-m
+			}
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() {
+		if g != nil {
+			<-g
+		}
+		if m != nil {
+			<-m
+		}
+	}, nil
+}
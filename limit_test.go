@@ -0,0 +1,134 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestAcquireCancelled(t *testing.T) {
+	SetModelConcurrencyLimit(&testModel{}, 1)
+	defer SetModelConcurrencyLimit(&testModel{}, 0)
+
+	release, err := acquire(context.Background(), &testModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := acquire(ctx, &testModel{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestAcquireReleasesModelSlot(t *testing.T) {
+	SetConcurrencyLimit(1)
+	defer SetConcurrencyLimit(0)
+	SetModelConcurrencyLimit(&testModel{}, 1)
+	defer SetModelConcurrencyLimit(&testModel{}, 0)
+
+	// Hold the global slot with another model
+	release, err := acquire(context.Background(), &cachedModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := acquire(ctx, &testModel{}); err == nil {
+		t.Fatal("acquired a slot while the global limit was reached")
+	}
+	release()
+
+	// The model slot taken by the cancelled call must have been released
+	release, err = acquire(context.Background(), &testModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
+
+func TestSaveContextCancelledWaitingOnLimit(t *testing.T) {
+	SetModelConcurrencyLimit(&testModel{}, 1)
+	defer SetModelConcurrencyLimit(&testModel{}, 0)
+
+	release, err := acquire(context.Background(), &testModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	e := new(testExecutor)
+	if _, err := SaveContext(ctx, &testModel{ID: 1}, e); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want context.DeadlineExceeded", err)
+	}
+	if n := e.count(); n != 0 {
+		t.Fatalf("got %d queries, want 0", n)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	SetModelConcurrencyLimit(&testModel{}, 2)
+	defer SetModelConcurrencyLimit(&testModel{}, 0)
+
+	// Count the queries performed at the same time
+	var mu sync.Mutex
+	var active, peak int
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		mu.Lock()
+		if active++; active > peak {
+			peak = active
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return testResult{affected: 1, returned: 1}, nil
+	}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if _, err := Save(&testModel{ID: id}, e); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if peak != 2 {
+		t.Errorf("got %d concurrent saves, want 2", peak)
+	}
+	if n := e.count(); n != 8 {
+		t.Errorf("got %d queries, want 8", n)
+	}
+}
+
+func TestConcurrencyLimitIgnoresGet(t *testing.T) {
+	SetConcurrencyLimit(1)
+	defer SetConcurrencyLimit(0)
+
+	release, err := acquire(context.Background(), &testModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	// Get isn't limited, so it doesn't wait on the slot held above
+	if _, err := Get(&testModel{}, new(testExecutor), "id", 1); err != nil {
+		t.Fatal(err)
+	}
+}
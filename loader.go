@@ -0,0 +1,189 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// MARK: Exported functions
+
+// GetByPKs gets the models of type T whose primary key values are keys with a
+// single query, e.g.
+//
+//	bs, err := pgmodel.GetByPKs[*Bar](t, ids)
+//
+// The models are returned in the order of keys, and keys without rows have
+// zero models. The keys must have the type of the models' primary key values
+// to be matched to their models.
+func GetByPKs[T KeyedModel](t Executor, keys []interface{}, opts ...QueryOption) ([]T, error) {
+	return getByPKs[T](context.Background(), t, keys, opts)
+}
+
+// LoadByPKs returns a batch function for GraphQL dataloaders, such as those
+// used by gqlgen resolvers, that loads the models of type T whose primary key
+// values are keys with a single query, e.g.
+//
+//	loader := dataloadgen.NewLoader(pgmodel.LoadByPKs[uuid.UUID, *Bar](db))
+//
+// The models are returned in the order of keys. Keys without rows have zero
+// models and an error matching ErrNotFound and pg.ErrNoRows. If the query
+// fails, every key has its error. Otherwise, the errors are nil.
+func LoadByPKs[K comparable, T KeyedModel](t Executor, opts ...QueryOption) func(ctx context.Context, keys []K) ([]T, []error) {
+	return func(ctx context.Context, keys []K) ([]T, []error) {
+		ms, err := getByPKs[T](ctx, t, anySlice(keys), opts)
+		if err != nil {
+			return make([]T, len(keys)), repeatError(err, len(keys))
+		}
+
+		var errs []error
+		for i, m := range ms {
+			if isZero(m) {
+				if errs == nil {
+					errs = make([]error, len(keys))
+				}
+				errs[i] = wrapError(pg.ErrNoRows)
+			}
+		}
+		return ms, errs
+	}
+}
+
+// LoadByColumn returns a batch function for GraphQL dataloaders that loads the
+// models of type T whose column, such as the Column of a Relation referencing
+// a parent, has each of the values, keys, with a single query, e.g.
+//
+//	loader := dataloadgen.NewLoader(pgmodel.LoadByColumn[uuid.UUID, *Comment](db, "post_id"))
+//
+// The models are grouped by their column's value and returned in the order of
+// keys, so that the models of a parent's children can be loaded in one batch.
+// Keys without rows have no models. If the query fails, every key has its
+// error. Otherwise, the errors are nil.
+func LoadByColumn[K comparable, T PGModel](t Executor, column string, opts ...QueryOption) func(ctx context.Context, keys []K) ([][]T, []error) {
+	return func(ctx context.Context, keys []K) ([][]T, []error) {
+		m := newModel[T]()
+		if err := validateColumns(m, []string{column}); err != nil {
+			return make([][]T, len(keys)), repeatError(err, len(keys))
+		}
+		if len(keys) == 0 {
+			return nil, nil
+		}
+
+		o := newQueryOptions(opts)
+		o.ctx = ctx
+		q, a, err := createSelectQuery(m, quoteIdent(column)+" IN (?)", []interface{}{pg.In(keys)}, o)
+		if err != nil {
+			return make([][]T, len(keys)), repeatError(err, len(keys))
+		}
+
+		var ms []T
+		_, err = runContext(ctx, OperationGetMany, m, t, func() (orm.Result, error) {
+			res, err := t.QueryContext(ctx, &ms, q, a...)
+			normalizeTimes(&ms)
+			return res, err
+		})
+		if err != nil {
+			return make([][]T, len(keys)), repeatError(err, len(keys))
+		}
+
+		// Group the models by their column's value
+		gm := make(map[K][]T)
+		for _, u := range ms {
+			v, _ := columnValue(u, column)
+			k, ok := v.(K)
+			if !ok {
+				var zk K
+				err := fmt.Errorf("pgmodel: %s value %v of %T is not of type %T", column, v, u, zk)
+				return make([][]T, len(keys)), repeatError(err, len(keys))
+			}
+			gm[k] = append(gm[k], u)
+		}
+
+		gs := make([][]T, len(keys))
+		for i, k := range keys {
+			gs[i] = gm[k]
+		}
+		return gs, nil
+	}
+}
+
+// MARK: Non-exported functions
+
+// getByPKs gets the models of type T whose primary key values are keys in the
+// order of keys.
+func getByPKs[T KeyedModel](ctx context.Context, t Executor, keys []interface{}, opts []QueryOption) ([]T, error) {
+	ms := make([]T, len(keys))
+	if len(keys) == 0 {
+		return ms, nil
+	}
+
+	m := newModel[T]()
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	q, a, err := createSelectQuery(m, quoteIdent(m.PrimaryKey())+" IN (?)", []interface{}{pg.In(keys)}, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var rs []T
+	_, err = runContext(ctx, OperationGetMany, m, t, func() (orm.Result, error) {
+		res, err := t.QueryContext(ctx, &rs, q, a...)
+		normalizeTimes(&rs)
+		return res, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rm := make(map[interface{}]T, len(rs))
+	for _, u := range rs {
+		rm[mapKey(u.PrimaryKeyValue())] = u
+	}
+	for i, k := range keys {
+		if u, ok := rm[mapKey(k)]; ok {
+			ms[i] = u
+		}
+	}
+	return ms, nil
+}
+
+// columnValue returns the unconverted value of pm's column, c, and whether pm
+// has the column.
+func columnValue(pm PGModel, c string) (interface{}, bool) {
+	if c == pm.PrimaryKey() {
+		return pm.PrimaryKeyValue(), true
+	}
+	for i, u := range pm.NonPKColumns() {
+		if u == c {
+			return pm.NonPKValues()[i], true
+		}
+	}
+	return nil, false
+}
+
+// anySlice returns the elements of s as a slice of interface values.
+func anySlice[K any](s []K) []interface{} {
+	is := make([]interface{}, len(s))
+	for i, u := range s {
+		is[i] = u
+	}
+	return is
+}
+
+// repeatError returns a slice of n copies of err.
+func repeatError(err error, n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// isZero returns true if m is the zero value of its type, such as a nil
+// pointer.
+func isZero[T any](m T) bool {
+	return reflect.ValueOf(&m).Elem().IsZero()
+}
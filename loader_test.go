@@ -0,0 +1,61 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestGetByPKs(t *testing.T) {
+	ms, err := GetByPKs[*testModel](rowsExecutor(2, 1), []interface{}{1, 3, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 3 || ms[0].ID != 1 || ms[1] != nil || ms[2].ID != 2 {
+		t.Errorf("got models %v", ms)
+	}
+}
+
+func TestLoadByPKs(t *testing.T) {
+	load := LoadByPKs[int, *testModel](rowsExecutor(1))
+	ms, errs := load(context.Background(), []int{1, 2})
+	if len(ms) != 2 || ms[0].ID != 1 || ms[1] != nil {
+		t.Fatalf("got models %v", ms)
+	}
+	if len(errs) != 2 || errs[0] != nil || !errors.Is(errs[1], ErrNotFound) {
+		t.Errorf("got errors %v, want the missing key not to be found", errs)
+	}
+
+	// Every key has the query's error
+	failed := errors.New("failed")
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return nil, failed
+	}}
+	_, errs = LoadByPKs[int, *testModel](e)(context.Background(), []int{1, 2})
+	if len(errs) != 2 || !errors.Is(errs[0], failed) || !errors.Is(errs[1], failed) {
+		t.Errorf("got errors %v", errs)
+	}
+}
+
+func TestLoadByColumn(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		ms := model.(*[]*testModel)
+		*ms = append(*ms, &testModel{ID: 1, Name: "a"}, &testModel{ID: 2, Name: "b"}, &testModel{ID: 3, Name: "a"})
+		return testResult{returned: 3}, nil
+	}}
+
+	gs, errs := LoadByColumn[string, *testModel](e, "name")(context.Background(), []string{"a", "c", "b"})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	if len(gs) != 3 || len(gs[0]) != 2 || len(gs[1]) != 0 || len(gs[2]) != 1 || gs[2][0].ID != 2 {
+		t.Errorf("got groups %v", gs)
+	}
+
+	// The column must be one of the model's
+	if _, errs := LoadByColumn[string, *testModel](e, "missing")(context.Background(), []string{"a"}); len(errs) != 1 || errs[0] == nil {
+		t.Errorf("got errors %v, want an unknown column error", errs)
+	}
+}
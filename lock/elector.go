@@ -0,0 +1,94 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Elector elects a single leader among the owners campaigning for the same
+// lock, such as the replicas of a service that should run scheduled work only
+// once.
+type Elector struct {
+	m        *Manager
+	name     string
+	ttl      time.Duration
+	onChange func(leader bool)
+
+	mu     sync.RWMutex
+	leader bool
+}
+
+// MARK: Exported functions
+
+// NewElector returns an elector that campaigns for the named lock with leases
+// of ttl, or ErrInvalidTTL if ttl is shorter than MinTTL. If onChange is
+// non-nil, it is called whenever the elector gains or loses leadership.
+func (m *Manager) NewElector(name string, ttl time.Duration, onChange func(leader bool)) (*Elector, error) {
+	if err := validateTTL(ttl); err != nil {
+		return nil, err
+	}
+	return &Elector{
+		m:        m,
+		name:     name,
+		ttl:      ttl,
+		onChange: onChange,
+	}, nil
+}
+
+// IsLeader returns whether the elector currently holds leadership.
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader
+}
+
+// Run campaigns for leadership until ctx is done, and then releases
+// leadership and returns ctx's error.
+//
+// While another owner is leader, the elector retries every third of its ttl.
+// If the elector's lease is lost, it steps down and campaigns again.
+func (e *Elector) Run(ctx context.Context) error {
+	for {
+		l, err := e.m.Acquire(ctx, e.name, e.ttl)
+		if err == nil {
+			e.setLeader(true)
+			select {
+			case <-l.Lost():
+				e.setLeader(false)
+			case <-ctx.Done():
+				e.setLeader(false)
+
+				// Step down so that another owner can take over immediately
+				rctx, cancel := context.WithTimeout(context.Background(), e.ttl)
+				_ = l.Release(rctx)
+				cancel()
+				return ctx.Err()
+			}
+		} else if !errors.Is(err, ErrNotAcquired) && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		select {
+		case <-time.After(e.ttl / 3):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// MARK: Non-exported functions
+
+// setLeader updates the elector's leadership and calls its callback if it
+// changed.
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	changed := e.leader != leader
+	e.leader = leader
+	e.mu.Unlock()
+
+	if changed && e.onChange != nil {
+		e.onChange(leader)
+	}
+}
@@ -0,0 +1,49 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestElectorSetLeader(t *testing.T) {
+	var changes []bool
+	e, err := NewManager(nil, "test").NewElector("test", time.Second, func(leader bool) {
+		changes = append(changes, leader)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, l := range []bool{true, true, false, false, true} {
+		e.setLeader(l)
+		if e.IsLeader() != l {
+			t.Errorf("got leader %v, want %v", e.IsLeader(), l)
+		}
+	}
+	if len(changes) != 3 || !changes[0] || changes[1] || !changes[2] {
+		t.Errorf("got changes %v, want [true false true]", changes)
+	}
+}
+
+func TestElectorRunCancelled(t *testing.T) {
+	db := pg.Connect(&pg.Options{Addr: "127.0.0.1:1", DialTimeout: time.Second})
+	defer db.Close()
+
+	e, err := NewManager(db, "test").NewElector("test", 30*time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := e.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if e.IsLeader() {
+		t.Error("became leader without acquiring the lock")
+	}
+}
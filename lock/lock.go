@@ -0,0 +1,211 @@
+// Package lock provides distributed locks with renewable leases stored in a
+// Postgres table.
+//
+// Every acquisition of a lock increments its fencing token. Resources guarded
+// by a lock can reject writes carrying a token lower than the highest they
+// have seen, protecting them from holders whose lease expired without them
+// noticing.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// DefaultTable is the table used by managers created with NewManager.
+const DefaultTable = "public.pgmodel_locks"
+
+// MinTTL is the shortest lease a lock can be acquired with.
+const MinTTL = time.Millisecond
+
+var (
+	// ErrNotAcquired is returned by Acquire when the lock is held by another
+	// owner.
+	ErrNotAcquired = errors.New("lock: not acquired")
+
+	// ErrLost is returned when renewing or releasing a lock whose lease has
+	// expired and been acquired by another owner.
+	ErrLost = errors.New("lock: lost")
+
+	// ErrInvalidTTL is returned when a lease is shorter than MinTTL.
+	ErrInvalidTTL = fmt.Errorf("lock: ttl is shorter than %v", MinTTL)
+)
+
+// Manager acquires locks on behalf of a single owner.
+type Manager struct {
+
+	// The table storing the locks.
+	Table string
+
+	db    *pg.DB
+	owner string
+}
+
+// Lock is a held lock.
+type Lock struct {
+
+	// The lock's name.
+	Name string
+
+	// The lock's fencing token.
+	Token int64
+
+	m      *Manager
+	ttl    time.Duration
+	cancel context.CancelFunc
+	done   chan struct{}
+	lost   chan struct{}
+	once   sync.Once
+}
+
+// MARK: Exported functions
+
+// NewManager returns a manager that acquires locks in db for the owner, which
+// should uniquely identify the process, e.g. by its hostname and PID.
+func NewManager(db *pg.DB, owner string) *Manager {
+	return &Manager{
+		Table: DefaultTable,
+		db:    db,
+		owner: owner,
+	}
+}
+
+// CreateTable creates the manager's table if it doesn't exist.
+func (m *Manager) CreateTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (
+			name text PRIMARY KEY,
+			owner text NOT NULL,
+			token bigint NOT NULL,
+			expires_at timestamptz NOT NULL
+		)`,
+		m.Table,
+	))
+	return err
+}
+
+// Acquire acquires the named lock with a lease of ttl, or returns
+// ErrNotAcquired if another owner holds an unexpired lease on it.
+//
+// The lease is renewed in the background every third of its ttl until the
+// lock is released. If a renewal fails, the channel returned by the lock's
+// Lost method is closed. ErrInvalidTTL is returned if ttl is shorter than
+// MinTTL.
+func (m *Manager) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	if err := validateTTL(ttl); err != nil {
+		return nil, err
+	}
+
+	var token int64
+	_, err := m.db.QueryOneContext(ctx, pg.Scan(&token), fmt.Sprintf(
+		`INSERT INTO %s AS l (name, owner, token, expires_at)
+		VALUES (?, ?, 1, now() + ? * interval '1 microsecond')
+		ON CONFLICT (name)
+		DO UPDATE
+		SET owner = EXCLUDED.owner, token = l.token + 1, expires_at = EXCLUDED.expires_at
+		WHERE l.expires_at < now()
+		RETURNING token`,
+		m.Table,
+	), name, m.owner, ttl.Microseconds())
+
+	if errors.Is(err, pg.ErrNoRows) {
+		return nil, ErrNotAcquired
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rctx, cancel := context.WithCancel(context.Background())
+	l := &Lock{
+		Name:   name,
+		Token:  token,
+		m:      m,
+		ttl:    ttl,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		lost:   make(chan struct{}),
+	}
+	go l.keepAlive(rctx)
+	return l, nil
+}
+
+// Lost returns a channel that is closed if the lock's lease could not be
+// renewed. Work guarded by the lock should stop when it is closed.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Renew extends the lock's lease by its ttl, or returns ErrLost if the lease
+// has been acquired by another owner.
+func (l *Lock) Renew(ctx context.Context) error {
+	res, err := l.m.db.ExecContext(ctx, fmt.Sprintf(
+		`UPDATE %s
+		SET expires_at = now() + ? * interval '1 microsecond'
+		WHERE name = ? AND owner = ? AND token = ?`,
+		l.m.Table,
+	), l.ttl.Microseconds(), l.Name, l.m.owner, l.Token)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrLost
+	}
+	return nil
+}
+
+// Release stops renewing the lock and expires its lease so that it can be
+// acquired immediately by another owner.
+func (l *Lock) Release(ctx context.Context) error {
+	l.cancel()
+	<-l.done
+
+	// Expire the lease rather than deleting it so that tokens keep increasing
+	res, err := l.m.db.ExecContext(ctx, fmt.Sprintf(
+		`UPDATE %s
+		SET expires_at = '-infinity'
+		WHERE name = ? AND owner = ? AND token = ?`,
+		l.m.Table,
+	), l.Name, l.m.owner, l.Token)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrLost
+	}
+	return nil
+}
+
+// MARK: Non-exported functions
+
+// validateTTL returns ErrInvalidTTL if the lease, ttl, is shorter than MinTTL.
+func validateTTL(ttl time.Duration) error {
+	if ttl < MinTTL {
+		return ErrInvalidTTL
+	}
+	return nil
+}
+
+// keepAlive renews the lock's lease until ctx is done or a renewal fails.
+func (l *Lock) keepAlive(ctx context.Context) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.Renew(ctx); err != nil && ctx.Err() == nil {
+				l.once.Do(func() { close(l.lost) })
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// testTable is the table the tests' managers store their locks in.
+const testTable = "test.locks"
+
+func TestAcquireInvalidTTL(t *testing.T) {
+	m := NewManager(nil, "test")
+	for _, ttl := range []time.Duration{-time.Second, 0, 2, MinTTL - 1} {
+		if _, err := m.Acquire(context.Background(), "test", ttl); !errors.Is(err, ErrInvalidTTL) {
+			t.Errorf("%v: got %v, want %v", ttl, err, ErrInvalidTTL)
+		}
+	}
+}
+
+func TestNewElectorInvalidTTL(t *testing.T) {
+	m := NewManager(nil, "test")
+	if _, err := m.NewElector("test", 2, nil); !errors.Is(err, ErrInvalidTTL) {
+		t.Errorf("got %v, want %v", err, ErrInvalidTTL)
+	}
+	if _, err := m.NewElector("test", MinTTL, nil); err != nil {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestAcquireUnreachable(t *testing.T) {
+	db := pg.Connect(&pg.Options{Addr: "127.0.0.1:1", DialTimeout: time.Second})
+	defer db.Close()
+
+	if _, err := NewManager(db, "test").Acquire(context.Background(), "test", time.Second); err == nil || errors.Is(err, ErrNotAcquired) {
+		t.Errorf("got %v, want the connection error", err)
+	}
+}
+
+func TestAcquireRelease(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	a, b := testManager(t, db, "a"), testManager(t, db, "b")
+
+	la, err := a.Acquire(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Acquire(ctx, "job", time.Minute); !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("got %v, want %v", err, ErrNotAcquired)
+	}
+	if err := la.Renew(ctx); err != nil {
+		t.Errorf("got %v renewing the lock", err)
+	}
+
+	// Releasing the lock lets another owner acquire it with a higher token
+	if err := la.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	lb, err := b.Acquire(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lb.Release(ctx)
+	if lb.Token <= la.Token {
+		t.Errorf("got token %d after %d, want it to increase", lb.Token, la.Token)
+	}
+
+	// The previous holder's lease is gone
+	if err := la.Renew(ctx); !errors.Is(err, ErrLost) {
+		t.Errorf("got %v renewing a released lock, want %v", err, ErrLost)
+	}
+	if err := la.Release(ctx); !errors.Is(err, ErrLost) {
+		t.Errorf("got %v releasing a released lock, want %v", err, ErrLost)
+	}
+}
+
+func TestLockLost(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	l, err := testManager(t, db, "a").Acquire(ctx, "job", 30*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release(ctx)
+
+	// Take the lease from the holder
+	if _, err := db.Exec(`UPDATE test.locks SET owner = 'b' WHERE name = 'job'`); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Error("the lock wasn't lost")
+	}
+}
+
+// MARK: Non-exported functions
+
+// testDB connects to the database named by PGMODEL_TEST_DATABASE, dropping the
+// test.locks table and closing the connection when the test finishes. The
+// test is skipped if there's no test database.
+func testDB(t *testing.T) *pg.DB {
+	t.Helper()
+	u := os.Getenv("PGMODEL_TEST_DATABASE")
+	if u == "" {
+		t.Skip("PGMODEL_TEST_DATABASE isn't set")
+	}
+	opt, err := pg.ParseURL(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := pg.Connect(opt)
+	for _, q := range []string{`CREATE SCHEMA IF NOT EXISTS test`, `DROP TABLE IF EXISTS test.locks`} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DROP TABLE IF EXISTS test.locks`)
+		_ = db.Close()
+	})
+	return db
+}
+
+// testManager returns a manager of the owner's locks in the test.locks table,
+// creating the table if it doesn't exist.
+func testManager(t *testing.T, db *pg.DB, owner string) *Manager {
+	t.Helper()
+	m := NewManager(db, owner)
+	m.Table = testTable
+	if err := m.CreateTable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
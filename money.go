@@ -0,0 +1,201 @@
+package pgmodel
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// currencyExponents holds the number of decimal places of the currencies that
+// don't have two.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Money is an amount of a currency stored in the composite type
+//
+//	CREATE TYPE money_amount AS (amount numeric, currency char(3));
+//
+// so that amounts are never stored without their currency, and are never
+// rounded by floating point arithmetic. Its amount is a whole number of the
+// currency's minor units, such as cents, e.g.
+//
+//	type Account struct {
+//		pgmodel.Base[Account] `pgmodel:"bank.accounts"`
+//		ID                    int           `pg:"id,pk"`
+//		Balance               pgmodel.Money `pg:"balance,type:money_amount"`
+//	}
+//
+// Money isn't bound to PostgreSQL's money type, whose format depends on the
+// database's locale and which has no currency.
+type Money struct {
+
+	// The amount in the currency's minor units.
+	Amount int64
+
+	// The ISO 4217 code of the currency, such as USD.
+	Currency string
+}
+
+// MARK: Exported functions
+
+// NewMoney returns the amount, such as "12.34", of the currency as Money. An
+// error is returned if the amount has more decimal places than the currency.
+func NewMoney(amount string, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	a, err := parseMinorUnits(amount, currencyExponent(currency))
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: a, Currency: currency}, nil
+}
+
+// Add returns the sum of m and n, or an error if their currencies differ or
+// the sum overflows.
+func (m Money) Add(n Money) (Money, error) {
+	if m.Currency != n.Currency {
+		return Money{}, fmt.Errorf("pgmodel: can't add %s to %s", n.Currency, m.Currency)
+	}
+	if n.Amount > 0 && m.Amount > math.MaxInt64-n.Amount || n.Amount < 0 && m.Amount < math.MinInt64-n.Amount {
+		return Money{}, fmt.Errorf("pgmodel: %s + %s overflows", m, n)
+	}
+	return Money{Amount: m.Amount + n.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns the difference of m and n, or an error if their currencies
+// differ or the difference overflows.
+func (m Money) Sub(n Money) (Money, error) {
+	if n.Amount == math.MinInt64 {
+		return Money{}, fmt.Errorf("pgmodel: %s - %s overflows", m, n)
+	}
+	return m.Add(Money{Amount: -n.Amount, Currency: n.Currency})
+}
+
+// Decimal returns m's amount in the currency's major units, such as "12.34".
+func (m Money) Decimal() string {
+	e := currencyExponent(m.Currency)
+	s := strconv.FormatUint(uint64(m.Amount), 10)
+	if m.Amount < 0 {
+		s = strconv.FormatUint(uint64(-m.Amount), 10)
+	}
+	if e > 0 {
+		if len(s) <= e {
+			s = strings.Repeat("0", e-len(s)+1) + s
+		}
+		s = s[:len(s)-e] + "." + s[len(s)-e:]
+	}
+	if m.Amount < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// String returns m's amount and currency, such as "12.34 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Value returns m as a money_amount composite value.
+func (m Money) Value() (driver.Value, error) {
+	return fmt.Sprintf("(%s,%s)", m.Decimal(), m.Currency), nil
+}
+
+// Scan sets m to the money_amount composite value, src.
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("pgmodel: can't scan %T in to Money", src)
+	}
+
+	fs := strings.Split(strings.TrimSuffix(strings.TrimPrefix(s, "("), ")"), ",")
+	if len(fs) != 2 {
+		return fmt.Errorf("pgmodel: can't scan %q in to Money", s)
+	}
+	n, err := NewMoney(fs[0], strings.Trim(fs[1], `"`))
+	if err != nil {
+		return err
+	}
+	*m = n
+	return nil
+}
+
+// AddMoney adds m to the Money column of the rows of pm's table matching the
+// condition, c, in the given transaction, without reading the rows, e.g. to
+// credit an account:
+//
+//	_, err := pgmodel.AddMoney(new(Account), t, pgmodel.Where("id", pgmodel.Eq, id), "balance", deposit, pgmodel.WithExpectRows(1))
+//
+// The sum is computed by the database with numeric arithmetic. Rows whose
+// amount is in a currency other than m's are never changed, so options such
+// as WithExpectRows can be used to return a *RowCountError instead. A negative
+// amount is subtracted.
+func AddMoney(pm PGModel, t *pg.Tx, c *Condition, column string, m Money, opts ...QueryOption) (*Result, error) {
+	if c == nil {
+		return nil, fmt.Errorf("pgmodel: AddMoney requires a condition")
+	}
+	if err := validateColumns(pm, []string{column}); err != nil {
+		return nil, err
+	}
+
+	o := newQueryOptions(opts)
+	c.apply(o)
+	ps, pa, err := o.predicates(pm)
+	if err != nil {
+		return nil, err
+	}
+	col := quoteIdent(column)
+	q := fmt.Sprintf(
+		`UPDATE %s
+		SET %s.amount = (%s).amount + ?::numeric
+		WHERE (%s).currency = ? AND %s`,
+		o.qualifiedName(pm),
+		col,
+		col,
+		col,
+		strings.Join(ps, " AND "),
+	)
+	a := append([]interface{}{m.Decimal(), m.Currency}, pa...)
+	defer InvalidateCache(pm)
+	return o.guard(OperationSave, pm, t, q, a)
+}
+
+// MARK: Non-exported functions
+
+// currencyExponent returns the number of decimal places of the currency.
+func currencyExponent(currency string) int {
+	if e, ok := currencyExponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// parseMinorUnits returns the decimal amount, s, as a whole number of minor
+// units of a currency with e decimal places.
+func parseMinorUnits(s string, e int) (int64, error) {
+	s = strings.TrimSpace(s)
+	ip, fp, _ := strings.Cut(s, ".")
+	if fp = strings.TrimRight(fp, "0"); len(fp) > e {
+		return 0, fmt.Errorf("pgmodel: amount %s has more than %d decimal places", s, e)
+	}
+
+	a, err := strconv.ParseInt(ip+fp+strings.Repeat("0", e-len(fp)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("pgmodel: invalid amount %s: %w", s, err)
+	}
+	return a, nil
+}
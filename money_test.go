@@ -0,0 +1,115 @@
+package pgmodel
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// accountModel is a model of the test.accounts table with a Money column.
+type accountModel struct {
+	Base[accountModel] `pgmodel:"test.accounts"`
+	ID                 int   `pg:"id,pk"`
+	Balance            Money `pg:"balance,type:money_amount"`
+}
+
+func TestNewMoney(t *testing.T) {
+	for _, c := range []struct {
+		amount   string
+		currency string
+		want     Money
+		decimal  string
+	}{
+		{"12.34", "usd", Money{1234, "USD"}, "12.34"},
+		{"12.3", "USD", Money{1230, "USD"}, "12.30"},
+		{"-0.05", "EUR", Money{-5, "EUR"}, "-0.05"},
+		{"1500", "JPY", Money{1500, "JPY"}, "1500"},
+		{"1.234", "KWD", Money{1234, "KWD"}, "1.234"},
+	} {
+		m, err := NewMoney(c.amount, c.currency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != c.want || m.Decimal() != c.decimal {
+			t.Errorf("got %+v (%s) for %s %s, want %+v (%s)", m, m.Decimal(), c.amount, c.currency, c.want, c.decimal)
+		}
+	}
+
+	for _, s := range []string{"1.234", "1.2.3", "abc"} {
+		if _, err := NewMoney(s, "USD"); err == nil {
+			t.Errorf("expected an error for %s", s)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	m, err := Money{1000, "USD"}.Add(Money{234, "USD"})
+	if err != nil || m != (Money{1234, "USD"}) {
+		t.Errorf("got %v, %v", m, err)
+	}
+	if m, err = m.Sub(Money{2000, "USD"}); err != nil || m.String() != "-7.66 USD" {
+		t.Errorf("got %v, %v", m, err)
+	}
+
+	for _, f := range []func() (Money, error){
+		func() (Money, error) { return Money{1, "USD"}.Add(Money{1, "EUR"}) },
+		func() (Money, error) { return Money{math.MaxInt64, "USD"}.Add(Money{1, "USD"}) },
+		func() (Money, error) { return Money{0, "USD"}.Sub(Money{math.MinInt64, "USD"}) },
+	} {
+		if m, err := f(); err == nil {
+			t.Errorf("got %v, expected an error", m)
+		}
+	}
+}
+
+func TestMoneyValueAndScan(t *testing.T) {
+	v, err := Money{-1234, "USD"}.Value()
+	if err != nil || v != "(-12.34,USD)" {
+		t.Fatalf("got value %v, %v", v, err)
+	}
+
+	var m Money
+	for _, src := range []interface{}{v, []byte(`(-12.34,"USD")`)} {
+		if err := m.Scan(src); err != nil || m != (Money{-1234, "USD"}) {
+			t.Errorf("got %+v, %v for %v", m, err, src)
+		}
+	}
+	if err := m.Scan(nil); err != nil || m != (Money{}) {
+		t.Errorf("got %+v, %v for nil", m, err)
+	}
+	if err := m.Scan("12.34"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestAddMoney(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TYPE money_amount AS (amount numeric, currency char(3))`,
+		`CREATE TABLE test.accounts (id int PRIMARY KEY, balance money_amount)`,
+		`INSERT INTO test.accounts VALUES (1, '(10.00,USD)'), (2, '(10.00,EUR)')`,
+	)
+
+	deposit, _ := NewMoney("2.50", "USD")
+	if _, err := AddMoney(&accountModel{}, tx, Where("id", Eq, 1), "balance", deposit, WithExpectRows(1)); err != nil {
+		t.Fatal(err)
+	}
+	m, _, err := GetOne[*accountModel](tx, "id", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Balance != (Money{1250, "USD"}) {
+		t.Errorf("got balance %v, want 12.50 USD", m.Balance)
+	}
+
+	// Rows in other currencies aren't changed
+	var rc *RowCountError
+	if _, err := AddMoney(&accountModel{}, tx, Where("id", Eq, 2), "balance", deposit, WithExpectRows(1)); !errors.As(err, &rc) {
+		t.Errorf("got error %v, want a *RowCountError", err)
+	}
+
+	if _, err := AddMoney(&accountModel{}, tx, nil, "balance", deposit); err == nil {
+		t.Error("expected an error without a condition")
+	}
+}
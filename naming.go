@@ -0,0 +1,138 @@
+package pgmodel
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// NamingStrategy types derive the table and column names of models from their
+// Go identifiers. They are used by Base, and by pgmodelgen, for the names that
+// aren't given by struct tags.
+type NamingStrategy interface {
+
+	// Returns the table name of the struct type named n.
+	TableName(n string) string
+
+	// Returns the column name of the struct field named n.
+	ColumnName(n string) string
+}
+
+// SnakeCase is the default naming strategy. It converts type and field names
+// to snake case, e.g. "UserID" to "user_id".
+var SnakeCase NamingStrategy = snakeCaseNaming{}
+
+// naming holds the strategy set by SetNamingStrategy.
+var naming = struct {
+	sync.RWMutex
+	strategy NamingStrategy
+}{
+	strategy: SnakeCase,
+}
+
+// snakeCaseNaming is the SnakeCase naming strategy.
+type snakeCaseNaming struct{}
+
+// pluralNaming pluralizes the table names of its strategy.
+type pluralNaming struct {
+	NamingStrategy
+}
+
+// prefixNaming prefixes the table names of its strategy.
+type prefixNaming struct {
+	NamingStrategy
+	prefix string
+}
+
+// MARK: Exported functions
+
+// SetNamingStrategy sets the strategy Base uses to name the tables and columns
+// of its models. A nil strategy restores SnakeCase.
+//
+// Each model type is only described the first time it's used, so the strategy
+// should be set before any models embedding Base are used.
+func SetNamingStrategy(s NamingStrategy) {
+	naming.Lock()
+	defer naming.Unlock()
+
+	if s == nil {
+		s = SnakeCase
+	}
+	naming.strategy = s
+}
+
+// PluralTables returns a strategy that pluralizes the table names of s, e.g.
+// "user" to "users" and "category" to "categories". Column names are
+// unchanged.
+func PluralTables(s NamingStrategy) NamingStrategy {
+	return pluralNaming{NamingStrategy: s}
+}
+
+// PrefixedTables returns a strategy that prefixes the table names of s with
+// prefix, e.g. "app_" for tables named "app_users". Column names are
+// unchanged.
+func PrefixedTables(prefix string, s NamingStrategy) NamingStrategy {
+	return prefixNaming{NamingStrategy: s, prefix: prefix}
+}
+
+// TableName returns the snake case type name, n.
+func (snakeCaseNaming) TableName(n string) string {
+	return snakeCase(n)
+}
+
+// ColumnName returns the snake case field name, n.
+func (snakeCaseNaming) ColumnName(n string) string {
+	return snakeCase(n)
+}
+
+// TableName returns the plural of the strategy's table name for n.
+func (s pluralNaming) TableName(n string) string {
+	return plural(s.NamingStrategy.TableName(n))
+}
+
+// TableName returns the strategy's table name for n with the prefix.
+func (s prefixNaming) TableName(n string) string {
+	return s.prefix + s.NamingStrategy.TableName(n)
+}
+
+// MARK: Non-exported functions
+
+// namingStrategy returns the strategy set by SetNamingStrategy.
+func namingStrategy() NamingStrategy {
+	naming.RLock()
+	defer naming.RUnlock()
+	return naming.strategy
+}
+
+// snakeCase converts the identifier, n, to snake case, e.g. "UserID" to
+// "user_id".
+func snakeCase(n string) string {
+	rs := []rune(n)
+	var b strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// plural returns the English plural of the name, n, using the regular rules
+// for its ending.
+func plural(n string) string {
+	switch {
+	case n == "":
+		return n
+	case strings.HasSuffix(n, "s"), strings.HasSuffix(n, "x"), strings.HasSuffix(n, "z"),
+		strings.HasSuffix(n, "ch"), strings.HasSuffix(n, "sh"):
+		return n + "es"
+	case strings.HasSuffix(n, "y") && len(n) > 1 && !strings.ContainsRune("aeiou", rune(n[len(n)-2])):
+		return n[:len(n)-1] + "ies"
+	default:
+		return n + "s"
+	}
+}
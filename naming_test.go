@@ -0,0 +1,51 @@
+package pgmodel
+
+import "testing"
+
+// orderCategory is a model whose table is named by the naming strategy.
+type orderCategory struct {
+	Base[orderCategory]
+	ID          int
+	DisplayName string
+}
+
+func TestSnakeCase(t *testing.T) {
+	for n, want := range map[string]string{
+		"ID":          "id",
+		"UserID":      "user_id",
+		"HTTPServer":  "http_server",
+		"createdAt":   "created_at",
+		"already_set": "already_set",
+	} {
+		if s := snakeCase(n); s != want {
+			t.Errorf("got %q for %q, want %q", s, n, want)
+		}
+	}
+}
+
+func TestPlural(t *testing.T) {
+	for n, want := range map[string]string{
+		"user":     "users",
+		"box":      "boxes",
+		"match":    "matches",
+		"category": "categories",
+		"day":      "days",
+	} {
+		if p := plural(n); p != want {
+			t.Errorf("got %q for %q, want %q", p, n, want)
+		}
+	}
+}
+
+func TestSetNamingStrategy(t *testing.T) {
+	SetNamingStrategy(PrefixedTables("app_", PluralTables(SnakeCase)))
+	defer SetNamingStrategy(nil)
+
+	m := &orderCategory{}
+	if tn := m.TableName(); tn != "app_order_categories" {
+		t.Errorf("got table name %q, want app_order_categories", tn)
+	}
+	if c := m.NonPKColumns(); len(c) != 1 || c[0] != "display_name" {
+		t.Errorf("got columns %v, want [display_name]", c)
+	}
+}
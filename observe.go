@@ -0,0 +1,175 @@
+package pgmodel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Operation identifies the kind of work performed by a pgmodel function.
+type Operation string
+
+const (
+	// OperationBegin is reported by Begin.
+	OperationBegin Operation = "begin"
+
+	// OperationGet is reported by Get.
+	OperationGet Operation = "get"
+
+	// OperationGetMany is reported by GetMany.
+	OperationGetMany Operation = "get_many"
+
+	// OperationSave is reported by Save.
+	OperationSave Operation = "save"
+
+	// OperationSaveAll is reported by SaveAll for each statement it performs.
+	OperationSaveAll Operation = "save_all"
+
+	// OperationDelete is reported by Delete.
+	OperationDelete Operation = "delete"
+
+	// OperationPurge is reported by Purge for each batch it deletes.
+	OperationPurge Operation = "purge"
+)
+
+// OperationStats describes the timing of a single operation.
+type OperationStats struct {
+
+	// The operation that was performed.
+	Operation Operation
+
+	// The schema and table names of the operation's model. Both are empty for
+	// OperationBegin.
+	Schema string
+	Table  string
+
+	// The time spent waiting on the limits set by SetConcurrencyLimit and
+	// SetModelConcurrencyLimit before the operation could execute.
+	LimitWait time.Duration
+
+	// The time taken to acquire a connection from the pool and start a
+	// transaction. Only Begin acquires connections itself, so this is zero for
+	// other operations. Operations performed in a transaction use the
+	// connection acquired by Begin, and go-pg doesn't report the time taken to
+	// acquire the connections of other executors, such as a *pg.DB.
+	ConnWait time.Duration
+
+	// The time spent executing the query. For operations performed with an
+	// executor other than a transaction, such as a *pg.DB, this includes the
+	// time taken to acquire a connection from the pool.
+	Exec time.Duration
+
+	// The error returned by the operation, if any.
+	Err error
+}
+
+// observer is the function called with the stats of every operation.
+var observer struct {
+	sync.RWMutex
+	fn func(OperationStats)
+}
+
+// MARK: Exported functions
+
+// SetObserver sets a function that is called with the stats of every
+// operation after it completes. A nil function removes the observer.
+//
+// The function is called synchronously, so it should return quickly.
+func SetObserver(fn func(OperationStats)) {
+	observer.Lock()
+	defer observer.Unlock()
+	observer.fn = fn
+}
+
+// Begin starts a transaction on db and reports the time taken to acquire its
+// connection to the observer.
+//
+// The transaction is in flight until it's committed or rolled back, so Drain
+// waits for it, and the operations performed in it after Drain is called
+// aren't refused.
+func Begin(db *pg.DB) (*pg.Tx, error) {
+	return BeginContext(context.Background(), db)
+}
+
+// BeginContext is identical to Begin but begins the transaction with the
+// context, ctx, so that it can be cancelled or given a deadline.
+func BeginContext(ctx context.Context, db *pg.DB) (*pg.Tx, error) {
+	if !enter(nil) {
+		return nil, ErrClosed
+	}
+
+	start := time.Now()
+	t, err := tracking(db).BeginContext(ctx)
+	observe(OperationStats{
+		Operation: OperationBegin,
+		ConnWait:  time.Since(start),
+		Err:       err,
+	})
+	if err != nil {
+		exit()
+		return nil, err
+	}
+	beginTx(t)
+	return t, nil
+}
+
+// MARK: Non-exported functions
+
+// runContext performs the operation, op, on pm with the executor, t, by
+// calling fn, applying concurrency limits, tracking it for Drain and reporting
+// its stats to the observer. It stops waiting on the concurrency limits and
+// returns ctx's error if ctx is done first.
+func runContext(ctx context.Context, op Operation, pm TableDescriber, t Executor, fn func() (orm.Result, error)) (orm.Result, error) {
+	if !enter(t) {
+		return nil, ErrClosed
+	}
+	defer exit()
+	if err := checkModel(pm); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if op == OperationSave || op == OperationSaveAll || op == OperationGetMany {
+		release, err := acquire(ctx, pm)
+		if err != nil {
+			observe(OperationStats{
+				Operation: op,
+				Schema:    pm.SchemaName(),
+				Table:     pm.TableName(),
+				LimitWait: time.Since(start),
+				Err:       err,
+			})
+			return nil, err
+		}
+		defer release()
+	}
+
+	// Perform the operation
+	executing := time.Now()
+	res, err := fn()
+	err = wrapError(err)
+
+	observe(OperationStats{
+		Operation: op,
+		Schema:    pm.SchemaName(),
+		Table:     pm.TableName(),
+		LimitWait: executing.Sub(start),
+		Exec:      time.Since(executing),
+		Err:       err,
+	})
+	return res, err
+}
+
+// observe reports s to the observer, if one is set.
+func observe(s OperationStats) {
+	observer.RLock()
+	fn := observer.fn
+	observer.RUnlock()
+
+	if fn != nil {
+		fn(s)
+	}
+}
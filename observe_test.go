@@ -0,0 +1,79 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestObserver(t *testing.T) {
+	var stats []OperationStats
+	SetObserver(func(s OperationStats) {
+		stats = append(stats, s)
+	})
+	defer SetObserver(nil)
+
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return nil, errors.New("failed")
+	}}
+	if _, err := Save(&testModel{ID: 1}, e); err == nil {
+		t.Fatal("got no error")
+	}
+
+	if len(stats) != 1 {
+		t.Fatalf("got %d stats, want 1", len(stats))
+	}
+	s := stats[0]
+	if s.Operation != OperationSave || s.Schema != "test" || s.Table != "models" || s.Err == nil {
+		t.Fatalf("got stats %+v", s)
+	}
+}
+
+func TestObserverSeparatesWaits(t *testing.T) {
+	var mu sync.Mutex
+	var stats []OperationStats
+	SetObserver(func(s OperationStats) {
+		mu.Lock()
+		defer mu.Unlock()
+		stats = append(stats, s)
+	})
+	defer SetObserver(nil)
+	SetModelConcurrencyLimit(&testModel{}, 1)
+	defer SetModelConcurrencyLimit(&testModel{}, 0)
+
+	// Hold the model's slot while the save waits for it
+	release, err := acquire(context.Background(), &testModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(20*time.Millisecond, release)
+
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		time.Sleep(10 * time.Millisecond)
+		return testResult{affected: 1}, nil
+	}}
+	if _, err := Save(&testModel{ID: 1}, e); err != nil {
+		t.Fatal(err)
+	}
+
+	// Begin reports the time taken to acquire a connection
+	if _, err := Begin(unreachableDB(t)); err == nil {
+		t.Fatal("got no error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(stats) != 2 {
+		t.Fatalf("got %d stats, want 2", len(stats))
+	}
+	if s := stats[0]; s.LimitWait < 20*time.Millisecond || s.Exec < 10*time.Millisecond || s.ConnWait != 0 {
+		t.Errorf("got save stats %+v", s)
+	}
+	if s := stats[1]; s.Operation != OperationBegin || s.ConnWait == 0 || s.LimitWait != 0 || s.Exec != 0 || s.Err == nil {
+		t.Errorf("got begin stats %+v", s)
+	}
+}
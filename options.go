@@ -0,0 +1,378 @@
+package pgmodel
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// QueryOption types configure the queries created by an operation.
+type QueryOption interface {
+	apply(o *queryOptions)
+}
+
+// queryOptionFunc adapts a function to the QueryOption interface.
+type queryOptionFunc func(o *queryOptions)
+
+// apply calls f with o.
+func (f queryOptionFunc) apply(o *queryOptions) {
+	f(o)
+}
+
+// queryOptions holds the configuration built from a set of QueryOptions.
+type queryOptions struct {
+	orderBy      []orderClause
+	searches     []search
+	conditions   []*Condition
+	duplicates   DuplicatePolicy
+	throttle     Throttle
+	progress     func(Progress)
+	chunkSize    int
+	workers      int
+	columns      []string
+	settings     []setting
+	savepoints   bool
+	schema       *string
+	table        string
+	unnest       *columnTypeCache
+	unscoped     bool
+	force        bool
+	strict       bool
+	maxRows      int
+	expectRows   *int
+	planEstimate bool
+	returning    bool
+	constraint   string
+	laterals     []Lateral
+	limit        int
+	offset       int
+	key          *statementKey
+	ctx          context.Context
+
+	// The values written to columns in place of the model's values.
+	values map[string]interface{}
+}
+
+// orderPattern matches an ordering, capturing its column or expression and its
+// optional direction and nulls ordering.
+var orderPattern = regexp.MustCompile(`(?is)^(.*?)(?:\s+(ASC|DESC))?(?:\s+NULLS\s+(FIRST|LAST))?\s*$`)
+
+// orderClause is a single expression in an ORDER BY clause.
+type orderClause struct {
+	column    string
+	collation string
+}
+
+// MARK: Exported functions
+
+// WithOrderCollate orders the results of GetMany by column, sorted using the
+// given collation. For example,
+//
+//	WithOrderCollate("name DESC", "de_DE")
+//
+// produces
+//
+//	ORDER BY "name" COLLATE "de_DE" DESC
+//
+// The column is quoted, and may be followed by ASC or DESC and NULLS FIRST or
+// NULLS LAST, as with WithOrderBy. Multiple orderings are applied in the order
+// they are given.
+func WithOrderCollate(column string, collation string) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.orderBy = append(o.orderBy, orderClause{
+			column:    column,
+			collation: collation,
+		})
+	})
+}
+
+// WithOrderBy orders the results of GetMany by the given columns or
+// expressions, each optionally followed by ASC or DESC and NULLS FIRST or
+// NULLS LAST, e.g.
+//
+//	WithOrderBy("created_at DESC NULLS LAST", "id")
+//
+// Column names are quoted, so they may be reserved words such as order.
+// Expressions are used as given. Multiple orderings are applied in the order
+// they are given.
+func WithOrderBy(columns ...string) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		for _, c := range columns {
+			o.orderBy = append(o.orderBy, orderClause{column: c})
+		}
+	})
+}
+
+// WithLimit limits the results of GetMany to at most n rows. It's usually
+// combined with WithOrderBy and WithOffset to select a page of rows.
+func WithLimit(n int) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.limit = n
+	})
+}
+
+// WithOffset skips the first n rows of the results of GetMany.
+func WithOffset(n int) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.offset = n
+	})
+}
+
+// WithReturning makes Save, Insert and UpdateByPK scan the row they write back
+// in to the model, so that the values of columns generated by the database,
+// such as identity primary keys and columns set by triggers, are read without
+// another query.
+//
+// Models with an empty primary key value are inserted with the key's default
+// value, such as the next value of a serial or identity column, instead of
+// the empty value.
+func WithReturning() QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.returning = true
+	})
+}
+
+// WithConflictConstraint makes Save, Insert and SaveIgnore resolve conflicts
+// on the named unique or exclusion constraint rather than a list of columns,
+// e.g.
+//
+//	pgmodel.Save(b, t, pgmodel.WithConflictConstraint("bookings_room_id_during_key"))
+//
+// Save updates every column of conflicting rows, including the primary key,
+// as SaveByKey does. Exclusion constraints can only be used by Insert and
+// SaveIgnore, as PostgreSQL can't update rows that conflict with them.
+func WithConflictConstraint(name string) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.constraint = name
+	})
+}
+
+// WithColumns limits the columns selected by GetMany and GetManyInto to the
+// given columns or expressions, e.g.
+//
+//	WithColumns("id", "name", "lower(email) AS email")
+//
+// Column names are quoted, so they may be reserved words such as order.
+// Expressions are used as given, and should be aliased to the name of the
+// field they're scanned in to.
+func WithColumns(columns ...string) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.columns = append(o.columns, columns...)
+	})
+}
+
+// WithSchema overrides the model's SchemaName for a single operation, such as
+// when working with a shadow copy of a table in another schema. An empty name
+// leaves the table unqualified so that it's resolved by the search path.
+func WithSchema(name string) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.schema = &name
+	})
+}
+
+// WithTable overrides the model's TableName for a single operation, such as
+// when working with temporary tables, shadow tables during migrations or
+// blue/green table swaps.
+func WithTable(name string) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.table = name
+	})
+}
+
+// MARK: Non-exported functions
+
+// withColumnValue writes v to the non-primary key column, c, in place of the
+// model's value.
+func withColumnValue(c string, v interface{}) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		if o.values == nil {
+			o.values = make(map[string]interface{})
+		}
+		o.values[c] = v
+	})
+}
+
+// convertVariables returns pm's converted non-primary key values, with the
+// values given by withColumnValue in place of the model's.
+func (o *queryOptions) convertVariables(pm PGModel) []interface{} {
+	cv := convertVariables(pm)
+	if len(o.values) == 0 {
+		return cv
+	}
+	for i, c := range pm.NonPKColumns() {
+		if v, ok := o.values[c]; ok {
+			cv[i] = v
+		}
+	}
+	return cv
+}
+
+// schemaName returns pm's schema name, or the options' schema name if one was
+// given.
+func (o *queryOptions) schemaName(pm TableDescriber) string {
+	if o.schema != nil {
+		return *o.schema
+	}
+	return pm.SchemaName()
+}
+
+// tableName returns pm's table name, or the options' table name if one was
+// given.
+func (o *queryOptions) tableName(pm TableDescriber) string {
+	if o.table != "" {
+		return o.table
+	}
+	return pm.TableName()
+}
+
+// qualifiedName returns the quoted table name of queries on pm qualified with
+// their quoted schema name, or the unqualified table name if there is no schema
+// name.
+func (o *queryOptions) qualifiedName(pm TableDescriber) string {
+	return qualify(o.schemaName(pm), o.tableName(pm))
+}
+
+// selectList returns the select list of the options for queries on pm.
+func (o *queryOptions) selectList(pm KeyedModel) string {
+	if len(o.columns) > 0 {
+		cs := make([]string, len(o.columns))
+		for i, c := range o.columns {
+			cs[i] = columnOrExpr(c)
+		}
+		return strings.Join(cs, ", ")
+	}
+	if pp, ok := pm.(PartialModel); ok {
+		return quoteList(columns(pp))
+	}
+	return "*"
+}
+
+// returningClause returns the RETURNING clause of writes of pm, or an empty
+// string if the options don't return rows.
+func (o *queryOptions) returningClause(pm PGModel) string {
+	if !o.returning {
+		return ""
+	}
+	return "RETURNING " + o.selectList(pm)
+}
+
+// conflictTarget returns the conflict target of upserts and inserts that
+// resolve conflicts on the quoted columns, ct, or on the options' constraint
+// if one was given. An empty string is returned if neither was given.
+func (o *queryOptions) conflictTarget(ct string) string {
+	switch {
+	case o.constraint != "":
+		return "ON CONSTRAINT " + quoteIdent(o.constraint)
+	case ct != "":
+		return "(" + ct + ")"
+	default:
+		return ""
+	}
+}
+
+// generatesKey returns true if writes of pm should insert the default value
+// of its primary key, such as the next value of an identity column, rather
+// than its empty primary key value, so that the generated value is returned.
+func (o *queryOptions) generatesKey(pm PGModel) bool {
+	return o.returning && len(primaryKeys(pm)) == 1 && isEmpty(pm.PrimaryKeyValue())
+}
+
+// predicates returns the additional predicates the options add to the WHERE
+// clause of queries on pm, along with their parameters, or an error if a
+// search or condition is invalid.
+func (o *queryOptions) predicates(pm KeyedModel) ([]string, []interface{}, error) {
+	var ps []string
+	var a []interface{}
+	if s := o.scope(pm); s != "" {
+		ps = append(ps, s)
+	}
+	for _, s := range o.searches {
+		p, sa, err := s.predicate(pm)
+		if err != nil {
+			return nil, nil, err
+		}
+		ps = append(ps, p)
+		a = append(a, sa...)
+	}
+	for _, c := range o.conditions {
+		p, ca, err := c.predicate()
+		if err != nil {
+			return nil, nil, err
+		}
+		ps = append(ps, p)
+		a = append(a, ca...)
+	}
+	return ps, a, nil
+}
+
+// context returns the context of the operation's queries, or the background
+// context if the operation wasn't given one.
+func (o *queryOptions) context() context.Context {
+	if o.ctx != nil {
+		return o.ctx
+	}
+	return context.Background()
+}
+
+// chunkSizeOrDefault returns the options' chunk size, or DefaultChunkSize if
+// none was set, reduced if necessary so that a chunk of pm's rows doesn't
+// exceed MaxParameters.
+func (o *queryOptions) chunkSizeOrDefault(pm PGModel) int {
+	n := DefaultChunkSize
+	if o.chunkSize > 0 {
+		n = o.chunkSize
+	}
+	if o.unnest != nil {
+		return n
+	}
+	if m := MaxParameters / len(columns(pm)); n > m {
+		n = m
+	}
+	return n
+}
+
+// newQueryOptions applies opts to a new set of query options.
+func newQueryOptions(opts []QueryOption) *queryOptions {
+	o := new(queryOptions)
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+	return o
+}
+
+// orderByClause returns the ORDER BY clause for the options, or an empty string
+// if no ordering was given.
+func (o *queryOptions) orderByClause() string {
+	if len(o.orderBy) == 0 {
+		return ""
+	}
+
+	var oc []string
+	for _, c := range o.orderBy {
+		e, m := splitOrder(c.column)
+		e = columnOrExpr(e)
+		if c.collation != "" {
+			e += " COLLATE " + quoteIdent(c.collation)
+		}
+		if m != "" {
+			e += " " + m
+		}
+		oc = append(oc, e)
+	}
+	return "ORDER BY " + strings.Join(oc, ", ")
+}
+
+// splitOrder splits the ordering, s, in to its column or expression and its
+// trailing ASC, DESC, NULLS FIRST and NULLS LAST modifiers, which are returned
+// in upper case.
+func splitOrder(s string) (string, string) {
+	m := orderPattern.FindStringSubmatch(s)
+	var ms []string
+	if m[2] != "" {
+		ms = append(ms, strings.ToUpper(m[2]))
+	}
+	if m[3] != "" {
+		ms = append(ms, "NULLS "+strings.ToUpper(m[3]))
+	}
+	return strings.TrimSpace(m[1]), strings.Join(ms, " ")
+}
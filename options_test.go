@@ -0,0 +1,147 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithOrderCollate(t *testing.T) {
+	e := new(testExecutor)
+	_, _, err := GetMany[*testModel](e, "name", "a",
+		WithOrderCollate("name", "de_DE"),
+		WithOrderCollate("id", ""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasSuffix(q, `ORDER BY "name" COLLATE "de_DE", "id"`) {
+		t.Errorf("got query %q", q)
+	}
+}
+
+func TestWithColumns(t *testing.T) {
+	type listItem struct {
+		ID   int
+		Name string
+	}
+
+	var dst []listItem
+	e := new(testExecutor)
+	if _, err := GetManyInto(&dst, &testModel{}, e, "name", "a", WithColumns("id", "upper(name) AS name")); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasPrefix(q, `SELECT "id", upper(name) AS name FROM "test"."models"`) {
+		t.Errorf("got query %q", q)
+	}
+
+	// Without columns, every column is selected
+	if _, _, err := GetMany[*testModel](e, "name", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasPrefix(q, `SELECT * FROM`) {
+		t.Errorf("got query %q", q)
+	}
+}
+
+func TestOrderAndColumnsQuoteIdentifiers(t *testing.T) {
+	e := new(testExecutor)
+	_, _, err := GetMany[*testModel](e, "name", "a",
+		WithColumns("order", "lower(name) AS name"),
+		WithOrderBy("order desc nulls last", "lower(name)"),
+		WithOrderCollate("name DESC", "de_DE"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := squash(e.last().query)
+	for _, want := range []string{
+		`SELECT "order", lower(name) AS name FROM`,
+		`ORDER BY "order" DESC NULLS LAST, lower(name), "name" COLLATE "de_DE" DESC`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query %q doesn't contain %q", q, want)
+		}
+	}
+}
+
+func TestSplitOrder(t *testing.T) {
+	for _, c := range []struct {
+		s, e, m string
+	}{
+		{"name", "name", ""},
+		{"name ASC", "name", "ASC"},
+		{"created_at desc nulls last", "created_at", "DESC NULLS LAST"},
+		{"created_at NULLS FIRST", "created_at", "NULLS FIRST"},
+		{"lower(name)  DESC", "lower(name)", "DESC"},
+		{"a || ' desc'", "a || ' desc'", ""},
+		{"descr", "descr", ""},
+	} {
+		e, m := splitOrder(c.s)
+		if e != c.e || m != c.m {
+			t.Errorf("splitOrder(%q) = %q, %q, want %q, %q", c.s, e, m, c.e, c.m)
+		}
+	}
+}
+
+func TestWithSchemaAndTable(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Get(&testModel{}, e, "id", 1, WithSchema("shadow"), WithTable("models_next")); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.Contains(q, `FROM "shadow"."models_next"`) {
+		t.Errorf("got query %q", q)
+	}
+
+	// An empty schema leaves the table unqualified
+	if _, err := Save(&testModel{ID: 1}, e, WithSchema("")); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasPrefix(q, `INSERT INTO "models"`) {
+		t.Errorf("got query %q", q)
+	}
+}
+
+func TestWithOrderByLimitOffset(t *testing.T) {
+	e := new(testExecutor)
+	if _, _, err := GetMany[*testModel](e, "name", "a", WithOrderBy("name DESC", "id"), WithLimit(10), WithOffset(20)); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasSuffix(q, `WHERE "name" = ? ORDER BY "name" DESC, "id" LIMIT 10 OFFSET 20`) {
+		t.Errorf("got query %q", q)
+	}
+}
+
+func TestWithReturning(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Save(&serialModel{Name: "new"}, e, WithReturning()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Empty primary keys are generated by the database
+	q := squash(e.last().query)
+	if !strings.HasPrefix(q, `INSERT INTO "test"."serials" ("id", "name") VALUES (DEFAULT, ?)`) || !strings.HasSuffix(q, "RETURNING *") {
+		t.Errorf("got query %q", q)
+	}
+
+	// Without the option, nothing is returned
+	if _, err := Save(&serialModel{ID: 1, Name: "new"}, e); err != nil {
+		t.Fatal(err)
+	}
+	if q := e.last().query; strings.Contains(q, "RETURNING") {
+		t.Errorf("got query %q", q)
+	}
+}
+
+func TestWithReturningScansGeneratedKey(t *testing.T) {
+	tx := testTx(t)
+	createSerialTable(t, tx)
+
+	m := &serialModel{Name: "new"}
+	if _, err := Save(m, tx, WithReturning()); err != nil {
+		t.Fatal(err)
+	}
+	if m.ID == 0 {
+		t.Error("the generated primary key wasn't scanned in to the model")
+	}
+}
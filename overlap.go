@@ -0,0 +1,124 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// MARK: Exported functions
+
+// InsertIfNoOverlap inserts pm as a new row in the given transaction unless
+// the range in its rangeColumn overlaps the range of an existing row with the
+// same values in keyColumns, such as a booking of the same room, in which case
+// ErrOverlap is returned and nothing is inserted.
+//
+// The check and insert are made atomic by a transaction-level advisory lock
+// on the model's table and key values, so concurrent calls for the same key
+// are performed one at a time at any isolation level. Rows written by other
+// means aren't locked out, so tables should also have an exclusion
+// constraint, whose violations are returned as an *ExclusionError. The insert
+// is made inside a savepoint, so the transaction can continue to be used
+// after either error.
+//
+// If the model has an ID generator set by SetIDGenerator and its primary key
+// value is empty, a new value is generated before the query is performed.
+func InsertIfNoOverlap(pm PGModel, t *pg.Tx, rangeColumn string, keyColumns ...string) (*Result, error) {
+	return InsertIfNoOverlapContext(context.Background(), pm, t, rangeColumn, keyColumns...)
+}
+
+// InsertIfNoOverlapContext is identical to InsertIfNoOverlap but performs its
+// queries with the context, ctx, so that they can be cancelled or given a
+// deadline.
+func InsertIfNoOverlapContext(ctx context.Context, pm PGModel, t *pg.Tx, rangeColumn string, keyColumns ...string) (*Result, error) {
+	if err := errPartial(pm, "InsertIfNoOverlap"); err != nil {
+		return nil, err
+	}
+	if err := beforeSave(ctx, pm, t); err != nil {
+		return nil, err
+	}
+	if err := validateColumns(pm, append([]string{rangeColumn}, keyColumns...)); err != nil {
+		return nil, err
+	}
+	if err := assignID(pm); err != nil {
+		return nil, err
+	}
+
+	// Find the values of the range and key columns
+	cv := make(map[string]interface{})
+	v := values(pm)
+	for i, c := range columns(pm) {
+		cv[c] = v[i]
+	}
+	lk := []string{qualify(pm.SchemaName(), pm.TableName())}
+	var ka []interface{}
+	for _, k := range keyColumns {
+		lk = append(lk, fmt.Sprint(cv[k]))
+		ka = append(ka, cv[k])
+	}
+
+	defer InvalidateCache(pm)
+	q := createInsertIfNoOverlapQuery(pm, rangeColumn, keyColumns)
+	a := append(append(insertValues(pm), ka...), cv[rangeColumn])
+
+	var res orm.Result
+	err := savepoint(t, func() error {
+		if _, err := t.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext(?))`, strings.Join(lk, ", ")); err != nil {
+			return err
+		}
+
+		var err error
+		res, err = runContext(ctx, OperationSave, pm, t, func() (orm.Result, error) {
+			return t.QueryContext(ctx, pm, q, a...)
+		})
+		return err
+	})
+	switch {
+	case err != nil:
+	case res.RowsAffected() == 0:
+		err = ErrOverlap
+	default:
+		err = afterSave(ctx, pm, t)
+	}
+	return newResult(res, q), err
+}
+
+// MARK: Non-exported functions
+
+// createInsertIfNoOverlapQuery creates a query inserting pm unless its range
+// in the range column, rc, overlaps the range of a row with the same values in
+// the key columns, kc.
+func createInsertIfNoOverlapQuery(pm PGModel, rc string, kc []string) string {
+	// Get everything once
+	qn := qualify(pm.SchemaName(), pm.TableName())
+	c := columns(pm)
+
+	// Create arrays to join
+	var im []string
+	for _, u := range c {
+		im = append(im, insertValue(pm, u))
+	}
+	var ps []string
+	for _, k := range kc {
+		ps = append(ps, fmt.Sprintf("%s = ?", quoteIdent(k)))
+	}
+	ps = append(ps, fmt.Sprintf("%s && ?", quoteIdent(rc)))
+
+	// Create the query
+	return fmt.Sprintf(
+		`INSERT INTO %s (%s)
+		SELECT %s
+		WHERE NOT EXISTS (
+			SELECT 1 FROM %s
+			WHERE %s
+		)`,
+		qn,
+		quoteList(c),
+		strings.Join(im, ", "),
+		qn,
+		strings.Join(ps, " AND "),
+	)
+}
@@ -0,0 +1,47 @@
+package pgmodel
+
+import (
+	"errors"
+	"testing"
+)
+
+// bookingModel is a model of the test.bookings table of room bookings.
+type bookingModel struct {
+	Base[bookingModel] `pgmodel:"test.bookings"`
+	ID                 int    `pg:"id,pk"`
+	RoomID             int    `pg:"room_id"`
+	During             string `pg:"during"`
+}
+
+func TestCreateInsertIfNoOverlapQuery(t *testing.T) {
+	q := squash(createInsertIfNoOverlapQuery(&bookingModel{}, "during", []string{"room_id"}))
+	want := `INSERT INTO "test"."bookings" ("id", "room_id", "during") SELECT ?, ?, ? WHERE NOT EXISTS ( SELECT 1 FROM "test"."bookings" WHERE "room_id" = ? AND "during" && ? )`
+	if q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+}
+
+func TestInsertIfNoOverlap(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.bookings (id int PRIMARY KEY, room_id int, during tstzrange)`,
+	)
+
+	b := &bookingModel{ID: 1, RoomID: 1, During: "[2024-01-01 10:00, 2024-01-01 11:00)"}
+	if _, err := InsertIfNoOverlap(b, tx, "during", "room_id"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overlapping bookings of the same room are rejected
+	b = &bookingModel{ID: 2, RoomID: 1, During: "[2024-01-01 10:30, 2024-01-01 12:00)"}
+	if _, err := InsertIfNoOverlap(b, tx, "during", "room_id"); !errors.Is(err, ErrOverlap) {
+		t.Fatalf("got error %v, want ErrOverlap", err)
+	}
+
+	// The transaction can still be used, and other rooms aren't affected
+	b.RoomID = 2
+	if _, err := InsertIfNoOverlap(b, tx, "during", "room_id"); err != nil {
+		t.Fatal(err)
+	}
+}
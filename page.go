@@ -0,0 +1,172 @@
+package pgmodel
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Cursor is a position in the rows of a table sorted by a column and then by
+// primary key, used by GetPage for keyset pagination.
+//
+// Unlike OFFSET, which reads and discards every row before the page, keyset
+// pagination seeks directly to the page with the index on the sort columns,
+// so later pages are as fast as the first.
+type Cursor struct {
+
+	// The column the rows are sorted by before their primary key. The rows are
+	// only sorted by primary key if it's empty.
+	Column string
+
+	// Whether the rows are sorted in descending order.
+	Desc bool
+
+	// The values of the sort column, if any, and primary key of the last row of
+	// the previous page. The first page is returned when it's empty.
+	After []interface{}
+}
+
+// MARK: Exported functions
+
+// GetPage gets the page of at most pageSize models of type T that follows the
+// cursor, c, and returns it with the cursor of the next page, or nil if it's
+// the last page, e.g.
+//
+//	c := &pgmodel.Cursor{Column: "created_at", Desc: true}
+//	for c != nil {
+//		var bs []*Bar
+//		bs, c, err = pgmodel.GetPage[*Bar](t, *c, 100)
+//		...
+//	}
+//
+// The sort column must be one of the model's columns and shouldn't be
+// nullable, and the table should have an index on the sort column and primary
+// key. Options that filter rows, such as Where, may be given, but orderings
+// are replaced by the cursor's.
+func GetPage[T PGModel](t Executor, c Cursor, pageSize int, opts ...QueryOption) ([]T, *Cursor, error) {
+	m := newModel[T]()
+	if pageSize <= 0 {
+		return nil, nil, fmt.Errorf("pgmodel: page size must be positive")
+	}
+	if c.Column != "" {
+		if err := validateColumns(m, []string{c.Column}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	o := newQueryOptions(opts)
+	o.orderBy = nil
+	o.offset = 0
+	o.limit = pageSize + 1
+
+	// Sort by the cursor's columns
+	cs := c.columns(m)
+	dir := "ASC"
+	if c.Desc {
+		dir = "DESC"
+	}
+	for _, u := range cs {
+		o.orderBy = append(o.orderBy, orderClause{column: u + " " + dir})
+	}
+
+	// Seek past the previous page
+	p := "TRUE"
+	if len(c.After) > 0 {
+		if len(c.After) != len(cs) {
+			return nil, nil, fmt.Errorf("pgmodel: cursor has %d values for %d columns", len(c.After), len(cs))
+		}
+		cmp := ">"
+		if c.Desc {
+			cmp = "<"
+		}
+		p = fmt.Sprintf("(%s) %s (%s)", strings.Join(cs, ", "), cmp, strings.TrimSuffix(strings.Repeat("?, ", len(cs)), ", "))
+	}
+	q, a, err := createSelectQuery(m, p, append([]interface{}{}, c.After...), o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ms []T
+	_, err = runContext(o.context(), OperationGetMany, m, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryContext(o.context(), &ms, q, a...)
+		})
+		normalizeTimes(&ms)
+		return res, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ms) <= pageSize {
+		return ms, nil, nil
+	}
+
+	ms = ms[:pageSize]
+	next := c
+	next.After = c.values(ms[pageSize-1])
+	return ms, &next, nil
+}
+
+// Encode returns the cursor's values as an opaque string that can be given to
+// the clients of an API and passed back to Decode. The sort column and order
+// aren't encoded, so clients can't choose the columns rows are sorted by.
+func (c Cursor) Encode() (string, error) {
+	b, err := json.Marshal(c.After)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Decode sets the cursor's values from the string, s, returned by Encode. An
+// empty string clears the values so that the cursor is of the first page.
+func (c *Cursor) Decode(s string) error {
+	c.After = nil
+	if s == "" {
+		return nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("pgmodel: invalid cursor: %w", err)
+	}
+
+	// Numbers are kept as strings so that large keys aren't rounded
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&c.After); err != nil {
+		return fmt.Errorf("pgmodel: invalid cursor: %w", err)
+	}
+	return nil
+}
+
+// MARK: Non-exported functions
+
+// columns returns the quoted columns the cursor sorts pm's rows by.
+func (c Cursor) columns(pm PGModel) []string {
+	cs := quoteIdents(primaryKeys(pm))
+	if c.Column != "" {
+		cs = append([]string{quoteIdent(c.Column)}, cs...)
+	}
+	return cs
+}
+
+// values returns pm's values of the columns the cursor sorts rows by.
+func (c Cursor) values(pm PGModel) []interface{} {
+	vs := primaryKeyValues(pm)
+	if c.Column == "" {
+		return vs
+	}
+
+	cs := columns(pm)
+	for i, v := range values(pm) {
+		if cs[i] == c.Column {
+			return append([]interface{}{v}, vs...)
+		}
+	}
+	return vs
+}
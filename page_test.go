@@ -0,0 +1,66 @@
+package pgmodel
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestGetPage(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		ms := model.(*[]*testModel)
+		*ms = append(*ms, &testModel{ID: 5, Name: "e"}, &testModel{ID: 4, Name: "d"}, &testModel{ID: 3, Name: "c"})
+		return testResult{returned: 3}, nil
+	}}
+
+	c := Cursor{Column: "name", Desc: true, After: []interface{}{"f", 6}}
+	ms, next, err := GetPage[*testModel](e, c, 2, WithOrderBy("id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `WHERE ("name", "id") < (?, ?) ORDER BY "name" DESC, "id" DESC LIMIT 3`
+	if q := squash(e.last().query); !strings.HasSuffix(q, want) {
+		t.Errorf("got query %q, want it to end with %q", q, want)
+	}
+
+	// The extra row is dropped and the next page follows the last row
+	if len(ms) != 2 || next == nil || !reflect.DeepEqual(next.After, []interface{}{"d", 4}) {
+		t.Errorf("got %d models and cursor %+v", len(ms), next)
+	}
+}
+
+func TestGetPageLastPage(t *testing.T) {
+	e := new(testExecutor)
+	ms, next, err := GetPage[*testModel](e, Cursor{}, 10)
+	if err != nil || next != nil || len(ms) != 0 {
+		t.Errorf("got %v, %+v, %v, want the last page", ms, next, err)
+	}
+	if _, _, err := GetPage[*testModel](e, Cursor{Column: "missing"}, 10); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+	if _, _, err := GetPage[*testModel](e, Cursor{After: []interface{}{1, 2}}, 10); err == nil {
+		t.Error("expected an error for the wrong number of values")
+	}
+}
+
+func TestCursorEncode(t *testing.T) {
+	c := Cursor{After: []interface{}{"a", 9007199254740993}}
+	s, err := c.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d Cursor
+	if err := d.Decode(s); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d.After, []interface{}{"a", json.Number("9007199254740993")}) {
+		t.Errorf("got values %v", d.After)
+	}
+	if err := d.Decode("!"); err == nil {
+		t.Error("expected an error for an invalid cursor")
+	}
+}
@@ -0,0 +1,34 @@
+package pgmodel
+
+import "fmt"
+
+// PartialModel types are models that map a subset of their table's columns,
+// such as a UserSummary over the same table as a wider User model.
+//
+// Queries for partial models select only the model's columns rather than all
+// of the table's columns. Save only updates the model's columns on existing
+// rows, never inserting a row that would be missing the table's other
+// columns, and upserts that could insert rows return an error.
+type PartialModel interface {
+	PGModel
+
+	// Partial is a marker method with no behavior.
+	Partial()
+}
+
+// MARK: Non-exported functions
+
+// isPartial returns whether pm is a partial model.
+func isPartial(pm PGModel) bool {
+	_, ok := pm.(PartialModel)
+	return ok
+}
+
+// errPartial returns an error if pm is a partial model, which the operation,
+// op, can't be performed on.
+func errPartial(pm PGModel, op string) error {
+	if isPartial(pm) {
+		return fmt.Errorf("pgmodel: %s can't be used with the partial model %T", op, pm)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+)
+
+// summaryModel is a partial model of the test.models table.
+type summaryModel struct {
+	Base[summaryModel] `pgmodel:"test.models"`
+	ID                 int    `pg:"id,pk"`
+	Name               string `pg:"name"`
+}
+
+func (m *summaryModel) Partial() {}
+
+func TestPartialModelSelectsColumns(t *testing.T) {
+	e := new(testExecutor)
+	if _, _, err := GetMany[*summaryModel](e, "name", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasPrefix(q, `SELECT "id", "name" FROM "test"."models"`) {
+		t.Errorf("got query %q", q)
+	}
+}
+
+func TestPartialModelSave(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Save(&summaryModel{ID: 1, Name: "one"}, e); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasPrefix(q, `UPDATE "test"."models"`) {
+		t.Errorf("got query %q, want an update", q)
+	}
+
+	// Operations that could insert rows aren't allowed
+	if _, err := SaveByKey(&summaryModel{ID: 1}, e, "name"); err == nil || !strings.Contains(err.Error(), "partial") {
+		t.Errorf("got error %v, want a partial model error", err)
+	}
+	if n := e.count(); n != 1 {
+		t.Errorf("got %d queries, want 1", n)
+	}
+}
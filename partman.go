@@ -0,0 +1,123 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// PartmanOptions configures the registration of a table with pg_partman by
+// CreatePartmanParent.
+type PartmanOptions struct {
+
+	// The time or integer column the table is partitioned by.
+	Control string
+
+	// The range of values in each partition, such as "1 day" for time columns
+	// or "100000" for integer columns.
+	Interval string
+
+	// The number of partitions created ahead of the current one. Defaults to
+	// pg_partman's default.
+	Premake int
+
+	// The lower bound of the first partition, such as "2024-01-01". Defaults to
+	// pg_partman's default, which is based on the current time or the table's
+	// existing rows.
+	StartPartition string
+}
+
+// PartmanConfig is a table's pg_partman configuration.
+type PartmanConfig struct {
+
+	// The table's qualified name, e.g. "public.events".
+	ParentTable string `pg:"parent_table"`
+
+	// The column the table is partitioned by.
+	Control string `pg:"control"`
+
+	// The range of values in each partition.
+	Interval string `pg:"partition_interval"`
+
+	// The partitioning method, such as "range".
+	Type string `pg:"partition_type"`
+
+	// The number of partitions created ahead of the current one.
+	Premake int `pg:"premake"`
+
+	// Whether partitions are created by pg_partman's maintenance.
+	AutomaticMaintenance bool `pg:"automatic_maintenance"`
+
+	// The age of the partitions that maintenance removes, or an empty string
+	// if partitions are kept.
+	Retention string `pg:"retention"`
+
+	// Whether removed partitions are detached rather than dropped.
+	RetentionKeepTable bool `pg:"retention_keep_table"`
+
+	// Whether time partitions are created even if no rows have been inserted
+	// in to the table recently.
+	InfiniteTimePartitions bool `pg:"infinite_time_partitions"`
+}
+
+// MARK: Exported functions
+
+// CreatePartmanParent registers pm's table with pg_partman so that its
+// partitions are created, and optionally removed, by pg_partman's maintenance
+// rather than by the application. The table must already be declared as
+// partitioned by the control column, e.g.
+//
+//	pgmodel.CreatePartmanParent(t, &Event{}, pgmodel.PartmanOptions{
+//		Control:  "created_at",
+//		Interval: "1 day",
+//	})
+//
+// pg_partman 5 or later must be installed in the partman schema. Its
+// maintenance, partman.run_maintenance(), can be scheduled with ScheduleJob.
+func CreatePartmanParent(t *pg.Tx, pm PGModel, opts PartmanOptions) error {
+	if err := validateColumns(pm, []string{opts.Control}); err != nil {
+		return err
+	}
+	if opts.Interval == "" {
+		return fmt.Errorf("pgmodel: no partition interval given for %s.%s", pm.SchemaName(), pm.TableName())
+	}
+
+	// Only pass the parameters given, so that pg_partman's defaults apply
+	ps := []string{"p_parent_table := ?", "p_control := ?", "p_interval := ?"}
+	pa := []interface{}{partmanTable(pm), opts.Control, opts.Interval}
+	if opts.Premake > 0 {
+		ps = append(ps, "p_premake := ?")
+		pa = append(pa, opts.Premake)
+	}
+	if opts.StartPartition != "" {
+		ps = append(ps, "p_start_partition := ?")
+		pa = append(pa, opts.StartPartition)
+	}
+
+	_, err := t.Exec(fmt.Sprintf(`SELECT partman.create_parent(%s)`, strings.Join(ps, ", ")), pa...)
+	return wrapError(err)
+}
+
+// GetPartmanConfig returns the pg_partman configuration of pm's table with
+// the given executor. An error matching ErrNotFound is returned if the table
+// isn't registered with pg_partman.
+func GetPartmanConfig(t Executor, pm TableDescriber) (PartmanConfig, error) {
+	var c PartmanConfig
+	_, err := t.QueryOne(&c, `
+		SELECT parent_table, control, partition_interval, partition_type, premake,
+			automatic_maintenance = 'on' AS automatic_maintenance, retention,
+			retention_keep_table, infinite_time_partitions
+		FROM partman.part_config
+		WHERE parent_table = ?`,
+		partmanTable(pm),
+	)
+	return c, wrapError(err)
+}
+
+// MARK: Non-exported functions
+
+// partmanTable returns the name pg_partman stores pm's table by.
+func partmanTable(pm TableDescriber) string {
+	return pm.SchemaName() + "." + pm.TableName()
+}
@@ -0,0 +1,70 @@
+package pgmodel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestCreatePartmanParentRequiresOptions(t *testing.T) {
+	for _, opts := range []PartmanOptions{
+		{Control: "created_at"},
+		{Control: "missing", Interval: "1 day"},
+		{Control: "created_at; --", Interval: "1 day"},
+	} {
+		if err := CreatePartmanParent(nil, &eventModel{}, opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestGetPartmanConfigQuery(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		model.(*PartmanConfig).Control = "created_at"
+		return testResult{returned: 1}, nil
+	}}
+	c, err := GetPartmanConfig(e, &eventModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Control != "created_at" {
+		t.Errorf("got config %+v", c)
+	}
+	if q, p := e.last().query, e.last().params; !strings.Contains(q, "FROM partman.part_config") || len(p) != 1 || p[0] != "test.events" {
+		t.Errorf("got query %q with parameters %v", q, p)
+	}
+
+	// Unregistered tables aren't found
+	e.handle = func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return nil, pg.ErrNoRows
+	}
+	if _, err := GetPartmanConfig(e, &eventModel{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestCreatePartmanParent(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.events (id int, created_at timestamptz NOT NULL) PARTITION BY RANGE (created_at)`,
+	)
+	if err := CreatePartmanParent(tx, &eventModel{}, PartmanOptions{
+		Control:  "created_at",
+		Interval: "1 day",
+		Premake:  2,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := GetPartmanConfig(tx, &eventModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ParentTable != "test.events" || c.Control != "created_at" || c.Premake != 2 {
+		t.Errorf("got config %+v", c)
+	}
+}
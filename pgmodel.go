@@ -4,6 +4,7 @@
 package pgmodel
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
@@ -12,22 +13,37 @@ import (
 	"github.com/go-pg/pg/v10/orm"
 )
 
-// PGModel interface types implement methods that describe their table.
-type PGModel interface {
+// TableDescriber types describe the table that a model's rows belong to.
+type TableDescriber interface {
+
+	// The schema name of the model's table.
+	SchemaName() string
+
+	// The model's table name.
+	TableName() string
+}
+
+// KeyedModel types describe their table and primary key. They are all that
+// read-only models, which are only used with functions like Get and GetMany,
+// need to implement.
+type KeyedModel interface {
+	TableDescriber
 
 	// The model's primary key.
 	PrimaryKey() string
 
 	// The value of the model's primary key.
 	PrimaryKeyValue() interface{}
+}
 
-	// The schema name of the model's table.
-	SchemaName() string
-
-	// The model's table name.
-	TableName() string
+// PGModel interface types implement methods that describe their table.
+type PGModel interface {
+	KeyedModel
 
 	// The total number of columns in the table.
+	//
+	// Deprecated: ColumnCount is no longer used. The number of columns is
+	// derived from NonPKColumns.
 	ColumnCount() int
 
 	// An array of non-primary key column names.
@@ -36,6 +52,12 @@ type PGModel interface {
 	// An array of non-primary key values in the same order as the columns defined
 	// by NonPKColumns.
 	NonPKValues() []interface{}
+}
+
+// SliceConverter types convert the values of their slice columns for queries.
+// Models with slice values that don't implement SliceConverter have their
+// slices passed as Postgres arrays, or bytea values for byte slices.
+type SliceConverter interface {
 
 	// Converts the model's slice from column, c, to a string value.
 	//
@@ -54,115 +76,358 @@ type PGModel interface {
 
 // MARK: Exported functions
 
-// Get is identical to GetMany but QueryOne is called instead of Query on the
-// transaction.
-func Get(pm PGModel, t *pg.Tx, queryKey string, queryValue interface{}) (orm.Result, error) {
-	return t.QueryOne(pm, createGetQuery(pm, queryKey, queryValue), queryValue)
+// Get gets the row matching the given queryKey and queryValue with the given
+// executor, which may be a *pg.DB, *pg.Tx or *pg.Conn, and scans it in to pm.
+//
+// The model's schema, table and column names are quoted in every query. The
+// queryKey must be a column name, such as "order" or "UserID", which may be
+// qualified and is quoted. Other keys, such as expressions, return an error so
+// that keys can't be used to inject SQL. Use GetFold to compare a column
+// without regard to case.
+func Get(pm KeyedModel, t Executor, queryKey string, queryValue interface{}, opts ...QueryOption) (*Result, error) {
+	return GetContext(context.Background(), pm, t, queryKey, queryValue, opts...)
 }
 
-// GetMany gets the entity defined by the slice of models in the given
-// transaction by querying for the given queryKey and queryValue.
-func GetMany(pm []PGModel, t *pg.Tx, queryKey string, queryValue interface{}) (orm.Result, error) {
-	m := reflect.New(reflect.TypeOf(pm)).Elem().Interface().(PGModel)
-	return t.Query(&pm, createGetQuery(m, queryKey, queryValue), queryValue)
+// GetContext is identical to Get but performs its queries with the context,
+// ctx, so that they can be cancelled or given a deadline.
+func GetContext(ctx context.Context, pm KeyedModel, t Executor, queryKey string, queryValue interface{}, opts ...QueryOption) (*Result, error) {
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	q, a, err := createGetQuery(pm, queryKey, queryValue, o)
+	if err != nil {
+		return nil, err
+	}
+	res, err := runContext(o.context(), OperationGet, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			res, err := o.queryOne(t, pm, q, a)
+			return o.checkRows(OperationGet, pm, t, queryKey, queryValue, res, err)
+		})
+		normalizeTimes(pm)
+		return res, err
+	})
+	if err == nil {
+		err = afterGet(ctx, pm, t)
+	}
+	return newResult(res, q), err
+}
+
+// GetManyInto is identical to GetMany but scans the rows in to dst, which must
+// be a pointer to a slice of any struct type. Combined with WithColumns, this
+// lets list views scan a subset of a model's columns in to lightweight
+// structs.
+func GetManyInto(dst interface{}, pm KeyedModel, t Executor, queryKey string, queryValue interface{}, opts ...QueryOption) (*Result, error) {
+	o := newQueryOptions(opts)
+	q, a, err := createGetQuery(pm, queryKey, queryValue, o)
+	if err != nil {
+		return nil, err
+	}
+	res, err := runContext(o.context(), OperationGetMany, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			res, err := o.query(t, dst, q, a)
+			return o.checkRows(OperationGetMany, pm, t, queryKey, queryValue, res, err)
+		})
+		normalizeTimes(dst)
+		return res, err
+	})
+	if err == nil {
+		err = afterGet(o.context(), dst, t)
+	}
+	return newResult(res, q), err
+}
+
+// Save performs an upsert with the given executor. Partial models are only
+// updated, and ConflictModel types upsert against their conflict columns
+// unless a constraint is given by WithConflictConstraint.
+//
+// If the model has an ID generator set by SetIDGenerator and its primary key
+// value is empty, a new value is generated before the query is performed.
+// Models implementing BeforeSaver and AfterSaver have their hooks called
+// before the model's values are read and after the model is saved.
+func Save(pm PGModel, t Executor, opts ...QueryOption) (*Result, error) {
+	return SaveContext(context.Background(), pm, t, opts...)
+}
+
+// SaveContext is identical to Save but performs its queries with the context,
+// ctx, so that they can be cancelled or given a deadline.
+func SaveContext(ctx context.Context, pm PGModel, t Executor, opts ...QueryOption) (*Result, error) {
+	if err := beforeSave(ctx, pm, t); err != nil {
+		return nil, err
+	}
+	res, err := saveContext(ctx, pm, t, opts)
+	if err == nil {
+		err = afterSave(ctx, pm, t)
+	}
+	return res, err
 }
 
-// Save performs an upsert in the given transaction.
-func Save(pm PGModel, t *pg.Tx) (orm.Result, error) {
-	pkv := convertVariable(pm, pm.PrimaryKeyValue(), pm.PrimaryKey())
-	npkv := convertVariables(pm)
+// Delete deletes the model with the given executor. SoftDeletable models are
+// marked as deleted instead of being removed from their table.
+//
+// Models implementing BeforeDeleter and AfterDeleter have their hooks called
+// before and after the model is deleted.
+func Delete(pm PGModel, t Executor, opts ...QueryOption) (*Result, error) {
+	return DeleteContext(context.Background(), pm, t, opts...)
+}
+
+// DeleteContext is identical to Delete but performs its queries with the
+// context, ctx, so that they can be cancelled or given a deadline.
+func DeleteContext(ctx context.Context, pm PGModel, t Executor, opts ...QueryOption) (*Result, error) {
+	if err := beforeDelete(ctx, pm, t); err != nil {
+		return nil, err
+	}
+
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	defer InvalidateCache(pm)
+	var q string
+	if o.deletedAtColumn(pm) != "" {
+		q = createSoftDeleteQuery(pm, o)
+	} else {
+		q = createDeleteQuery(pm, o)
+	}
+	res, err := runContext(o.context(), OperationDelete, pm, t, func() (orm.Result, error) {
+		return o.withSettings(t, func() (orm.Result, error) {
+			return o.query(t, pm, q, primaryKeyValues(pm))
+		})
+	})
+	if err == nil {
+		err = afterDelete(ctx, pm, t)
+	}
+	return newResult(res, q), err
+}
+
+// MARK: Non-exported functions
+
+// saveContext performs the upsert of SaveContext without calling pm's hooks.
+func saveContext(ctx context.Context, pm PGModel, t Executor, opts []QueryOption) (*Result, error) {
+	if err := assignID(pm); err != nil {
+		return nil, err
+	}
+
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	pkv := primaryKeyValues(pm)
+	defer InvalidateCache(pm)
+	if isPartial(pm) {
+		return update(pm, t, pkv, o.convertVariables(pm), o)
+	}
+	if o.constraint != "" {
+		return saveByKey(pm, t, nil, o)
+	}
+	if cm, ok := pm.(ConflictModel); ok {
+		if err := validateColumns(pm, cm.ConflictColumns()); err != nil {
+			return nil, err
+		}
+		return saveByKey(pm, t, cm.ConflictColumns(), o)
+	}
+	return save(pm, t, pkv, o.convertVariables(pm), o)
+}
 
+// save performs an upsert of pm with the converted primary key values, pkv,
+// and non-primary key values, npkv, applying the settings of the options, o.
+func save(pm PGModel, t Executor, pkv []interface{}, npkv []interface{}, o *queryOptions) (*Result, error) {
 	// Create total column/value slices
-	v := append([]interface{}{pkv}, npkv...)
+	npkv = stampValues(pm, npkv)
+	v := append(append([]interface{}{}, pkv...), npkv...)
+	if o.generatesKey(pm) {
+		v = v[len(pkv):]
+	}
 
 	// Create our inputs
 	tv := append(v, npkv...)
-	tv = append(tv, pkv)
+	tv = append(tv, pkv...)
 
 	// Perform the query
-	return t.Query(pm, createSaveQuery(pm), tv...)
+	q := createSaveQuery(pm, o)
+	res, err := runContext(o.context(), OperationSave, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return o.query(t, pm, q, tv)
+		})
+		if o.returning {
+			normalizeTimes(pm)
+		}
+		return res, err
+	})
+	return newResult(res, q), err
 }
 
-// Delete deletes the model from the transaction.
-func Delete(pm PGModel, t *pg.Tx) (orm.Result, error) {
-	return t.Query(pm, createDeleteQuery(pm), pm.PrimaryKeyValue())
+// update updates pm's existing row with the converted primary key values, pkv,
+// and non-primary key values, npkv, applying the settings of the options, o.
+func update(pm PGModel, t Executor, pkv []interface{}, npkv []interface{}, o *queryOptions) (*Result, error) {
+	// Create our inputs
+	tv := append(stampValues(pm, npkv), pkv...)
+
+	// Perform the query
+	q, _ := o.statement(pm, "update", func() (string, error) {
+		return createUpdateQuery(pm, pm.NonPKColumns(), keyPredicate(pm, o.tableName(pm)), o), nil
+	})
+	res, err := runContext(o.context(), OperationSave, pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return o.query(t, pm, q, tv)
+		})
+		if o.returning {
+			normalizeTimes(pm)
+		}
+		return res, err
+	})
+	return newResult(res, q), err
 }
 
-// MARK: Non-exported functions
+// createGetQuery creates a get query from the given queryKey and queryValue
+// and returns it with its parameters. An empty queryKey matches every row.
+func createGetQuery(pm KeyedModel, queryKey string, queryValue interface{}, o *queryOptions) (string, []interface{}, error) {
+	var pa []interface{}
+	if queryKey != "" {
+		pa = []interface{}{queryValue}
+	}
+
+	// Cached queries have no parameters other than the queryValue
+	a := pa
+	q, err := o.statement(pm, "get "+queryKey, func() (string, error) {
+		p := "TRUE"
+		if queryKey != "" {
+			k, err := queryKeyExpr(queryKey)
+			if err != nil {
+				return "", err
+			}
+			p = fmt.Sprintf("%s = ?", k)
+		}
+
+		var q string
+		var err error
+		q, a, err = createSelectQuery(pm, p, pa, o)
+		return q, err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return q, a, nil
+}
 
-// createGetQuery creates a get query from the given queryKey and queryValue.
-func createGetQuery(pm PGModel, queryKey string, queryValue interface{}) string {
+// createSelectQuery creates a query selecting the rows matching the predicate,
+// p, with parameters, pa, and returns it with the parameters of all of its
+// predicates.
+func createSelectQuery(pm KeyedModel, p string, pa []interface{}, o *queryOptions) (string, []interface{}, error) {
 	// Get everything once
-	sn := pm.SchemaName()
-	tn := pm.TableName()
+	qn := o.qualifiedName(pm)
+
+	// Add the option predicates
+	op, oa, err := o.predicates(pm)
+	if err != nil {
+		return "", nil, err
+	}
+	ps := append([]string{p}, op...)
+	a := append(pa, oa...)
 
 	// Create the query
 	return fmt.Sprintf(
-		`SELECT * FROM %s.%s
-		WHERE %s = ?`,
-		sn,
-		tn,
-		queryKey,
-	)
+		`SELECT %s FROM %s
+		%s
+		WHERE %s
+		%s
+		%s`,
+		o.lateralList(o.selectList(pm)),
+		qn,
+		o.lateralJoins(pm),
+		strings.Join(ps, " AND "),
+		o.orderByClause(),
+		o.limitClause(),
+	), a, nil
 }
 
 // createSaveQuery creates a save query.
-func createSaveQuery(pm PGModel) string {
-	// Get everything once
-	pk := pm.PrimaryKey()
-	sn := pm.SchemaName()
-	tn := pm.TableName()
-	cc := pm.ColumnCount()
-	npkc := pm.NonPKColumns()
+func createSaveQuery(pm PGModel, o *queryOptions) string {
+	// Create the query
+	q, _ := o.statement(pm, "save", func() (string, error) {
+		return createUpsertQuery(
+			pm,
+			quoteList(primaryKeys(pm)),
+			pm.NonPKColumns(),
+			"WHERE "+keyPredicate(pm, o.tableName(pm)),
+			o,
+		), nil
+	})
+	return q
+}
 
-	// Create total column/value slices
-	c := append([]string{pk}, npkc...)
+// createUpsertQuery creates an upsert query that resolves conflicts on the
+// conflict target, ct, by setting the columns, sc, on rows matching the
+// optional where clause, w.
+func createUpsertQuery(pm PGModel, ct string, sc []string, w string, o *queryOptions) string {
+	// Get everything once
+	qn := o.qualifiedName(pm)
+	c := columns(pm)
 
 	// Create arrays to join
 	var im, sm []string
-	im = append(im, "?")
-	for i := 0; i < cc-1; i++ {
-		im = append(im, "?")
-		sm = append(sm, fmt.Sprintf("%s = ?", npkc[i]))
+	for _, u := range c {
+		im = append(im, insertValue(pm, u))
+	}
+	if o.generatesKey(pm) {
+		im[0] = "DEFAULT"
+	}
+	for _, u := range sc {
+		sm = append(sm, fmt.Sprintf("%s = %s", quoteIdent(u), setValue(pm, o.tableName(pm), u)))
 	}
 
 	// Create the query
 	return fmt.Sprintf(
-		`INSERT INTO %s.%s (%s) 
+		`INSERT INTO %s (%s) 
 		VALUES (%s) 
-		ON CONFLICT (%s) 
+		ON CONFLICT %s 
 		DO UPDATE
 		SET %s 
-		WHERE %s.%s = ?`,
-		sn,
-		tn,
-		strings.Join(c, ", "),
+		%s
+		%s`,
+		qn,
+		quoteList(c),
 		strings.Join(im, ", "),
-		pk,
+		o.conflictTarget(ct),
 		strings.Join(sm, ", "),
-		tn,
-		pk,
+		o.scopedWhere(pm, w),
+		o.returningClause(pm),
 	)
 }
 
-// createDeleteQuery creates a delete query.
-func createDeleteQuery(pm PGModel) string {
+// createUpdateQuery creates a query that sets the columns, sc, on the rows
+// matching the predicate, p.
+func createUpdateQuery(pm PGModel, sc []string, p string, o *queryOptions) string {
 	// Get everything once
-	pk := pm.PrimaryKey()
-	sn := pm.SchemaName()
-	tn := pm.TableName()
+	qn := o.qualifiedName(pm)
+
+	// Create arrays to join
+	var sm []string
+	for _, u := range sc {
+		sm = append(sm, fmt.Sprintf("%s = %s", quoteIdent(u), setValue(pm, o.tableName(pm), u)))
+	}
 
 	// Create the query
 	return fmt.Sprintf(
-		`DELETE FROM %s.%s
-		WHERE %s.%s = ?`,
-		sn,
-		tn,
-		tn,
-		pk,
+		`UPDATE %s
+		SET %s
+		WHERE %s
+		%s`,
+		qn,
+		strings.Join(sm, ", "),
+		o.scoped(pm, p),
+		o.returningClause(pm),
 	)
 }
 
+// createDeleteQuery creates a delete query.
+func createDeleteQuery(pm PGModel, o *queryOptions) string {
+	// Create the query
+	q, _ := o.statement(pm, "delete", func() (string, error) {
+		return fmt.Sprintf(
+			`DELETE FROM %s
+			WHERE %s`,
+			o.qualifiedName(pm),
+			o.scoped(pm, keyPredicate(pm, "")),
+		), nil
+	})
+	return q
+}
+
 func convertVariables(pm PGModel) []interface{} {
 	var cv []interface{}
 	for i, u := range pm.NonPKValues() {
@@ -172,11 +437,27 @@ func convertVariables(pm PGModel) []interface{} {
 }
 
 func convertVariable(pm PGModel, v interface{}, c string) interface{} {
+	if tv, ok := bindTime(pm, v); ok {
+		return tv
+	}
+
 	rt := reflect.TypeOf(v)
-	switch rt.Kind() {
-	case reflect.Slice:
-		return pm.ConvertSlice(c)
-	default:
+	if rt == nil {
 		return v
 	}
+
+	switch {
+	case rt.Kind() != reflect.Slice:
+		return v
+	case rt.Elem().Kind() == reflect.Uint8:
+		if sc, ok := pm.(SliceConverter); ok {
+			return sc.ConvertSlice(c)
+		}
+		return v
+	default:
+		if sc, ok := pm.(SliceConverter); ok {
+			return sc.ConvertSlice(c)
+		}
+		return pg.Array(v)
+	}
 }
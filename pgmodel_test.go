@@ -0,0 +1,257 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// testDatabaseVariable is the environment variable holding the URL of the
+// database used by the tests that need one, e.g.
+//
+//	PGMODEL_TEST_DATABASE=postgres://postgres@localhost:5432/pgmodel?sslmode=disable
+//
+// The tests are skipped if it isn't set.
+const testDatabaseVariable = "PGMODEL_TEST_DATABASE"
+
+// testModel is a model of the test.models table.
+type testModel struct {
+	Base[testModel] `pgmodel:"test.models"`
+	ID              int      `pg:"id,pk"`
+	Name            string   `pg:"name"`
+	Tags            []string `pg:"tags,array"`
+}
+
+// testQuery is a query performed with a testExecutor.
+type testQuery struct {
+	ctx    context.Context
+	query  string
+	params []interface{}
+}
+
+// testResult is the result of a query performed with a testExecutor.
+type testResult struct {
+	affected int
+	returned int
+}
+
+// testExecutor records the queries performed with it and answers them with
+// its handler, so that queries can be tested without a database.
+type testExecutor struct {
+	sync.Mutex
+	queries []testQuery
+
+	// Called with the model, query and parameters of every query. If nil,
+	// every query affects and returns one row.
+	handle func(model interface{}, q string, params []interface{}) (orm.Result, error)
+}
+
+// reportRow is a read-only model of the test.reports view that only
+// implements KeyedModel.
+type reportRow struct {
+	Day   string
+	Total int
+}
+
+// bytesModel is a model of the test.files table that converts its slices.
+type bytesModel struct {
+	Base[bytesModel] `pgmodel:"test.files"`
+	ID               int    `pg:"id,pk"`
+	Data             []byte `pg:"data"`
+}
+
+// MARK: Exported functions
+
+func (r testResult) Model() orm.Model  { return nil }
+func (r testResult) RowsAffected() int { return r.affected }
+func (r testResult) RowsReturned() int { return r.returned }
+
+func (e *testExecutor) Exec(query interface{}, params ...interface{}) (orm.Result, error) {
+	return e.perform(context.Background(), nil, query, params)
+}
+
+func (e *testExecutor) ExecContext(c context.Context, query interface{}, params ...interface{}) (orm.Result, error) {
+	return e.perform(c, nil, query, params)
+}
+
+func (e *testExecutor) Query(model interface{}, query interface{}, params ...interface{}) (orm.Result, error) {
+	return e.perform(context.Background(), model, query, params)
+}
+
+func (e *testExecutor) QueryContext(c context.Context, model interface{}, query interface{}, params ...interface{}) (orm.Result, error) {
+	return e.perform(c, model, query, params)
+}
+
+func (e *testExecutor) QueryOne(model interface{}, query interface{}, params ...interface{}) (orm.Result, error) {
+	return e.perform(context.Background(), model, query, params)
+}
+
+func (e *testExecutor) QueryOneContext(c context.Context, model interface{}, query interface{}, params ...interface{}) (orm.Result, error) {
+	return e.perform(c, model, query, params)
+}
+
+func (r *reportRow) SchemaName() string           { return "test" }
+func (r *reportRow) TableName() string            { return "reports" }
+func (r *reportRow) PrimaryKey() string           { return "day" }
+func (r *reportRow) PrimaryKeyValue() interface{} { return r.Day }
+
+func (m *bytesModel) ConvertSlice(c string) string {
+	return fmt.Sprintf(`\x%x`, m.Data)
+}
+
+func TestGetKeyedModel(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Get(&reportRow{}, e, "day", "2024-01-02"); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.HasPrefix(q, `SELECT * FROM "test"."reports" WHERE "day" = ?`) {
+		t.Errorf("got query %q", q)
+	}
+}
+
+func TestConvertVariable(t *testing.T) {
+	// Slices are passed as arrays unless the model converts them
+	if v := convertVariable(&testModel{}, []string{"a"}, "tags"); reflect.TypeOf(v) == reflect.TypeOf([]string{}) {
+		t.Errorf("got %T, want an array", v)
+	}
+	m := &bytesModel{Data: []byte{0xa3, 0xa4}}
+	if v := convertVariable(m, m.Data, "data"); v != `\xa3a4` {
+		t.Errorf("got %v, want the converted slice", v)
+	}
+	if v := convertVariable(&testModel{}, 1, "id"); v != 1 {
+		t.Errorf("got %v, want the value", v)
+	}
+}
+
+func TestContextVariants(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	e := new(testExecutor)
+
+	if _, err := GetContext(ctx, &testModel{}, e, "id", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := GetManyContext[*testModel](ctx, e, "name", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveContext(ctx, &testModel{ID: 1}, e); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DeleteContext(ctx, &testModel{ID: 1}, e); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every query is performed with the context
+	for _, q := range e.queries {
+		if q.ctx.Value(key{}) != "request" {
+			t.Errorf("query %q wasn't performed with the context", squash(q.query))
+		}
+	}
+	if len(e.queries) != 4 {
+		t.Errorf("got %d queries, want 4", len(e.queries))
+	}
+}
+
+// MARK: Non-exported functions
+
+// testDB connects to the test database, closing the connection when the test
+// finishes, and skips the test if there's no test database.
+func testDB(t *testing.T) *pg.DB {
+	t.Helper()
+	u := os.Getenv(testDatabaseVariable)
+	if u == "" {
+		t.Skipf("%s isn't set", testDatabaseVariable)
+	}
+	opt, err := pg.ParseURL(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := pg.Connect(opt)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+// testTx begins a transaction in the test database that's rolled back when the
+// test finishes, skipping the test if there's no test database.
+func testTx(t *testing.T) *pg.Tx {
+	t.Helper()
+	tx, err := testDB(t).Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = tx.Rollback()
+	})
+	return tx
+}
+
+// testExec performs each of the queries, qs, failing the test if any of them
+// fail.
+func testExec(t *testing.T, e Executor, qs ...string) {
+	t.Helper()
+	for _, q := range qs {
+		if _, err := e.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// unreachableDB returns a database whose connections are refused, so that
+// every query fails.
+func unreachableDB(t *testing.T) *pg.DB {
+	db := pg.Connect(&pg.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: time.Second,
+	})
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+// perform records and answers a query performed with the context, c.
+func (e *testExecutor) perform(c context.Context, model interface{}, query interface{}, params []interface{}) (orm.Result, error) {
+	q := fmt.Sprint(query)
+	e.Lock()
+	e.queries = append(e.queries, testQuery{ctx: c, query: q, params: params})
+	h := e.handle
+	e.Unlock()
+
+	if h == nil {
+		return testResult{affected: 1, returned: 1}, nil
+	}
+	return h(model, q, params)
+}
+
+// count returns the number of queries performed.
+func (e *testExecutor) count() int {
+	e.Lock()
+	defer e.Unlock()
+	return len(e.queries)
+}
+
+// last returns the last query performed.
+func (e *testExecutor) last() testQuery {
+	e.Lock()
+	defer e.Unlock()
+	if len(e.queries) == 0 {
+		return testQuery{}
+	}
+	return e.queries[len(e.queries)-1]
+}
+
+// squash returns q with its runs of whitespace replaced by single spaces.
+func squash(q string) string {
+	return strings.Join(strings.Fields(q), " ")
+}
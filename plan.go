@@ -0,0 +1,89 @@
+package pgmodel
+
+import "github.com/go-pg/pg/v10"
+
+// Plan is a destructive operation that has been prepared but not performed, so
+// that admin tooling can show a person the statement that will run and the
+// rows it will affect before they confirm it, e.g.
+//
+//	p, err := pgmodel.PlanDeleteWhere(new(Session), t, c)
+//	...
+//	fmt.Printf("%s\n-- deletes %d rows\n", p.SQL, p.Impact.Rows)
+//	if confirmed {
+//		_, err = p.Execute(t)
+//	}
+type Plan struct {
+
+	// The statement that will be performed, with placeholders in place of its
+	// parameters.
+	SQL string
+
+	// The statement's parameters in the order of their placeholders.
+	Params []interface{}
+
+	// The rows the statement affects at the time it was planned.
+	Impact *Impact
+
+	pm TableDescriber
+	o  *queryOptions
+}
+
+// MARK: Exported functions
+
+// PlanDeleteWhere plans the deletion of the rows of pm's table matching the
+// condition, c, by DeleteWhere, counting the rows it would delete with the
+// given executor.
+func PlanDeleteWhere(pm KeyedModel, t Executor, c *Condition, opts ...QueryOption) (*Plan, error) {
+	o := newQueryOptions(opts)
+	q, a, err := createDeleteWhereQuery(pm, c, o)
+	if err != nil {
+		return nil, err
+	}
+	return newPlan(pm, t, OperationDelete, c, q, a, o, opts)
+}
+
+// PlanUpdateWhere plans the update of the rows of pm's table matching the
+// condition, c, by UpdateWhere, counting the rows it would update with the
+// given executor.
+func PlanUpdateWhere(pm PGModel, t Executor, c *Condition, set map[string]interface{}, opts ...QueryOption) (*Plan, error) {
+	o := newQueryOptions(opts)
+	q, a, err := createUpdateWhereQuery(pm, c, set, o)
+	if err != nil {
+		return nil, err
+	}
+	return newPlan(pm, t, OperationSave, c, q, a, o, opts)
+}
+
+// Execute performs the planned statement in the given transaction.
+//
+// Unless the plan's options expect a number of rows, or its impact is an
+// estimate, the statement is rolled back and a *RowCountError is returned if
+// it affects a different number of rows than it was planned to, so that rows
+// written since the plan was reviewed aren't changed without review.
+func (p *Plan) Execute(t *pg.Tx) (*Result, error) {
+	o := *p.o
+	if o.expectRows == nil && !p.Impact.Estimated {
+		n := p.Impact.Rows
+		o.expectRows = &n
+	}
+	return o.guard(p.Impact.Operation, p.pm, t, p.SQL, p.Params)
+}
+
+// MARK: Non-exported functions
+
+// newPlan returns the plan of the operation, op, performing the query, q, with
+// its parameters, a, and options, o, with its impact estimated with the given
+// executor.
+func newPlan(pm KeyedModel, t Executor, op Operation, c *Condition, q string, a []interface{}, o *queryOptions, opts []QueryOption) (*Plan, error) {
+	i, err := EstimateImpact(pm, t, op, c, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{
+		SQL:    q,
+		Params: a,
+		Impact: i,
+		pm:     pm,
+		o:      o,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package pgmodel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPlanDeleteWhere(t *testing.T) {
+	e := new(testExecutor)
+	p, err := PlanDeleteWhere(&testModel{}, e, Where("name", Eq, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `DELETE FROM "test"."models" WHERE ("name" = ?)`; squash(p.SQL) != want {
+		t.Errorf("got statement %q, want %q", p.SQL, want)
+	}
+	if len(p.Params) != 1 || p.Params[0] != "a" || p.Impact.Operation != OperationDelete {
+		t.Errorf("got plan %+v", p)
+	}
+
+	// Only the impact is queried when planning
+	if n := e.count(); n != 1 {
+		t.Errorf("got %d queries, want 1", n)
+	}
+}
+
+func TestPlanExecute(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx, `INSERT INTO test.models (id, name) VALUES (1, 'a'), (2, 'b')`)
+
+	p, err := PlanUpdateWhere(&testModel{}, tx, Where("name", Eq, "a"), map[string]interface{}{"name": "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Impact.Rows != 1 {
+		t.Fatalf("got impact %+v, want 1 row", p.Impact)
+	}
+
+	// Rows written since the plan change its impact, so it's rolled back
+	testExec(t, tx, `UPDATE test.models SET name = 'a'`)
+	var rce *RowCountError
+	if _, err := p.Execute(tx); !errors.As(err, &rce) || rce.Rows != 2 {
+		t.Fatalf("got error %v, want a *RowCountError for 2 rows", err)
+	}
+
+	testExec(t, tx, `UPDATE test.models SET name = 'b' WHERE id = 2`)
+	if res, err := p.Execute(tx); err != nil || res.RowsAffected() != 1 {
+		t.Errorf("got %v, %v, want 1 row updated", res, err)
+	}
+}
@@ -0,0 +1,182 @@
+package pgmodel
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// invalidStatementName is the SQLSTATE of an error executing a statement that
+// hasn't been prepared.
+const invalidStatementName = "26000"
+
+// prepared holds the names of the prepared statements of each database with
+// prepared statements enabled, keyed by the model type and shape of their
+// queries. Names are never reused, so a statement prepared before prepared
+// statements were closed is never executed afterwards.
+var prepared = struct {
+	sync.RWMutex
+	dbs map[*pg.DB]map[statementKey]string
+	n   int
+}{
+	dbs: make(map[*pg.DB]map[statementKey]string),
+}
+
+// MARK: Exported functions
+
+// EnablePreparedStatements makes Get, GetMany, Save and Delete prepare their
+// queries the first time they're performed on each of db's connections, and
+// execute the prepared statements afterwards, so that the database doesn't
+// parse and plan them again.
+//
+// Only the queries cached for each model type are prepared, so queries with
+// options such as Where or WithOrderBy are performed as usual, as are queries
+// performed in transactions. Statements are prepared on the connection that
+// performs them, so no connection is held between queries.
+func EnablePreparedStatements(db *pg.DB) {
+	prepared.Lock()
+	defer prepared.Unlock()
+	if _, ok := prepared.dbs[db]; !ok {
+		prepared.dbs[db] = make(map[statementKey]string)
+	}
+}
+
+// ClosePreparedStatements stops executing db's prepared statements and stops
+// preparing new ones. It should be called after migrations that change the
+// tables of prepared statements, which the database may no longer be able to
+// perform, before enabling prepared statements again.
+//
+// Statements already prepared stay prepared on db's connections until the
+// connections are closed, but are never executed again.
+func ClosePreparedStatements(db *pg.DB) {
+	prepared.Lock()
+	defer prepared.Unlock()
+	delete(prepared.dbs, db)
+}
+
+// MARK: Non-exported functions
+
+// query performs the query, q, with its parameters, a, with the given
+// executor and scans its rows in to model, using a prepared statement if one
+// is enabled.
+func (o *queryOptions) query(t Executor, model interface{}, q string, a []interface{}) (orm.Result, error) {
+	db, name := o.prepared(t)
+	if db == nil {
+		return t.QueryContext(o.context(), model, q, a...)
+	}
+	return o.execute(db, name, q, len(a), func(c *pg.Conn, e string) (orm.Result, error) {
+		return c.QueryContext(o.context(), model, e, a...)
+	})
+}
+
+// queryOne is identical to query but requires the query to return one row.
+func (o *queryOptions) queryOne(t Executor, model interface{}, q string, a []interface{}) (orm.Result, error) {
+	db, name := o.prepared(t)
+	if db == nil {
+		return t.QueryOneContext(o.context(), model, q, a...)
+	}
+	return o.execute(db, name, q, len(a), func(c *pg.Conn, e string) (orm.Result, error) {
+		return c.QueryOneContext(o.context(), model, e, a...)
+	})
+}
+
+// execute performs the EXECUTE statement of the prepared statement, name,
+// with n parameters using perform on one of db's connections. If the statement
+// hasn't been prepared on the connection, the query, q, is prepared on it and
+// the statement is executed again.
+func (o *queryOptions) execute(db *pg.DB, name, q string, n int, perform func(c *pg.Conn, e string) (orm.Result, error)) (orm.Result, error) {
+	c := db.Conn()
+	defer c.Close()
+
+	e := executeQuery(name, n)
+	r, err := perform(c, e)
+	if !isUnprepared(err) {
+		return r, err
+	}
+	if _, err := c.ExecContext(o.context(), "PREPARE "+name+" AS "+numberParams(q)); err != nil {
+		return nil, err
+	}
+	return perform(c, e)
+}
+
+// prepared returns the database and the name of the prepared statement of the
+// cached query, or nil if prepared statements aren't enabled for the executor
+// or the query wasn't cached.
+func (o *queryOptions) prepared(t Executor) (*pg.DB, string) {
+	db, ok := t.(*pg.DB)
+	if !ok || o.key == nil {
+		return nil, ""
+	}
+
+	prepared.RLock()
+	ns, ok := prepared.dbs[db]
+	name := ns[*o.key]
+	prepared.RUnlock()
+	if !ok {
+		return nil, ""
+	}
+	if name != "" {
+		return db, name
+	}
+
+	// Name the statement, keeping the first of any names given concurrently. If
+	// prepared statements were disabled in the meantime, the query is performed
+	// as usual.
+	prepared.Lock()
+	defer prepared.Unlock()
+	ns, ok = prepared.dbs[db]
+	if !ok {
+		return nil, ""
+	}
+	if name = ns[*o.key]; name == "" {
+		prepared.n++
+		name = "pgmodel_" + strconv.Itoa(prepared.n)
+		ns[*o.key] = name
+	}
+	return db, name
+}
+
+// executeQuery returns the statement executing the prepared statement, name,
+// with n ? placeholders for its parameters.
+func executeQuery(name string, n int) string {
+	if n == 0 {
+		return "EXECUTE " + name
+	}
+	return "EXECUTE " + name + "(" + strings.Repeat("?, ", n-1) + "?)"
+}
+
+// isUnprepared returns whether err is the error of executing a statement that
+// hasn't been prepared.
+func isUnprepared(err error) bool {
+	var pe pg.Error
+	return errors.As(err, &pe) && pe.Field('C') == invalidStatementName
+}
+
+// numberParams returns the query, q, with its ? placeholders replaced by the
+// numbered placeholders of prepared statements, e.g. $1. Question marks in
+// quoted identifiers and strings are left unchanged.
+func numberParams(q string) string {
+	var b strings.Builder
+	var quote rune
+	n := 0
+	for _, r := range q {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,151 @@
+package pgmodel
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestNumberParams(t *testing.T) {
+	q := numberParams(`SELECT "a?" FROM t WHERE b = ? AND c = '?' AND d IN (?)`)
+	if want := `SELECT "a?" FROM t WHERE b = $1 AND c = '?' AND d IN ($2)`; q != want {
+		t.Fatalf("got %q, want %q", q, want)
+	}
+}
+
+func TestExecuteQuery(t *testing.T) {
+	if q := executeQuery("pgmodel_1", 0); q != "EXECUTE pgmodel_1" {
+		t.Errorf("got %q", q)
+	}
+	if q := executeQuery("pgmodel_1", 3); q != "EXECUTE pgmodel_1(?, ?, ?)" {
+		t.Errorf("got %q", q)
+	}
+}
+
+func TestIsUnprepared(t *testing.T) {
+	if !isUnprepared(testPGError{'C': invalidStatementName}) {
+		t.Error("an unprepared statement error wasn't recognized")
+	}
+	if isUnprepared(testPGError{'C': uniqueViolation}) || isUnprepared(nil) {
+		t.Error("another error was recognized")
+	}
+}
+
+func TestPreparedSkipsOtherExecutors(t *testing.T) {
+	o := new(queryOptions)
+	o.key = &statementKey{shape: "get"}
+	if db, name := o.prepared(new(testExecutor)); db != nil || name != "" {
+		t.Fatalf("got database %v and statement %q, want neither", db, name)
+	}
+}
+
+func TestPreparedNames(t *testing.T) {
+	db := unreachableDB(t)
+	o := new(queryOptions)
+	o.key = &statementKey{shape: "get"}
+
+	// Without prepared statements enabled, nothing is named
+	if p, _ := o.prepared(db); p != nil {
+		t.Fatal("a statement was named before prepared statements were enabled")
+	}
+
+	EnablePreparedStatements(db)
+	defer ClosePreparedStatements(db)
+	_, name := o.prepared(db)
+	if _, again := o.prepared(db); name == "" || again != name {
+		t.Fatalf("got statements %q and %q, want the same name", name, again)
+	}
+
+	// After closing, statements are named differently
+	ClosePreparedStatements(db)
+	EnablePreparedStatements(db)
+	if _, next := o.prepared(db); next == name {
+		t.Errorf("the statement %q was named again", name)
+	}
+}
+
+func TestPreparedUnreachable(t *testing.T) {
+	db := unreachableDB(t)
+	EnablePreparedStatements(db)
+	defer ClosePreparedStatements(db)
+
+	if _, err := Get(&testModel{}, db, "id", 1); err == nil {
+		t.Fatal("got no error from an unreachable database")
+	}
+}
+
+func TestPreparedStatements(t *testing.T) {
+	db := testDB(t)
+	testExec(t, db, `CREATE SCHEMA IF NOT EXISTS test`, `DROP TABLE IF EXISTS test.models`)
+	createModelsTable(t, db)
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DROP TABLE IF EXISTS test.models`)
+	})
+
+	EnablePreparedStatements(db)
+	defer ClosePreparedStatements(db)
+
+	// Perform the queries concurrently so that they're prepared on more than
+	// one connection
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if _, err := Save(&testModel{ID: id, Name: "a"}, db); err != nil {
+				errs <- err
+				return
+			}
+			m := &testModel{}
+			if _, err := Get(m, db, "id", id); err != nil {
+				errs <- err
+			} else if m.Name != "a" {
+				errs <- errors.New("got the wrong model")
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// With one connection, each query is prepared on it once
+	opt := *db.Options()
+	opt.PoolSize = 1
+	one := pg.Connect(&opt)
+	defer one.Close()
+	EnablePreparedStatements(one)
+	defer ClosePreparedStatements(one)
+	for i := 0; i < 2; i++ {
+		if _, err := Get(&testModel{}, one, "id", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := preparedCount(t, one); n != 1 {
+		t.Errorf("got %d prepared statements, want 1", n)
+	}
+
+	// After closing, the query is prepared again with another name
+	ClosePreparedStatements(one)
+	EnablePreparedStatements(one)
+	if _, err := Get(&testModel{}, one, "id", 1); err != nil {
+		t.Fatal(err)
+	}
+	if n := preparedCount(t, one); n != 2 {
+		t.Errorf("got %d prepared statements, want 2", n)
+	}
+}
+
+// preparedCount returns the number of statements prepared on db's connection.
+func preparedCount(t *testing.T, db *pg.DB) int {
+	t.Helper()
+	var n int
+	if _, err := db.QueryOne(pg.Scan(&n), `SELECT count(*) FROM pg_prepared_statements WHERE name LIKE 'pgmodel\_%'`); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
@@ -0,0 +1,96 @@
+package pgmodel
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Progress describes the progress of a long-running bulk operation.
+type Progress struct {
+
+	// The number of rows processed so far.
+	Processed int64
+
+	// The estimated total number of rows, or zero if it is unknown.
+	Total int64
+
+	// The time since the operation started.
+	Elapsed time.Duration
+
+	// The estimated time remaining, or zero if it is unknown.
+	ETA time.Duration
+}
+
+// progress tracks the progress of a single operation.
+type progress struct {
+	fn    func(Progress)
+	start time.Time
+	total int64
+	rows  int64
+}
+
+// MARK: Exported functions
+
+// WithProgress sets a function that SaveAll calls with its progress after
+// each chunk it writes.
+func WithProgress(fn func(Progress)) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.progress = fn
+	})
+}
+
+// MARK: Non-exported functions
+
+// newProgress returns a tracker that reports to fn for an operation of an
+// estimated total number of rows starting now. A nil fn disables reporting.
+func newProgress(fn func(Progress), total int64) *progress {
+	return &progress{
+		fn:    fn,
+		start: time.Now(),
+		total: total,
+	}
+}
+
+// add records that n more rows were processed and reports the new progress.
+func (p *progress) add(n int) {
+	p.rows += int64(n)
+	if p.fn == nil {
+		return
+	}
+
+	pr := Progress{
+		Processed: p.rows,
+		Total:     p.total,
+		Elapsed:   time.Since(p.start),
+	}
+	if p.total > p.rows && p.rows > 0 {
+		pr.ETA = time.Duration(float64(pr.Elapsed) / float64(p.rows) * float64(p.total-p.rows))
+	}
+	p.fn(pr)
+}
+
+// estimateRows returns the planner's estimate of the number of rows the query
+// returns.
+func estimateRows(ctx context.Context, db orm.DB, q string, params ...interface{}) (int64, error) {
+	var plan string
+	if _, err := db.QueryOneContext(ctx, pg.Scan(&plan), "EXPLAIN (FORMAT JSON) "+q, params...); err != nil {
+		return 0, err
+	}
+
+	var ps []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &ps); err != nil {
+		return 0, err
+	}
+	if len(ps) == 0 {
+		return 0, nil
+	}
+	return int64(ps[0].Plan.Rows), nil
+}
@@ -0,0 +1,45 @@
+package pgmodel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	var ps []Progress
+	p := newProgress(func(pr Progress) {
+		ps = append(ps, pr)
+	}, 100)
+
+	p.add(25)
+	time.Sleep(10 * time.Millisecond)
+	p.add(25)
+	p.add(50)
+
+	if len(ps) != 3 {
+		t.Fatalf("got %d reports, want 3", len(ps))
+	}
+	if ps[1].Processed != 50 || ps[1].Total != 100 {
+		t.Errorf("got %+v, want 50 of 100 rows processed", ps[1])
+	}
+	if ps[1].ETA <= 0 || ps[1].ETA > 2*ps[1].Elapsed {
+		t.Errorf("got an ETA of %v after %v, want about the time elapsed", ps[1].ETA, ps[1].Elapsed)
+	}
+	if ps[2].ETA != 0 {
+		t.Errorf("got an ETA of %v once complete, want 0", ps[2].ETA)
+	}
+}
+
+func TestProgressUnknownTotal(t *testing.T) {
+	var pr Progress
+	p := newProgress(func(r Progress) {
+		pr = r
+	}, 0)
+	p.add(10)
+	if pr.Processed != 10 || pr.ETA != 0 {
+		t.Errorf("got %+v, want 10 rows processed and no ETA", pr)
+	}
+
+	// A nil function disables reporting
+	newProgress(nil, 10).add(1)
+}
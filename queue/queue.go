@@ -0,0 +1,365 @@
+// Package queue provides a job queue backed by a Postgres table of PGModels.
+//
+// Jobs are claimed with SELECT ... FOR UPDATE SKIP LOCKED, so any number of
+// workers can process the same queue without blocking on each other or
+// claiming the same job twice.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/colinc86/pgmodel"
+	"github.com/go-pg/pg/v10"
+)
+
+// The statuses of a job.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Columns names the columns of a jobs table used to track jobs. The columns
+// are managed by the queue and should have defaults so that jobs can be
+// inserted without them.
+type Columns struct {
+
+	// A text column holding the job's status.
+	Status string
+
+	// An integer column counting the number of times the job has been claimed.
+	Attempts string
+
+	// A timestamptz column holding the earliest time the job may run, or the
+	// end of its lease while it's being run by Work.
+	RunAt string
+
+	// A nullable text column holding the error from the job's last failure.
+	LastError string
+}
+
+// DefaultColumns are the columns used by queues created with New.
+var DefaultColumns = Columns{
+	Status:    "status",
+	Attempts:  "attempts",
+	RunAt:     "run_at",
+	LastError: "last_error",
+}
+
+// Handler types process jobs claimed by Work. Returning an error fails the
+// job.
+type Handler func(ctx context.Context, t *pg.Tx, job pgmodel.PGModel) error
+
+// Queue is a job queue stored in the table of its model.
+type Queue struct {
+
+	// The columns used to track jobs.
+	Columns Columns
+
+	// The maximum number of times a job is attempted before it is marked as
+	// failed.
+	MaxAttempts int
+
+	// The delay before a failed job is retried for the first time. The delay
+	// doubles with each subsequent attempt.
+	Backoff time.Duration
+
+	// The time Work waits before polling again when the queue is empty.
+	PollInterval time.Duration
+
+	// The time a job claimed by Work is leased to its worker. A job that
+	// hasn't been completed or failed by the end of its lease, e.g. because
+	// its worker stopped, can be claimed again.
+	Lease time.Duration
+
+	// Called with the errors Work encounters claiming, completing or failing
+	// jobs, after which it waits for PollInterval and carries on. If nil, Work
+	// returns the error.
+	OnError func(err error)
+
+	// The type of the queue's jobs.
+	jobType reflect.Type
+	model   pgmodel.PGModel
+}
+
+// MARK: Exported functions
+
+// New returns a queue of jobs of model's type, which must be a pointer to a
+// struct, stored in model's table.
+func New(model pgmodel.PGModel) *Queue {
+	return &Queue{
+		Columns:      DefaultColumns,
+		MaxAttempts:  5,
+		Backoff:      10 * time.Second,
+		PollInterval: time.Second,
+		Lease:        5 * time.Minute,
+		jobType:      reflect.TypeOf(model).Elem(),
+		model:        model,
+	}
+}
+
+// Enqueue saves the job and makes it available to be claimed immediately.
+func (q *Queue) Enqueue(t *pg.Tx, job pgmodel.PGModel) error {
+	return q.EnqueueAt(t, job, time.Time{})
+}
+
+// EnqueueAt saves the job and makes it available to be claimed at runAt. A zero
+// time makes it available immediately.
+func (q *Queue) EnqueueAt(t *pg.Tx, job pgmodel.PGModel, runAt time.Time) error {
+	if _, err := pgmodel.Save(job, t); err != nil {
+		return err
+	}
+
+	var ra interface{}
+	if !runAt.IsZero() {
+		ra = runAt.UTC()
+	}
+
+	c := q.columns()
+	_, err := t.Exec(fmt.Sprintf(
+		`UPDATE %s
+		SET %s = ?, %s = 0, %s = COALESCE(?, now()), %s = NULL
+		WHERE %s = ?`,
+		q.table(),
+		c.Status,
+		c.Attempts,
+		c.RunAt,
+		c.LastError,
+		quoteIdent(job.PrimaryKey()),
+	), StatusPending, ra, job.PrimaryKeyValue())
+	return err
+}
+
+// Claim claims the pending job that has been waiting to run the longest and
+// scans it in to dst. It returns false if there are no jobs ready to run.
+//
+// The claimed job's row remains locked until t ends, so it should be
+// completed or failed in the same transaction. If the transaction is rolled
+// back, the job becomes available to claim again.
+func (q *Queue) Claim(t *pg.Tx, dst pgmodel.PGModel) (bool, error) {
+	c := q.columns()
+	pk := quoteIdent(q.model.PrimaryKey())
+	_, err := t.QueryOne(dst, fmt.Sprintf(
+		`UPDATE %s
+		SET %s = ?, %s = %s + 1
+		WHERE %s = (
+			SELECT %s FROM %s
+			WHERE %s = ? AND %s <= now()
+			ORDER BY %s
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		q.table(),
+		c.Status, c.Attempts, c.Attempts,
+		pk,
+		pk, q.table(),
+		c.Status, c.RunAt,
+		c.RunAt,
+	), StatusRunning, StatusPending)
+
+	if errors.Is(err, pg.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Complete marks the job as done.
+func (q *Queue) Complete(t *pg.Tx, job pgmodel.PGModel) error {
+	c := q.columns()
+	_, err := t.Exec(fmt.Sprintf(
+		`UPDATE %s
+		SET %s = ?, %s = NULL
+		WHERE %s = ?`,
+		q.table(),
+		c.Status,
+		c.LastError,
+		quoteIdent(job.PrimaryKey()),
+	), StatusDone, job.PrimaryKeyValue())
+	return err
+}
+
+// Fail records the job's error and schedules it to be retried after the
+// queue's backoff, or marks it as failed if it has been attempted MaxAttempts
+// times.
+func (q *Queue) Fail(t *pg.Tx, job pgmodel.PGModel, jobErr error) error {
+	msg := ""
+	if jobErr != nil {
+		msg = jobErr.Error()
+	}
+
+	c := q.columns()
+	_, err := t.Exec(fmt.Sprintf(
+		`UPDATE %s
+		SET %s = CASE WHEN %s >= ? THEN ? ELSE ? END,
+			%s = now() + ? * power(2, greatest(%s - 1, 0)) * interval '1 microsecond',
+			%s = ?
+		WHERE %s = ?`,
+		q.table(),
+		c.Status, c.Attempts,
+		c.RunAt, c.Attempts,
+		c.LastError,
+		quoteIdent(job.PrimaryKey()),
+	), q.MaxAttempts, StatusFailed, StatusPending, q.Backoff.Microseconds(), msg, job.PrimaryKeyValue())
+	return err
+}
+
+// Work claims and processes jobs with handler until ctx is done, and then
+// returns ctx's error. Each job is handled and completed or failed in its own
+// transaction. Changes made by a failed handler are rolled back.
+//
+// A job's attempt is counted and committed before its handler runs, so a job
+// whose handler never returns, or that brings its worker down, is marked as
+// failed once its lease has expired MaxAttempts times instead of being retried
+// forever.
+func (q *Queue) Work(ctx context.Context, db *pg.DB, handler Handler) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		claimed, err := q.workOne(ctx, db, handler)
+		if err != nil && ctx.Err() == nil {
+			if q.OnError == nil {
+				return err
+			}
+			q.OnError(err)
+		}
+		if err != nil || !claimed {
+			// Wait before polling again
+			select {
+			case <-time.After(q.PollInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// MARK: Non-exported functions
+
+// workOne leases and handles a single job, returning whether one was leased.
+func (q *Queue) workOne(ctx context.Context, db *pg.DB, handler Handler) (bool, error) {
+	job := reflect.New(q.jobType).Interface().(pgmodel.PGModel)
+	leased, err := q.lease(ctx, db, job)
+	if err != nil || !leased {
+		return leased, err
+	}
+
+	err = db.RunInTransaction(ctx, func(t *pg.Tx) error {
+		// Lock the job, unless its lease has already expired
+		c := q.columns()
+		_, err := t.QueryOne(job, fmt.Sprintf(
+			`SELECT * FROM %s
+			WHERE %s = ? AND %s = ? AND %s > now()
+			FOR UPDATE`,
+			q.table(),
+			quoteIdent(job.PrimaryKey()), c.Status, c.RunAt,
+		), job.PrimaryKeyValue(), StatusRunning)
+		if errors.Is(err, pg.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// Handle the job in a savepoint so that its failure can be recorded
+		if _, err := t.Exec("SAVEPOINT pgmodel_job"); err != nil {
+			return err
+		}
+		if herr := handler(ctx, t, job); herr != nil {
+			if _, err := t.Exec("ROLLBACK TO SAVEPOINT pgmodel_job"); err != nil {
+				return err
+			}
+			return q.Fail(t, job, herr)
+		}
+		return q.Complete(t, job)
+	})
+	return true, err
+}
+
+// lease marks the pending job that has been waiting to run the longest, or a
+// running job whose lease has expired, as running until the end of a new lease
+// and counts the attempt. The job is scanned in to dst, and false is returned
+// if there are no jobs ready to run.
+//
+// Each statement is committed on its own. Jobs whose leases have expired
+// MaxAttempts times are marked as failed first.
+func (q *Queue) lease(ctx context.Context, db *pg.DB, dst pgmodel.PGModel) (bool, error) {
+	if _, err := db.ExecContext(ctx, q.createExpireQuery(), StatusFailed, StatusRunning, q.MaxAttempts); err != nil {
+		return false, err
+	}
+
+	_, err := db.QueryOneContext(ctx, dst, q.createLeaseQuery(), StatusRunning, q.Lease.Microseconds(), StatusPending, StatusRunning)
+	if errors.Is(err, pg.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// createExpireQuery creates the query marking running jobs whose leases have
+// expired as failed if they've been attempted MaxAttempts times.
+func (q *Queue) createExpireQuery() string {
+	c := q.columns()
+	return fmt.Sprintf(
+		`UPDATE %s
+		SET %s = ?, %s = 'queue: the lease of the job''s last attempt expired'
+		WHERE %s = ? AND %s <= now() AND %s >= ?`,
+		q.table(),
+		c.Status, c.LastError,
+		c.Status, c.RunAt, c.Attempts,
+	)
+}
+
+// createLeaseQuery creates the query leasing the next job ready to run.
+func (q *Queue) createLeaseQuery() string {
+	c := q.columns()
+	pk := quoteIdent(q.model.PrimaryKey())
+	return fmt.Sprintf(
+		`UPDATE %s
+		SET %s = ?, %s = %s + 1, %s = now() + ? * interval '1 microsecond'
+		WHERE %s = (
+			SELECT %s FROM %s
+			WHERE %s IN (?, ?) AND %s <= now()
+			ORDER BY %s
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		q.table(),
+		c.Status, c.Attempts, c.Attempts, c.RunAt,
+		pk,
+		pk, q.table(),
+		c.Status, c.RunAt,
+		c.RunAt,
+	)
+}
+
+// table returns the quoted, qualified name of the queue's table.
+func (q *Queue) table() string {
+	if sn := q.model.SchemaName(); sn != "" {
+		return quoteIdent(sn) + "." + quoteIdent(q.model.TableName())
+	}
+	return quoteIdent(q.model.TableName())
+}
+
+// columns returns the queue's columns quoted for use in queries.
+func (q *Queue) columns() Columns {
+	return Columns{
+		Status:    quoteIdent(q.Columns.Status),
+		Attempts:  quoteIdent(q.Columns.Attempts),
+		RunAt:     quoteIdent(q.Columns.RunAt),
+		LastError: quoteIdent(q.Columns.LastError),
+	}
+}
+
+// quoteIdent returns the identifier, s, double-quoted with its embedded double
+// quotes escaped.
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,262 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colinc86/pgmodel"
+	"github.com/go-pg/pg/v10"
+)
+
+// testJob is a model of the test.jobs table.
+type testJob struct {
+	pgmodel.Base[testJob] `pgmodel:"test.jobs"`
+	ID                    int `pg:"id,pk"`
+}
+
+// testDB connects to the database named by PGMODEL_TEST_DATABASE and creates
+// the test.jobs table in it, dropping the table and closing the connection
+// when the test finishes. The test is skipped if there's no test database.
+func testDB(t *testing.T) *pg.DB {
+	t.Helper()
+	u := os.Getenv("PGMODEL_TEST_DATABASE")
+	if u == "" {
+		t.Skip("PGMODEL_TEST_DATABASE isn't set")
+	}
+	opt, err := pg.ParseURL(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := pg.Connect(opt)
+	for _, q := range []string{
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`DROP TABLE IF EXISTS test.jobs`,
+		`CREATE TABLE test.jobs (
+			id serial PRIMARY KEY,
+			status text NOT NULL DEFAULT 'pending',
+			attempts int NOT NULL DEFAULT 0,
+			run_at timestamptz NOT NULL DEFAULT now(),
+			last_error text
+		)`,
+	} {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DROP TABLE test.jobs`)
+		_ = db.Close()
+	})
+	return db
+}
+
+// jobStatus returns the status, attempts and last error of the job with the
+// ID, id.
+func jobStatus(t *testing.T, db *pg.DB, id int) (status string, attempts int, lastError string) {
+	t.Helper()
+	var le *string
+	if _, err := db.QueryOne(pg.Scan(&status, &attempts, &le), `SELECT status, attempts, last_error FROM test.jobs WHERE id = ?`, id); err != nil {
+		t.Fatal(err)
+	}
+	if le != nil {
+		lastError = *le
+	}
+	return
+}
+
+// unreachableDB returns a database whose connections are refused, so that
+// every query fails.
+func unreachableDB(t *testing.T) *pg.DB {
+	db := pg.Connect(&pg.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: time.Second,
+	})
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+func TestCreateLeaseQuery(t *testing.T) {
+	q := strings.Join(strings.Fields(New(&testJob{}).createLeaseQuery()), " ")
+	for _, want := range []string{
+		`UPDATE "test"."jobs" SET "status" = ?, "attempts" = "attempts" + 1, "run_at" = now() + ? * interval '1 microsecond'`,
+		`WHERE "status" IN (?, ?) AND "run_at" <= now()`,
+		`FOR UPDATE SKIP LOCKED`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("%q doesn't contain %q", q, want)
+		}
+	}
+}
+
+func TestCreateExpireQuery(t *testing.T) {
+	q := strings.Join(strings.Fields(New(&testJob{}).createExpireQuery()), " ")
+	want := `WHERE "status" = ? AND "run_at" <= now() AND "attempts" >= ?`
+	if !strings.Contains(q, want) {
+		t.Errorf("%q doesn't contain %q", q, want)
+	}
+}
+
+func TestTable(t *testing.T) {
+	if tn := New(&testJob{}).table(); tn != `"test"."jobs"` {
+		t.Errorf("got table %q", tn)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	q := New(&testJob{})
+	q.Columns.Status = `st"atus`
+	if c := q.columns(); c.Status != `"st""atus"` || c.RunAt != `"run_at"` {
+		t.Errorf("got columns %+v", c)
+	}
+}
+
+func TestClaimCompleteFail(t *testing.T) {
+	db := testDB(t)
+	q := New(&testJob{})
+	q.MaxAttempts = 2
+	q.Backoff = time.Hour
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	// A job scheduled in the future can't be claimed
+	later := &testJob{ID: 1}
+	if err := q.EnqueueAt(tx, later, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	job := &testJob{ID: 2}
+	if err := q.Enqueue(tx, job); err != nil {
+		t.Fatal(err)
+	}
+
+	var claimed testJob
+	if ok, err := q.Claim(tx, &claimed); err != nil || !ok || claimed.ID != 2 {
+		t.Fatalf("got %v, %v, %d, want job 2 to be claimed", ok, err, claimed.ID)
+	}
+	if ok, err := q.Claim(tx, &claimed); err != nil || ok {
+		t.Fatalf("got %v, %v, want no job to be ready", ok, err)
+	}
+
+	// Failing a job retries it until it's been attempted MaxAttempts times
+	if err := q.Fail(tx, job, errors.New("failed once")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if s, a, le := jobStatus(t, db, 2); s != StatusPending || a != 1 || le != "failed once" {
+		t.Errorf("got %s, %d, %q after the first failure", s, a, le)
+	}
+
+	if _, err := db.Exec(`UPDATE test.jobs SET status = ?, attempts = 2`, StatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		return q.Fail(tx, job, errors.New("failed twice"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if s, _, _ := jobStatus(t, db, 2); s != StatusFailed {
+		t.Errorf("got %s after the last failure, want %s", s, StatusFailed)
+	}
+
+	// Completing a job clears its error
+	if err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		return q.Complete(tx, job)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if s, _, le := jobStatus(t, db, 2); s != StatusDone || le != "" {
+		t.Errorf("got %s, %q after completing the job", s, le)
+	}
+}
+
+func TestWork(t *testing.T) {
+	db := testDB(t)
+	q := New(&testJob{})
+	q.PollInterval = 10 * time.Millisecond
+	q.Backoff = time.Hour
+
+	if err := db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		for id := 1; id <= 2; id++ {
+			if err := q.Enqueue(tx, &testJob{ID: id}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Job 1 succeeds and job 2 fails, rolling back its changes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handled := 0
+	err := q.Work(ctx, db, func(ctx context.Context, tx *pg.Tx, job pgmodel.PGModel) error {
+		if handled++; handled == 2 {
+			cancel()
+		}
+		if job.(*testJob).ID == 2 {
+			if _, err := tx.Exec(`UPDATE test.jobs SET attempts = 100 WHERE id = 2`); err != nil {
+				return err
+			}
+			return errors.New("job failed")
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+
+	if s, a, _ := jobStatus(t, db, 1); s != StatusDone || a != 1 {
+		t.Errorf("got job 1 %s after %d attempts", s, a)
+	}
+	if s, a, le := jobStatus(t, db, 2); s != StatusPending || a != 1 || le != "job failed" {
+		t.Errorf("got job 2 %s after %d attempts with error %q", s, a, le)
+	}
+}
+
+func TestWorkReturnsErrors(t *testing.T) {
+	q := New(&testJob{})
+	err := q.Work(context.Background(), unreachableDB(t), func(ctx context.Context, t *pg.Tx, job pgmodel.PGModel) error {
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestWorkReportsErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var errs []error
+	q := New(&testJob{})
+	q.PollInterval = time.Millisecond
+	q.OnError = func(err error) {
+		errs = append(errs, err)
+		if len(errs) == 2 {
+			cancel()
+		}
+	}
+
+	err := q.Work(ctx, unreachableDB(t), func(ctx context.Context, t *pg.Tx, job pgmodel.PGModel) error {
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	if len(errs) != 2 {
+		t.Errorf("got %d errors, want 2", len(errs))
+	}
+}
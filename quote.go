@@ -0,0 +1,119 @@
+package pgmodel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// identPattern matches unquoted identifiers, optionally qualified, such as
+// name and bars.name.
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)*$`)
+
+// identPartPattern matches the unquoted or double-quoted identifier at the
+// start of a string, such as name or "Name".
+var identPartPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_$]*|"([^"]|"")+")`)
+
+// MARK: Non-exported functions
+
+// quoteIdent returns the identifier, s, double-quoted so that it can be used in
+// queries even if it's a reserved word or contains capitals or special
+// characters. Embedded double quotes are escaped by doubling them.
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// quoteLiteral returns s single-quoted as a string literal. Embedded single
+// quotes are escaped by doubling them.
+func quoteLiteral(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `''`) + `'`
+}
+
+// quoteIdents returns each of the identifiers, ss, quoted.
+func quoteIdents(ss []string) []string {
+	qs := make([]string, len(ss))
+	for i, s := range ss {
+		qs[i] = quoteIdent(s)
+	}
+	return qs
+}
+
+// quoteList returns the identifiers, ss, quoted and joined in to a comma
+// separated list.
+func quoteList(ss []string) string {
+	return strings.Join(quoteIdents(ss), ", ")
+}
+
+// qualify returns the quoted table name, tn, qualified with the quoted schema
+// name, sn, or the quoted table name alone if sn is empty.
+func qualify(sn string, tn string) string {
+	if sn == "" {
+		return quoteIdent(tn)
+	}
+	return quoteIdent(sn) + "." + quoteIdent(tn)
+}
+
+// quoteExpr returns s quoted if it's an unquoted, and optionally qualified,
+// identifier, or as given if it's an expression.
+func quoteExpr(s string) string {
+	if identPattern.MatchString(s) {
+		return strings.Join(quoteIdents(strings.Split(s, ".")), ".")
+	}
+	return s
+}
+
+// identExpr returns the identifier, s, with each of its parts quoted, or false
+// if s isn't an identifier. Identifiers may be qualified, and their parts may
+// already be quoted, such as name, bars.name and "Bars"."Name".
+func identExpr(s string) (string, bool) {
+	var qs []string
+	for {
+		p := identPartPattern.FindString(s)
+		if p == "" {
+			return "", false
+		}
+		if strings.HasPrefix(p, `"`) {
+			qs = append(qs, p)
+		} else {
+			qs = append(qs, quoteIdent(p))
+		}
+
+		s = s[len(p):]
+		switch {
+		case s == "":
+			return strings.Join(qs, "."), true
+		case s[0] != '.':
+			return "", false
+		}
+		s = s[1:]
+	}
+}
+
+// columnOrExpr returns the column, s, quoted if it's a column name, or as
+// given if it's an expression.
+func columnOrExpr(s string) string {
+	if e, ok := identExpr(s); ok {
+		return e
+	}
+	return s
+}
+
+// queryKeyExpr returns the query key, k, quoted, or an error if it isn't a
+// column name, so that keys can't be used to inject SQL.
+func queryKeyExpr(k string) (string, error) {
+	e, ok := identExpr(k)
+	if !ok {
+		return "", fmt.Errorf("pgmodel: query key %q isn't a column name", k)
+	}
+	return e, nil
+}
+
+// columnExpr returns the column, c, of a condition or search quoted, or an
+// error if it isn't a column name.
+func columnExpr(c string) (string, error) {
+	e, ok := identExpr(c)
+	if !ok {
+		return "", fmt.Errorf("pgmodel: %q isn't a column name", c)
+	}
+	return e, nil
+}
@@ -0,0 +1,51 @@
+package pgmodel
+
+import "testing"
+
+func TestQueryKeyExpr(t *testing.T) {
+	for k, want := range map[string]string{
+		"name":          `"name"`,
+		"UserID":        `"UserID"`,
+		"bars.name":     `"bars"."name"`,
+		`"Bars"."Name"`: `"Bars"."Name"`,
+		`"a""b"`:        `"a""b"`,
+		`s."t".c`:       `"s"."t"."c"`,
+	} {
+		got, err := queryKeyExpr(k)
+		if err != nil {
+			t.Errorf("%q: %v", k, err)
+		} else if got != want {
+			t.Errorf("%q: got %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestQueryKeyExprRejectsExpressions(t *testing.T) {
+	for _, k := range []string{
+		"true OR id",
+		"(SELECT secret FROM t)",
+		"lower(email)",
+		"id; DROP TABLE t",
+		`"unterminated`,
+		"a.",
+		".a",
+		"a..b",
+		"id = 1 --",
+	} {
+		if got, err := queryKeyExpr(k); err == nil {
+			t.Errorf("%q: got %q, want an error", k, got)
+		}
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	if got := quoteIdent(`we"ird`); got != `"we""ird"` {
+		t.Errorf("got %q", got)
+	}
+	if got := qualify("", "t"); got != `"t"` {
+		t.Errorf("got %q", got)
+	}
+	if got := qualify("s", "t"); got != `"s"."t"` {
+		t.Errorf("got %q", got)
+	}
+}
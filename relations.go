@@ -0,0 +1,147 @@
+package pgmodel
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// Relation declares that a column of a child model's table references a
+// column of a parent model's table.
+type Relation struct {
+
+	// The referencing model and column.
+	Child  PGModel
+	Column string
+
+	// The referenced model and column. The column defaults to the parent's
+	// primary key.
+	Parent       PGModel
+	ParentColumn string
+}
+
+// RelatedModel types declare the relations of their table to parent tables.
+type RelatedModel interface {
+	Relations() []Relation
+}
+
+// RepairAction controls what CheckReferences does with orphaned rows.
+type RepairAction int
+
+const (
+	// ReportOrphans only reports orphaned rows.
+	ReportOrphans RepairAction = iota
+
+	// DeleteOrphans deletes orphaned rows.
+	DeleteOrphans
+
+	// NullifyOrphans sets the referencing column of orphaned rows to NULL.
+	NullifyOrphans
+)
+
+// Orphans describes the rows of a relation's child table whose references
+// don't exist in its parent table.
+type Orphans struct {
+
+	// The relation that was checked.
+	Relation Relation
+
+	// The primary key values of the orphaned rows, as text.
+	Keys []string
+}
+
+// MARK: Exported functions
+
+// CheckReferences scans the child table of each relation for rows whose
+// non-null referencing column has no matching row in the parent table, and
+// applies the repair action to them. Relations with no orphaned rows are
+// omitted from the result.
+//
+// This is intended for auditing data before adding foreign key constraints or
+// running migrations.
+func CheckReferences(t *pg.Tx, action RepairAction, relations ...Relation) ([]Orphans, error) {
+	var orphans []Orphans
+	for _, r := range relations {
+		p := orphanPredicate(r)
+
+		var keys []string
+		_, err := t.Query(&keys, fmt.Sprintf(
+			`SELECT c.%s::text FROM %s c
+			WHERE %s
+			ORDER BY 1`,
+			quoteIdent(r.Child.PrimaryKey()),
+			qualify(r.Child.SchemaName(), r.Child.TableName()),
+			p,
+		))
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		orphans = append(orphans, Orphans{Relation: r, Keys: keys})
+
+		// Repair the orphans
+		var q string
+		switch action {
+		case DeleteOrphans:
+			q = fmt.Sprintf(
+				`DELETE FROM %s c
+				WHERE %s`,
+				qualify(r.Child.SchemaName(), r.Child.TableName()),
+				p,
+			)
+		case NullifyOrphans:
+			q = fmt.Sprintf(
+				`UPDATE %s c
+				SET %s = NULL
+				WHERE %s`,
+				qualify(r.Child.SchemaName(), r.Child.TableName()),
+				quoteIdent(r.Column),
+				p,
+			)
+		}
+		if q != "" {
+			if _, err := t.Exec(q); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return orphans, nil
+}
+
+// CheckModelReferences calls CheckReferences with the relations declared by
+// each of the models.
+func CheckModelReferences(t *pg.Tx, action RepairAction, models ...RelatedModel) ([]Orphans, error) {
+	var rs []Relation
+	for _, m := range models {
+		rs = append(rs, m.Relations()...)
+	}
+	return CheckReferences(t, action, rs...)
+}
+
+// MARK: Non-exported functions
+
+// parentColumn returns the referenced column of the relation.
+func (r Relation) parentColumn() string {
+	if r.ParentColumn != "" {
+		return r.ParentColumn
+	}
+	return r.Parent.PrimaryKey()
+}
+
+// orphanPredicate returns a predicate matching the relation's orphaned child
+// rows, aliased c.
+func orphanPredicate(r Relation) string {
+	return fmt.Sprintf(
+		`c.%s IS NOT NULL AND NOT EXISTS (
+			SELECT 1 FROM %s p
+			WHERE p.%s = c.%s
+		)`,
+		quoteIdent(r.Column),
+		qualify(r.Parent.SchemaName(), r.Parent.TableName()),
+		quoteIdent(r.parentColumn()),
+		quoteIdent(r.Column),
+	)
+}
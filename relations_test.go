@@ -0,0 +1,74 @@
+package pgmodel
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// childModel is a model of the test.children table, whose parent_id column
+// references test.models.
+type childModel struct {
+	Base[childModel] `pgmodel:"test.children"`
+	ID               int  `pg:"id,pk"`
+	ParentID         *int `pg:"parent_id"`
+}
+
+func (m *childModel) Relations() []Relation {
+	return []Relation{{Child: m, Column: "parent_id", Parent: &testModel{}}}
+}
+
+func TestOrphanPredicate(t *testing.T) {
+	r := Relation{Child: &childModel{}, Column: "parent_id", Parent: &testModel{}, ParentColumn: "name"}
+	want := `c."parent_id" IS NOT NULL AND NOT EXISTS ( SELECT 1 FROM "test"."models" p WHERE p."name" = c."parent_id" )`
+	if p := squash(orphanPredicate(r)); p != want {
+		t.Errorf("got predicate %q, want %q", p, want)
+	}
+
+	// The parent column defaults to the parent's primary key
+	r.ParentColumn = ""
+	if c := r.parentColumn(); c != "id" {
+		t.Errorf("got parent column %q, want \"id\"", c)
+	}
+}
+
+func TestCheckModelReferences(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx,
+		`CREATE TABLE test.children (id int PRIMARY KEY, parent_id int)`,
+		`INSERT INTO test.models (id, name) VALUES (1, 'one')`,
+		`INSERT INTO test.children VALUES (1, 1), (2, 2), (3, NULL), (4, 3)`,
+	)
+
+	// Reporting leaves the orphans in place
+	os, err := CheckModelReferences(tx, ReportOrphans, &childModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(os) != 1 || !reflect.DeepEqual(os[0].Keys, []string{"2", "4"}) {
+		t.Fatalf("got orphans %+v, want keys [2 4]", os)
+	}
+
+	// Nullifying clears the references of the orphans
+	if _, err := CheckModelReferences(tx, NullifyOrphans, &childModel{}); err != nil {
+		t.Fatal(err)
+	}
+	var nulls int
+	if _, err := tx.QueryOne(pg.Scan(&nulls), `SELECT count(*) FROM test.children WHERE parent_id IS NULL`); err != nil {
+		t.Fatal(err)
+	}
+	if nulls != 3 {
+		t.Errorf("got %d null references, want 3", nulls)
+	}
+
+	// No orphans remain
+	os, err = CheckModelReferences(tx, DeleteOrphans, &childModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(os) != 0 {
+		t.Errorf("got orphans %+v, want none", os)
+	}
+}
@@ -0,0 +1,50 @@
+package pgmodel
+
+import "github.com/go-pg/pg/v10/orm"
+
+// Result describes the outcome of the statements performed by an operation.
+type Result struct {
+	affected int
+	returned int
+	sql      string
+}
+
+// RowsAffected returns the number of rows affected by the operation.
+func (r *Result) RowsAffected() int {
+	return r.affected
+}
+
+// RowsReturned returns the number of rows returned by the operation.
+func (r *Result) RowsReturned() int {
+	return r.returned
+}
+
+// SQL returns the statement performed by the operation with placeholders in
+// place of its parameters, so that it's safe to log. For operations that
+// perform several statements, such as SaveAll, it is the last statement
+// performed.
+func (r *Result) SQL() string {
+	return r.sql
+}
+
+// MARK: Non-exported functions
+
+// newResult returns the result, res, of the statement, q, or nil if res is
+// nil.
+func newResult(res orm.Result, q string) *Result {
+	if res == nil {
+		return nil
+	}
+	return &Result{
+		affected: res.RowsAffected(),
+		returned: res.RowsReturned(),
+		sql:      q,
+	}
+}
+
+// add adds the counts of the result, s, to r and takes its statement.
+func (r *Result) add(s *Result) {
+	r.affected += s.affected
+	r.returned += s.returned
+	r.sql = s.sql
+}
@@ -0,0 +1,39 @@
+package pgmodel
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestResult(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return testResult{affected: 1, returned: 1}, nil
+	}}
+	res, err := Save(&testModel{ID: 1, Name: "secret"}, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsAffected() != 1 || res.RowsReturned() != 1 {
+		t.Errorf("got %d rows affected and %d returned, want 1 and 1", res.RowsAffected(), res.RowsReturned())
+	}
+
+	// The statement has placeholders rather than its parameters
+	if res.SQL() != e.last().query {
+		t.Errorf("got statement %q, want %q", res.SQL(), e.last().query)
+	}
+}
+
+func TestResultAdd(t *testing.T) {
+	r := new(Result)
+	r.add(&Result{affected: 2, returned: 1, sql: "first"})
+	r.add(&Result{affected: 3, returned: 0, sql: "second"})
+	if r.RowsAffected() != 5 || r.RowsReturned() != 1 || r.SQL() != "second" {
+		t.Errorf("got result %+v", r)
+	}
+
+	// A missing result has no counts
+	if newResult(nil, "q") != nil {
+		t.Error("got a result, want nil")
+	}
+}
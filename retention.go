@@ -0,0 +1,242 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// DefaultPurgeBatchSize is the number of rows Purge deletes per statement when
+// no batch size is given.
+const DefaultPurgeBatchSize = 1000
+
+// RetainedModel types declare how long their rows are kept.
+//
+// Tables that are range partitioned by their retention column have the
+// partitions whose rows have all expired detached by Purge, rather than their
+// rows deleted.
+type RetainedModel interface {
+	PGModel
+
+	// The time column that a row's age is measured from.
+	RetentionColumn() string
+
+	// The maximum age of a row before it is purged.
+	RetentionPeriod() time.Duration
+}
+
+// PurgeOptions configures Purge and RunRetention.
+type PurgeOptions struct {
+
+	// The number of rows to delete per statement. Defaults to
+	// DefaultPurgeBatchSize.
+	BatchSize int
+
+	// The time to wait between statements, throttling the load placed on the
+	// database.
+	Pause time.Duration
+
+	// Limits the rate at which batches are deleted, in addition to Pause.
+	Throttle Throttle
+
+	// If set, called after each batch with the stats so far.
+	OnBatch func(PurgeStats)
+
+	// If set, called after each batch with the progress of the purge. The total
+	// is the planner's estimate of the number of expired rows when the purge
+	// started.
+	OnProgress func(Progress)
+
+	// If set, called by RunRetention with errors from Purge. Errors stop Purge
+	// but RunRetention continues with the next model.
+	OnError func(pm RetainedModel, err error)
+
+	// Whether partitions detached by Purge are dropped. Otherwise they're left
+	// as standalone tables, e.g. to be archived.
+	DropPartitions bool
+}
+
+// PurgeStats describes the progress of a purge.
+type PurgeStats struct {
+
+	// The schema and table names of the purged model.
+	Schema string
+	Table  string
+
+	// The number of rows deleted.
+	Rows int64
+
+	// The number of expired partitions detached.
+	Partitions int
+
+	// The number of statements performed.
+	Batches int
+
+	// The time since the purge started.
+	Elapsed time.Duration
+}
+
+// MARK: Exported functions
+
+// Purge deletes the rows of pm's table that are older than its retention
+// period. Rows are deleted in batches, each in its own statement, until no
+// expired rows remain or ctx is done.
+//
+// If the table is range partitioned by its retention column, the partitions
+// whose upper bounds are before the cutoff are detached first, and dropped if
+// opts.DropPartitions is set.
+//
+// Each batch and partition is reported to the observer as OperationPurge.
+func Purge(ctx context.Context, db *pg.DB, pm RetainedModel, opts PurgeOptions) (PurgeStats, error) {
+	start := time.Now()
+	stats := PurgeStats{
+		Schema: pm.SchemaName(),
+		Table:  pm.TableName(),
+	}
+
+	size := opts.BatchSize
+	if size <= 0 {
+		size = DefaultPurgeBatchSize
+	}
+
+	// Fix the cutoff so that the purge terminates
+	cutoff := start.Add(-pm.RetentionPeriod()).UTC()
+	q := createPurgeQuery(pm, size, "?")
+	th := newThrottler(opts.Throttle)
+
+	// Detach the partitions whose rows have all expired
+	var ps []string
+	if _, err := db.QueryContext(ctx, &ps, expiredPartitionsQuery, qualify(pm.SchemaName(), pm.TableName()), pm.RetentionColumn(), cutoff); err != nil {
+		return stats, err
+	}
+	for _, p := range ps {
+		_, err := runContext(ctx, OperationPurge, pm, db, func() (orm.Result, error) {
+			return db.ExecContext(ctx, createDetachPartitionQuery(pm, p, opts.DropPartitions))
+		})
+		if err != nil {
+			return stats, err
+		}
+		stats.Partitions++
+		stats.Elapsed = time.Since(start)
+	}
+
+	var total int64
+	if opts.OnProgress != nil {
+		var err error
+		total, err = estimateRows(ctx, db, fmt.Sprintf(
+			`SELECT 1 FROM %s WHERE %s < ?`,
+			qualify(pm.SchemaName(), pm.TableName()),
+			quoteIdent(pm.RetentionColumn()),
+		), cutoff)
+		if err != nil {
+			return stats, err
+		}
+	}
+	pr := newProgress(opts.OnProgress, total)
+
+	for {
+		if err := th.wait(ctx); err != nil {
+			return stats, err
+		}
+
+		res, err := runContext(ctx, OperationPurge, pm, db, func() (orm.Result, error) {
+			return db.ExecContext(ctx, q, cutoff)
+		})
+		if err != nil {
+			return stats, err
+		}
+
+		th.done(res.RowsAffected())
+		pr.add(res.RowsAffected())
+		stats.Rows += int64(res.RowsAffected())
+		stats.Batches++
+		stats.Elapsed = time.Since(start)
+		if opts.OnBatch != nil {
+			opts.OnBatch(stats)
+		}
+
+		if res.RowsAffected() < size {
+			return stats, nil
+		}
+
+		if opts.Pause > 0 {
+			select {
+			case <-time.After(opts.Pause):
+			case <-ctx.Done():
+				return stats, ctx.Err()
+			}
+		}
+	}
+}
+
+// RunRetention purges each of the models every interval until ctx is done,
+// and then returns ctx's error.
+func RunRetention(ctx context.Context, db *pg.DB, interval time.Duration, opts PurgeOptions, models ...RetainedModel) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, pm := range models {
+			if _, err := Purge(ctx, db, pm, opts); err != nil && opts.OnError != nil && ctx.Err() == nil {
+				opts.OnError(pm, err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// MARK: Non-exported functions
+
+// expiredPartitionsQuery selects the quoted, qualified names of the partitions
+// of a table whose upper bounds are before a cutoff, given the table's name,
+// its retention column and the cutoff. Only tables range partitioned by the
+// retention column alone have their partitions selected.
+const expiredPartitionsQuery = `SELECT format('%I.%I', n.nspname, c.relname)
+	FROM pg_catalog.pg_inherits i
+	JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid
+	JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	WHERE i.inhparent = ?::regclass
+	AND pg_catalog.pg_get_partkeydef(i.inhparent) = 'RANGE (' || quote_ident(?) || ')'
+	AND (regexp_match(pg_catalog.pg_get_expr(c.relpartbound, c.oid), 'TO \(''([^'']*)''\)$'))[1]::timestamptz <= ?
+	ORDER BY 1`
+
+// createDetachPartitionQuery creates a query detaching the partition, p, from
+// pm's table, and dropping it if drop is set.
+func createDetachPartitionQuery(pm RetainedModel, p string, drop bool) string {
+	q := fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", qualify(pm.SchemaName(), pm.TableName()), p)
+	if drop {
+		q += "; DROP TABLE " + p
+	}
+	return q
+}
+
+// createPurgeQuery creates a query deleting up to n rows whose retention column
+// is before the cutoff expression, c.
+func createPurgeQuery(pm RetainedModel, n int, c string) string {
+	// Get everything once
+	qn := qualify(pm.SchemaName(), pm.TableName())
+	rc := quoteIdent(pm.RetentionColumn())
+
+	// Create the query
+	return fmt.Sprintf(
+		`DELETE FROM %s
+		WHERE ctid = ANY(ARRAY(
+			SELECT ctid FROM %s
+			WHERE %s < %s
+			LIMIT %d
+		))`,
+		qn,
+		qn,
+		rc,
+		c,
+		n,
+	)
+}
@@ -0,0 +1,60 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// eventModel is a model of the test.events table, which is kept for a day and
+// partitioned by hour.
+type eventModel struct {
+	Base[eventModel] `pgmodel:"test.events"`
+	ID               int       `pg:"id,pk"`
+	CreatedAt        time.Time `pg:"created_at"`
+}
+
+func (m *eventModel) RetentionColumn() string {
+	return "created_at"
+}
+
+func (m *eventModel) RetentionPeriod() time.Duration {
+	return 24 * time.Hour
+}
+
+func (m *eventModel) PartitionInterval() time.Duration {
+	return time.Hour
+}
+
+func TestCreateDetachPartitionQuery(t *testing.T) {
+	p := `"test"."events_20240101000000"`
+	if q := createDetachPartitionQuery(&eventModel{}, p, false); q != `ALTER TABLE "test"."events" DETACH PARTITION `+p {
+		t.Errorf("got %q", q)
+	}
+	if q := createDetachPartitionQuery(&eventModel{}, p, true); !strings.HasSuffix(q, `; DROP TABLE `+p) {
+		t.Errorf("got %q", q)
+	}
+}
+
+func TestExpiredPartitions(t *testing.T) {
+	tx := testTx(t)
+	for _, q := range []string{
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.events (id int, created_at timestamptz NOT NULL) PARTITION BY RANGE (created_at)`,
+		`CREATE TABLE test.events_old PARTITION OF test.events FOR VALUES FROM ('2000-01-01') TO ('2000-01-02')`,
+		`CREATE TABLE test.events_new PARTITION OF test.events FOR VALUES FROM ('2100-01-01') TO ('2100-01-02')`,
+		`CREATE TABLE test.events_default PARTITION OF test.events DEFAULT`,
+	} {
+		if _, err := tx.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ps []string
+	if _, err := tx.Query(&ps, expiredPartitionsQuery, `"test"."events"`, "created_at", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 1 || ps[0] != "test.events_old" {
+		t.Errorf("got partitions %v, want [test.events_old]", ps)
+	}
+}
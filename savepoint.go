@@ -0,0 +1,53 @@
+package pgmodel
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// savepoints counts the savepoints created so that their names are unique.
+var savepoints uint64
+
+// MARK: Exported functions
+
+// WithSavepoint calls fn inside a savepoint of the transaction, t, so that the
+// Save, Delete and other calls it makes can be rolled back independently of
+// the rest of the transaction, e.g. for best-effort audit writes:
+//
+//	if err := pgmodel.WithSavepoint(t, func() error {
+//		_, err := pgmodel.Save(entry, t)
+//		return err
+//	}); err != nil {
+//		log.Printf("audit entry not saved: %v", err)
+//	}
+//
+// If fn returns an error, its changes are rolled back and the error is
+// returned, and the transaction can continue to be used and committed. Calls
+// may be nested.
+func WithSavepoint(t *pg.Tx, fn func() error) error {
+	return savepoint(t, fn)
+}
+
+// MARK: Non-exported functions
+
+// savepoint calls fn inside a savepoint of the transaction, t. If fn returns
+// an error, the transaction is rolled back to the savepoint and the error is
+// returned so that the transaction can continue to be used.
+func savepoint(t *pg.Tx, fn func() error) error {
+	name := fmt.Sprintf("pgmodel_%d", atomic.AddUint64(&savepoints, 1))
+	if _, err := t.Exec("SAVEPOINT " + name); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		if _, rerr := t.Exec("ROLLBACK TO SAVEPOINT " + name); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+
+	_, err := t.Exec("RELEASE SAVEPOINT " + name)
+	return err
+}
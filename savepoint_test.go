@@ -0,0 +1,59 @@
+package pgmodel
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestWithSavepoint(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+
+	// A failed nested savepoint rolls back only its own changes
+	want := errors.New("failed")
+	err := WithSavepoint(tx, func() error {
+		if _, err := Save(&testModel{ID: 1, Name: "one"}, tx); err != nil {
+			return err
+		}
+		if err := WithSavepoint(tx, func() error {
+			if _, err := Save(&testModel{ID: 2, Name: "two"}, tx); err != nil {
+				return err
+			}
+			return want
+		}); err != want {
+			t.Errorf("got error %v, want %v", err, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed savepoint's error is returned
+	if err := WithSavepoint(tx, func() error {
+		if _, err := Save(&testModel{ID: 3, Name: "three"}, tx); err != nil {
+			return err
+		}
+		return want
+	}); err != want {
+		t.Fatalf("got error %v, want %v", err, want)
+	}
+
+	// The transaction can still be used
+	if err := WithSavepoint(tx, func() error {
+		_, err := Save(&testModel{ID: 4, Name: "four"}, tx)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	if _, err := tx.QueryOne(pg.Scan(pg.Array(&ids)), `SELECT array_agg(id ORDER BY id) FROM test.models`); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 4 {
+		t.Errorf("got ids %v, want [1 4]", ids)
+	}
+}
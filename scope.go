@@ -0,0 +1,91 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScopedModel types are models with a condition that limits every query on
+// their table to the rows they may access, such as rows belonging to the
+// current tenant or rows that haven't been soft deleted.
+//
+// The scope is added to the WHERE clauses of the queries performed by Get,
+// GetMany, GetManyInto, Save, SaveAll and Delete, and the functions built on
+// them. Saves may still insert rows outside of the scope, but never update
+// them.
+//
+// Scopes can refer to settings applied with WithSessionSetting, e.g.
+//
+//	func (u *User) DefaultScope() string {
+//		return "tenant_id = current_setting('app.tenant_id')::uuid"
+//	}
+type ScopedModel interface {
+	KeyedModel
+
+	// A predicate that every row accessed through the model must satisfy.
+	DefaultScope() string
+}
+
+// MARK: Exported functions
+
+// Unscoped disables the default scope of a ScopedModel, and the exclusion of
+// the soft deleted rows of a SoftDeletable model, for a single operation.
+func Unscoped() QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.unscoped = true
+	})
+}
+
+// MARK: Non-exported functions
+
+// scope returns the default scope applied to queries on pm, along with the
+// exclusion of soft deleted rows, or an empty string if there isn't one.
+func (o *queryOptions) scope(pm TableDescriber) string {
+	if o.unscoped {
+		return ""
+	}
+
+	var ss []string
+	if sm, ok := pm.(ScopedModel); ok {
+		if s := sm.DefaultScope(); s != "" {
+			ss = append(ss, "("+s+")")
+		}
+	}
+	if c := o.deletedAtColumn(pm); c != "" {
+		ss = append(ss, quoteIdent(c)+" IS NULL")
+	}
+	return strings.Join(ss, " AND ")
+}
+
+// scoped returns the predicate, p, combined with the default scope of queries
+// on pm.
+func (o *queryOptions) scoped(pm TableDescriber, p string) string {
+	if s := o.scope(pm); s != "" {
+		return p + " AND " + s
+	}
+	return p
+}
+
+// scopedWhere returns the optional where clause, w, of an upsert's DO UPDATE
+// action combined with the default scope of queries on pm.
+//
+// Unqualified columns are ambiguous in the action's where clause, as they may
+// belong to the existing or excluded row, so the scope is checked against the
+// existing row in a subquery.
+func (o *queryOptions) scopedWhere(pm TableDescriber, w string) string {
+	s := o.scope(pm)
+	if s == "" {
+		return w
+	}
+
+	s = fmt.Sprintf(
+		"EXISTS (SELECT 1 FROM %s AS pgmodel_scope WHERE pgmodel_scope.ctid = %s.ctid AND %s)",
+		o.qualifiedName(pm),
+		quoteIdent(o.tableName(pm)),
+		s,
+	)
+	if w == "" {
+		return "WHERE " + s
+	}
+	return w + " AND " + s
+}
@@ -0,0 +1,51 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+)
+
+// tenantModel is a model of the test.models table scoped to a tenant.
+type tenantModel struct {
+	Base[tenantModel] `pgmodel:"test.models"`
+	ID                int    `pg:"id,pk"`
+	Name              string `pg:"name"`
+}
+
+func (m *tenantModel) DefaultScope() string {
+	return "name = current_setting('app.tenant')"
+}
+
+func TestDefaultScope(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Get(&tenantModel{}, e, "id", 1); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.Contains(q, `AND (name = current_setting('app.tenant'))`) {
+		t.Errorf("got query %q, want the scope", q)
+	}
+
+	// Unscoped removes the scope for a single operation
+	if _, err := Get(&tenantModel{}, e, "id", 1, Unscoped()); err != nil {
+		t.Fatal(err)
+	}
+	if q := e.last().query; strings.Contains(q, "app.tenant") {
+		t.Errorf("got query %q, want no scope", q)
+	}
+}
+
+func TestScopedWhere(t *testing.T) {
+	o := new(queryOptions)
+	want := `WHERE EXISTS (SELECT 1 FROM "test"."models" AS pgmodel_scope WHERE pgmodel_scope.ctid = "models".ctid AND (name = current_setting('app.tenant')))`
+	if w := o.scopedWhere(&tenantModel{}, ""); w != want {
+		t.Errorf("got %q, want %q", w, want)
+	}
+	if w := o.scopedWhere(&tenantModel{}, "WHERE true"); !strings.HasPrefix(w, "WHERE true AND EXISTS") {
+		t.Errorf("got %q", w)
+	}
+
+	// Unscoped models have no where clause to add
+	if w := o.scopedWhere(&testModel{}, ""); w != "" {
+		t.Errorf("got %q, want no where clause", w)
+	}
+}
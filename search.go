@@ -0,0 +1,102 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TextSearchModel types configure the text search configurations used when
+// searching their columns with WithSearch.
+type TextSearchModel interface {
+
+	// The text search configurations, such as "english" or "simple", used to
+	// search column c.
+	//
+	// Returning more than one configuration matches rows against a query parsed
+	// with each configuration, which is useful for tsvector columns built from
+	// content in several languages. Returning no configurations uses the
+	// database's default_text_search_config.
+	SearchConfigs(c string) []string
+}
+
+// TSVectorModel types declare which of their columns are precomputed tsvector
+// columns. Other searched columns are converted with to_tsvector.
+type TSVectorModel interface {
+	TSVectorColumns() []string
+}
+
+// search is a full-text search of a single column.
+type search struct {
+	column  string
+	query   string
+	configs []string
+}
+
+// MARK: Exported functions
+
+// WithSearch limits the results of GetMany to rows whose column matches the
+// web search style query, q.
+//
+// The query is parsed with the configurations returned by the model's
+// SearchConfigs method if it implements TextSearchModel.
+func WithSearch(column string, q string) QueryOption {
+	return WithSearchConfig(column, q)
+}
+
+// WithSearchConfig is identical to WithSearch but the query is parsed with the
+// given text search configurations instead of the model's.
+func WithSearchConfig(column string, q string, configs ...string) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.searches = append(o.searches, search{
+			column:  column,
+			query:   q,
+			configs: configs,
+		})
+	})
+}
+
+// MARK: Non-exported functions
+
+// predicate returns the search's predicate on pm and its parameters, or an
+// error if the searched column isn't a column name.
+func (s search) predicate(pm KeyedModel) (string, []interface{}, error) {
+	col, err := columnExpr(s.column)
+	if err != nil {
+		return "", nil, err
+	}
+
+	cs := s.configs
+	if len(cs) == 0 {
+		if tsm, ok := pm.(TextSearchModel); ok {
+			cs = tsm.SearchConfigs(s.column)
+		}
+	}
+
+	// Precomputed vectors are matched directly
+	v := fmt.Sprintf("to_tsvector(%s)", col)
+	if tvm, ok := pm.(TSVectorModel); ok {
+		for _, c := range tvm.TSVectorColumns() {
+			if c == s.column {
+				v = col
+				break
+			}
+		}
+	}
+
+	if len(cs) == 0 {
+		return fmt.Sprintf("%s @@ websearch_to_tsquery(?)", v), []interface{}{s.query}, nil
+	}
+
+	var ps []string
+	var a []interface{}
+	for _, c := range cs {
+		cv := v
+		if cv != col {
+			cv = fmt.Sprintf("to_tsvector(?::regconfig, %s)", col)
+			a = append(a, c)
+		}
+		ps = append(ps, fmt.Sprintf("%s @@ websearch_to_tsquery(?::regconfig, ?)", cv))
+		a = append(a, c, s.query)
+	}
+	return "(" + strings.Join(ps, " OR ") + ")", a, nil
+}
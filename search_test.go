@@ -0,0 +1,61 @@
+package pgmodel
+
+import (
+	"reflect"
+	"testing"
+)
+
+// searchModel is a model of the test.documents table with a precomputed
+// tsvector column searched in English and German.
+type searchModel struct {
+	Base[searchModel] `pgmodel:"test.documents"`
+	ID                int    `pg:"id,pk"`
+	Body              string `pg:"body"`
+	Vector            string `pg:"vector"`
+}
+
+func (m *searchModel) SearchConfigs(c string) []string {
+	if c == "vector" {
+		return []string{"english", "german"}
+	}
+	return nil
+}
+
+func (m *searchModel) TSVectorColumns() []string {
+	return []string{"vector"}
+}
+
+func TestSearchPredicate(t *testing.T) {
+	for _, c := range []struct {
+		search search
+		want   string
+		params []interface{}
+	}{
+		{
+			search{column: "body", query: "q"},
+			`to_tsvector("body") @@ websearch_to_tsquery(?)`,
+			[]interface{}{"q"},
+		},
+		{
+			search{column: "body", query: "q", configs: []string{"simple"}},
+			`(to_tsvector(?::regconfig, "body") @@ websearch_to_tsquery(?::regconfig, ?))`,
+			[]interface{}{"simple", "simple", "q"},
+		},
+		{
+			search{column: "vector", query: "q"},
+			`("vector" @@ websearch_to_tsquery(?::regconfig, ?) OR "vector" @@ websearch_to_tsquery(?::regconfig, ?))`,
+			[]interface{}{"english", "q", "german", "q"},
+		},
+	} {
+		p, a, err := c.search.predicate(&searchModel{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != c.want {
+			t.Errorf("got predicate %q, want %q", p, c.want)
+		}
+		if !reflect.DeepEqual(a, c.params) {
+			t.Errorf("got parameters %v, want %v", a, c.params)
+		}
+	}
+}
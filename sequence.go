@@ -0,0 +1,82 @@
+package pgmodel
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// MARK: Exported functions
+
+// NextVal advances the sequence and returns its new value.
+func NextVal(t Executor, sequence string) (int64, error) {
+	var v int64
+	_, err := t.QueryOne(pg.Scan(&v), `SELECT nextval(?::regclass)`, sequence)
+	return v, err
+}
+
+// SetVal sets the current value of the sequence. If isCalled is false, the
+// next call to NextVal returns value, otherwise it returns the value after it.
+func SetVal(t Executor, sequence string, value int64, isCalled bool) error {
+	_, err := t.Exec(`SELECT setval(?::regclass, ?, ?)`, sequence, value, isCalled)
+	return err
+}
+
+// SequenceName returns the name of the sequence backing pm's primary key
+// column.
+func SequenceName(pm PGModel, t Executor) (string, error) {
+	var sn *string
+	_, err := t.QueryOne(pg.Scan(&sn),
+		`SELECT pg_get_serial_sequence(?, ?)`,
+		qualify(pm.SchemaName(), pm.TableName()),
+		pm.PrimaryKey(),
+	)
+	if err != nil {
+		return "", err
+	}
+	if sn == nil {
+		return "", fmt.Errorf("pgmodel: %s.%s.%s is not backed by a sequence", pm.SchemaName(), pm.TableName(), pm.PrimaryKey())
+	}
+	return *sn, nil
+}
+
+// ReserveIDs reserves n values from the sequence backing pm's primary key and
+// returns them in ascending order. The values won't be returned by the
+// sequence again, so they can be assigned to models before a bulk insert.
+//
+// Values reserved concurrently by other sessions may be interleaved, so the
+// returned values are not guaranteed to be contiguous.
+func ReserveIDs(pm PGModel, t Executor, n int) ([]int64, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	sn, err := SequenceName(pm, t)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	_, err = t.Query(&ids,
+		`SELECT nextval(?::regclass) AS id
+		FROM generate_series(1, ?)
+		ORDER BY id`,
+		sn,
+		n,
+	)
+	return ids, err
+}
+
+// ResetIdentity restarts the sequence backing pm's primary key so that the
+// next generated value is restartWith. The sequence is found by introspection,
+// so this works for both serial and identity columns.
+//
+// This is typically used after importing rows with explicit keys or after
+// truncating the table.
+func ResetIdentity(pm PGModel, t Executor, restartWith int64) error {
+	sn, err := SequenceName(pm, t)
+	if err != nil {
+		return err
+	}
+	return SetVal(t, sn, restartWith, false)
+}
@@ -0,0 +1,92 @@
+package pgmodel
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// serialModel is a model of the test.serials table with a serial primary key.
+type serialModel struct {
+	Base[serialModel] `pgmodel:"test.serials"`
+	ID                int64  `pg:"id,pk"`
+	Name              string `pg:"name"`
+}
+
+// createSerialTable creates the test.serials table in the transaction.
+func createSerialTable(t *testing.T, e Executor) {
+	t.Helper()
+	for _, q := range []string{
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.serials (id bigserial PRIMARY KEY, name text)`,
+	} {
+		if _, err := e.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReserveIDsNone(t *testing.T) {
+	e := new(testExecutor)
+	ids, err := ReserveIDs(&serialModel{}, e, 0)
+	if err != nil || ids != nil {
+		t.Errorf("got %v, %v", ids, err)
+	}
+	if e.count() != 0 {
+		t.Errorf("performed %d queries, want 0", e.count())
+	}
+}
+
+func TestReserveIDs(t *testing.T) {
+	tx := testTx(t)
+	createSerialTable(t, tx)
+
+	ids, err := ReserveIDs(&serialModel{}, tx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || ids[0] >= ids[1] || ids[1] >= ids[2] {
+		t.Fatalf("got %v, want 3 ascending IDs", ids)
+	}
+
+	sn, err := SequenceName(&serialModel{}, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := NextVal(tx, sn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v <= ids[2] {
+		t.Errorf("got %d from the sequence, reserved %v", v, ids)
+	}
+}
+
+func TestResetIdentityLookupError(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return nil, errors.New("lookup failed")
+	}}
+	if err := ResetIdentity(&serialModel{}, e, 1); err == nil {
+		t.Fatal("expected an error")
+	}
+	if e.count() != 1 {
+		t.Errorf("performed %d queries, want only the lookup", e.count())
+	}
+}
+
+func TestResetIdentity(t *testing.T) {
+	tx := testTx(t)
+	createSerialTable(t, tx)
+
+	if err := ResetIdentity(&serialModel{}, tx, 100); err != nil {
+		t.Fatal(err)
+	}
+	sn, err := SequenceName(&serialModel{}, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := NextVal(tx, sn); err != nil || v != 100 {
+		t.Errorf("got %d, %v, want 100", v, err)
+	}
+}
@@ -0,0 +1,122 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// Query is a select query on a model's table that can be combined with other
+// queries by UNION, EXCEPT and INTERSECT, e.g. to report the rows of one table
+// that are missing from another:
+//
+//	q := pgmodel.Select(new(Bar), nil).Except(pgmodel.Select(new(Bar), nil, pgmodel.WithSchema("archive")))
+//	var bs []*Bar
+//	_, err := q.Scan(t, &bs)
+//
+// Combined queries must select the same number of columns with compatible
+// types.
+type Query struct {
+	pm  KeyedModel
+	o   *queryOptions
+	q   string
+	a   []interface{}
+	err error
+}
+
+// MARK: Exported functions
+
+// Select returns a query selecting the rows of pm's table that match the
+// condition, c, or every row if c is nil, with the options. Options such as
+// WithColumns, WithOrderBy and WithLimit apply to the query before it's
+// combined with others.
+func Select(pm KeyedModel, c *Condition, opts ...QueryOption) *Query {
+	o := newQueryOptions(opts)
+	c.apply(o)
+	q, a, err := createSelectQuery(pm, "TRUE", nil, o)
+	return &Query{pm: pm, o: o, q: q, a: a, err: err}
+}
+
+// Union returns a query selecting the distinct rows of q and r.
+func (q *Query) Union(r *Query) *Query {
+	return q.combine("UNION", r)
+}
+
+// UnionAll returns a query selecting the rows of q and r, including
+// duplicates.
+func (q *Query) UnionAll(r *Query) *Query {
+	return q.combine("UNION ALL", r)
+}
+
+// Except returns a query selecting the distinct rows of q that aren't rows of
+// r.
+func (q *Query) Except(r *Query) *Query {
+	return q.combine("EXCEPT", r)
+}
+
+// Intersect returns a query selecting the distinct rows of q that are also
+// rows of r.
+func (q *Query) Intersect(r *Query) *Query {
+	return q.combine("INTERSECT", r)
+}
+
+// SQL returns the query with placeholders in place of its parameters.
+func (q *Query) SQL() string {
+	return q.q
+}
+
+// Scan performs the query with the given executor and scans its rows in to
+// dst, which must be a pointer to a slice of models or structs. An error is
+// returned without performing the query if the condition of any of the
+// combined queries is invalid. The settings given to each of the combined
+// queries' Select calls are applied to the query.
+func (q *Query) Scan(t Executor, dst interface{}) (*Result, error) {
+	return q.ScanContext(context.Background(), t, dst)
+}
+
+// ScanContext is identical to Scan but performs the query with the context,
+// ctx, so that it can be cancelled or given a deadline.
+func (q *Query) ScanContext(ctx context.Context, t Executor, dst interface{}) (*Result, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	o := *q.o
+	o.ctx = ctx
+	res, err := runContext(ctx, OperationGetMany, q.pm, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryContext(ctx, dst, q.q, q.a...)
+		})
+		normalizeTimes(dst)
+		return res, err
+	})
+	return newResult(res, q.q), err
+}
+
+// MARK: Non-exported functions
+
+// combine returns a query combining the rows of q and r with the set
+// operator, op.
+func (q *Query) combine(op string, r *Query) *Query {
+	if q.err != nil {
+		return q
+	}
+	if r.err != nil {
+		return r
+	}
+	a := make([]interface{}, 0, len(q.a)+len(r.a))
+	a = append(a, q.a...)
+	a = append(a, r.a...)
+
+	// Apply the settings of both queries
+	o := *q.o
+	o.settings = make([]setting, 0, len(q.o.settings)+len(r.o.settings))
+	o.settings = append(o.settings, q.o.settings...)
+	o.settings = append(o.settings, r.o.settings...)
+	return &Query{
+		pm: q.pm,
+		o:  &o,
+		q:  fmt.Sprintf("(%s) %s (%s)", q.q, op, r.q),
+		a:  a,
+	}
+}
@@ -0,0 +1,63 @@
+package pgmodel
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestQueryCombine(t *testing.T) {
+	q := Select(&testModel{}, Where("name", Eq, "a"), WithColumns("id")).
+		Except(Select(&testModel{}, Where("name", Eq, "b"), WithColumns("id"), WithSchema("archive")))
+
+	sql := squash(q.SQL())
+	if !strings.HasPrefix(sql, `(SELECT "id" FROM "test"."models"`) || !strings.Contains(sql, `) EXCEPT (SELECT "id" FROM "archive"."models"`) {
+		t.Errorf("got query %q", sql)
+	}
+
+	e := new(testExecutor)
+	var ms []*testModel
+	if _, err := q.Scan(e, &ms); err != nil {
+		t.Fatal(err)
+	}
+	if p := e.last().params; len(p) != 2 || p[0] != "a" || p[1] != "b" {
+		t.Errorf("got parameters %v, want [a b]", p)
+	}
+
+	for op, r := range map[string]*Query{
+		"UNION":     Select(&testModel{}, nil).Union(Select(&testModel{}, nil)),
+		"UNION ALL": Select(&testModel{}, nil).UnionAll(Select(&testModel{}, nil)),
+		"INTERSECT": Select(&testModel{}, nil).Intersect(Select(&testModel{}, nil)),
+	} {
+		if !strings.Contains(r.SQL(), ") "+op+" (") {
+			t.Errorf("got query %q, want %s", r.SQL(), op)
+		}
+	}
+}
+
+func TestQueryCombineInvalid(t *testing.T) {
+	q := Select(&testModel{}, nil).Union(Select(&testModel{}, Where("name; DROP TABLE x", Eq, 1)))
+
+	e := new(testExecutor)
+	var ms []*testModel
+	if _, err := q.Scan(e, &ms); err == nil {
+		t.Error("expected an error for the invalid condition")
+	}
+	if n := e.count(); n != 0 {
+		t.Errorf("got %d queries, want none", n)
+	}
+}
+
+func TestQueryScanContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	e := new(testExecutor)
+
+	var ms []*testModel
+	if _, err := Select(&testModel{}, nil).Union(Select(&testModel{}, nil)).ScanContext(ctx, e, &ms); err != nil {
+		t.Fatal(err)
+	}
+	if q := e.last(); q.ctx.Value(key{}) != "request" {
+		t.Errorf("query %q wasn't performed with the context", squash(q.query))
+	}
+}
@@ -0,0 +1,111 @@
+package pgmodel
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// setting is a run-time configuration parameter applied to an operation.
+type setting struct {
+	name  string
+	value string
+}
+
+// MARK: Exported functions
+
+// WithSessionSetting sets the run-time configuration parameter, name, to value
+// for the duration of the operation's queries.
+//
+// The parameter is set locally to the transaction before the operation and
+// restored to its previous value afterwards, so it doesn't affect the
+// transaction's other queries. Custom parameters, such as app.tenant_id, don't
+// need to have been set before, and are reset afterwards if they weren't.
+// Operations given settings must be performed in a transaction.
+func WithSessionSetting(name string, value string) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.settings = append(o.settings, setting{
+			name:  name,
+			value: value,
+		})
+	})
+}
+
+// WithSearchPath sets search_path to the given schemas for the duration of the
+// operation's queries. Schemas are given as they would appear in a SET
+// search_path statement, e.g.
+//
+//	WithSearchPath("tenant_42", "public")
+//
+// The search path only affects models whose SchemaName is empty, or operations
+// given WithSchema(""), as all other tables are qualified with their schema.
+func WithSearchPath(schemas ...string) QueryOption {
+	return WithSessionSetting("search_path", strings.Join(schemas, ", "))
+}
+
+// WithPlannerSetting sets the planner configuration parameter, name, to value
+// for the duration of the operation's queries, e.g.
+//
+//	WithPlannerSetting("enable_seqscan", "off")
+//	WithPlannerSetting("work_mem", "256MB")
+//	WithPlannerSetting("jit", "off")
+//
+// The parameter is applied the same way as WithSessionSetting. It's intended
+// as an escape hatch for specific queries that Postgres plans poorly.
+func WithPlannerSetting(name string, value string) QueryOption {
+	return WithSessionSetting(name, value)
+}
+
+// WithApplicationName sets application_name to name for the duration of the
+// operation's queries so that the workload performing them can be identified
+// in pg_stat_activity and the server's logs.
+func WithApplicationName(name string) QueryOption {
+	return WithSessionSetting("application_name", name)
+}
+
+// MARK: Non-exported functions
+
+// withSettings applies the options' settings in the given transaction, calls
+// fn, and then restores the settings' previous values. ErrNotTransaction is
+// returned if there are settings and t isn't a transaction.
+//
+// If fn returns an error from the server the settings aren't restored, since
+// the transaction has been aborted and rolling it back, or back to a
+// savepoint, reverts them.
+func (o *queryOptions) withSettings(t Executor, fn func() (orm.Result, error)) (orm.Result, error) {
+	if len(o.settings) == 0 {
+		return fn()
+	}
+	if _, ok := t.(*pg.Tx); !ok {
+		return nil, ErrNotTransaction
+	}
+
+	// Apply the settings, keeping their previous values. Custom parameters that
+	// haven't been set have no value, and are reset when they're restored.
+	prev := make([]*string, len(o.settings))
+	for i, s := range o.settings {
+		if _, err := t.QueryOneContext(o.context(), pg.Scan(&prev[i]), `SELECT current_setting(?, true)`, s.name); err != nil {
+			return nil, err
+		}
+		if _, err := t.ExecContext(o.context(), `SELECT set_config(?, ?, true)`, s.name, s.value); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := fn()
+	var pe pg.Error
+	if errors.As(err, &pe) {
+		return res, err
+	}
+
+	// Restore the previous values in reverse order so that repeated settings
+	// end up with their original value
+	for i := len(o.settings) - 1; i >= 0; i-- {
+		if _, rerr := t.ExecContext(o.context(), `SELECT set_config(?, ?, true)`, o.settings[i].name, prev[i]); rerr != nil {
+			return nil, rerr
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,154 @@
+package pgmodel
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestWithSettingsRequiresTransaction(t *testing.T) {
+	o := newQueryOptions([]QueryOption{WithSessionSetting("app.tenant_id", "42")})
+	_, err := o.withSettings(new(testExecutor), func() (orm.Result, error) {
+		return testResult{}, nil
+	})
+	if !errors.Is(err, ErrNotTransaction) {
+		t.Fatalf("got error %v, want ErrNotTransaction", err)
+	}
+}
+
+func TestWithSettingsUnsetCustomParameter(t *testing.T) {
+	tx := testTx(t)
+
+	var during string
+	o := newQueryOptions([]QueryOption{WithSessionSetting("pgmodel_test.tenant_id", "42")})
+	_, err := o.withSettings(tx, func() (orm.Result, error) {
+		return tx.QueryOne(pg.Scan(&during), `SELECT current_setting('pgmodel_test.tenant_id')`)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if during != "42" {
+		t.Errorf("got %q during the operation, want 42", during)
+	}
+
+	var after *string
+	if _, err := tx.QueryOne(pg.Scan(&after), `SELECT nullif(current_setting('pgmodel_test.tenant_id', true), '')`); err != nil {
+		t.Fatal(err)
+	}
+	if after != nil {
+		t.Errorf("got %q after the operation, want no value", *after)
+	}
+}
+
+func TestWithSettingsRestoresValue(t *testing.T) {
+	tx := testTx(t)
+	if _, err := tx.Exec(`SET LOCAL application_name = 'before'`); err != nil {
+		t.Fatal(err)
+	}
+
+	o := newQueryOptions([]QueryOption{WithApplicationName("during")})
+	if _, err := o.withSettings(tx, func() (orm.Result, error) {
+		return tx.Exec(`SELECT 1`)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var after string
+	if _, err := tx.QueryOne(pg.Scan(&after), `SELECT current_setting('application_name')`); err != nil {
+		t.Fatal(err)
+	}
+	if after != "before" {
+		t.Errorf("got %q after the operation, want before", after)
+	}
+}
+
+func TestWithPlannerSetting(t *testing.T) {
+	o := newQueryOptions([]QueryOption{WithPlannerSetting("enable_seqscan", "off")})
+	if want := []setting{{name: "enable_seqscan", value: "off"}}; !reflect.DeepEqual(o.settings, want) {
+		t.Fatalf("got settings %v, want %v", o.settings, want)
+	}
+
+	tx := testTx(t)
+	var during string
+	if _, err := o.withSettings(tx, func() (orm.Result, error) {
+		return tx.QueryOne(pg.Scan(&during), `SELECT current_setting('enable_seqscan')`)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if during != "off" {
+		t.Errorf("got %q during the operation, want off", during)
+	}
+}
+
+func TestWithApplicationName(t *testing.T) {
+	o := newQueryOptions([]QueryOption{WithApplicationName("billing-worker")})
+	if want := []setting{{name: "application_name", value: "billing-worker"}}; !reflect.DeepEqual(o.settings, want) {
+		t.Fatalf("got settings %v, want %v", o.settings, want)
+	}
+
+	tx := testTx(t)
+	var during string
+	if _, err := o.withSettings(tx, func() (orm.Result, error) {
+		return tx.QueryOne(pg.Scan(&during), `SELECT current_setting('application_name')`)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if during != "billing-worker" {
+		t.Errorf("got %q during the operation, want billing-worker", during)
+	}
+}
+
+func TestOperationsApplySettings(t *testing.T) {
+	tenant := WithSessionSetting("app.tenant_id", "42")
+	e := new(testExecutor)
+
+	// Each operation applies its settings, so requires a transaction
+	if _, _, err := GetPage[*testModel](e, Cursor{}, 10, tenant); !errors.Is(err, ErrNotTransaction) {
+		t.Errorf("GetPage: got %v, want %v", err, ErrNotTransaction)
+	}
+	if _, err := GetByKey(&testModel{}, e, map[string]interface{}{"name": "a"}, tenant); !errors.Is(err, ErrNotTransaction) {
+		t.Errorf("GetByKey: got %v, want %v", err, ErrNotTransaction)
+	}
+	var ms []*testModel
+	if _, err := Select(&testModel{}, nil, tenant).Scan(e, &ms); !errors.Is(err, ErrNotTransaction) {
+		t.Errorf("Select: got %v, want %v", err, ErrNotTransaction)
+	}
+
+	// Combined queries apply the settings of each query
+	if _, err := Select(&testModel{}, nil).Union(Select(&testModel{}, nil, tenant)).Scan(e, &ms); !errors.Is(err, ErrNotTransaction) {
+		t.Errorf("Union: got %v, want %v", err, ErrNotTransaction)
+	}
+	if e.count() != 0 {
+		t.Errorf("got %d queries, want none", e.count())
+	}
+}
+
+func TestUpdateWhereAppliesSettings(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx,
+		`INSERT INTO test.models (id, name) VALUES (1, 'one')`,
+		`CREATE FUNCTION test.models_name() RETURNS trigger LANGUAGE plpgsql AS $$
+		BEGIN
+			NEW.name := current_setting('pgmodel_test.name');
+			RETURN NEW;
+		END
+		$$`,
+		`CREATE TRIGGER models_name BEFORE UPDATE ON test.models FOR EACH ROW EXECUTE FUNCTION test.models_name()`,
+	)
+
+	_, err := UpdateWhere(&testModel{}, tx, Where("id", Eq, 1), map[string]interface{}{"name": "two"}, WithSessionSetting("pgmodel_test.name", "set"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	if _, err := tx.QueryOne(pg.Scan(&name), `SELECT name FROM test.models WHERE id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if name != "set" {
+		t.Errorf("got name %q, want the setting's value", name)
+	}
+}
@@ -0,0 +1,240 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+)
+
+const (
+	// ShadowSuffix is appended to a model's table name to name its shadow
+	// table.
+	ShadowSuffix = "_shadow"
+
+	// ShadowOldSuffix is appended to a model's table name to name the original
+	// table after SwapShadow replaces it.
+	ShadowOldSuffix = "_old"
+)
+
+// ShadowOptions configures CopyToShadow.
+type ShadowOptions struct {
+
+	// The number of rows to copy per transaction. Defaults to
+	// DefaultBackfillBatchSize.
+	BatchSize int
+
+	// Limits the rate at which batches are copied.
+	Throttle Throttle
+
+	// If set, called after each batch with the progress of the copy. The total
+	// is the planner's estimate of the number of rows in the table.
+	OnProgress func(Progress)
+}
+
+// MARK: Exported functions
+
+// CreateShadow creates a shadow copy of pm's table for a zero-downtime schema
+// change, applies the ALTER TABLE actions, alter, to it, and installs triggers
+// that keep it in sync with changes to pm's table. For example,
+//
+//	pgmodel.CreateShadow(pm, t, "ALTER COLUMN amount TYPE numeric(20, 4)")
+//
+// The shadow table starts with the same columns, defaults, constraints and
+// indexes as pm's table but no rows. Existing rows are copied with
+// CopyToShadow, after which SwapShadow replaces pm's table with the shadow
+// table.
+//
+// The triggers write the model's columns, so the actions must leave every one
+// of them in place.
+func CreateShadow(pm PGModel, t *pg.Tx, alter ...string) error {
+	// Get everything once
+	pk := quoteIdent(pm.PrimaryKey())
+	sn := pm.SchemaName()
+	tn := pm.TableName()
+	stn := tn + ShadowSuffix
+	qn := qualify(sn, tn)
+	sqn := qualify(sn, stn)
+	fn := qualify(sn, stn+"_fn")
+	c := quoteIdents(columns(pm))
+
+	// Create arrays to join
+	var nv, sm []string
+	for _, u := range c {
+		nv = append(nv, "NEW."+u)
+	}
+	for _, u := range quoteIdents(pm.NonPKColumns()) {
+		sm = append(sm, fmt.Sprintf("%s = EXCLUDED.%s", u, u))
+	}
+
+	qs := []string{
+		fmt.Sprintf(
+			`CREATE TABLE %s (LIKE %s INCLUDING ALL)`,
+			sqn,
+			qn,
+		),
+	}
+	for _, a := range alter {
+		qs = append(qs, fmt.Sprintf(`ALTER TABLE %s %s`, sqn, a))
+	}
+	qs = append(qs,
+		fmt.Sprintf(
+			`CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$
+			BEGIN
+				IF TG_OP = 'DELETE' OR (TG_OP = 'UPDATE' AND OLD.%s IS DISTINCT FROM NEW.%s) THEN
+					DELETE FROM %s WHERE %s = OLD.%s;
+				END IF;
+				IF TG_OP IN ('INSERT', 'UPDATE') THEN
+					INSERT INTO %s (%s) VALUES (%s)
+					ON CONFLICT (%s) DO UPDATE SET %s;
+				END IF;
+				RETURN NULL;
+			END
+			$$ LANGUAGE plpgsql`,
+			fn,
+			pk, pk,
+			sqn, pk, pk,
+			sqn, strings.Join(c, ", "), strings.Join(nv, ", "),
+			pk, strings.Join(sm, ", "),
+		),
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS %s ON %s`, quoteIdent(stn), qn),
+		fmt.Sprintf(
+			`CREATE TRIGGER %s
+			AFTER INSERT OR UPDATE OR DELETE ON %s
+			FOR EACH ROW EXECUTE PROCEDURE %s()`,
+			quoteIdent(stn),
+			qn,
+			fn,
+		),
+	)
+
+	for _, q := range qs {
+		if _, err := t.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyToShadow copies the rows of pm's table in to the shadow table created by
+// CreateShadow in primary key order, each batch in its own transaction.
+//
+// Rows that the triggers have already written to the shadow table are left as
+// they are, so a copy that was interrupted can safely be run again.
+func CopyToShadow(ctx context.Context, db *pg.DB, pm PGModel, opts ShadowOptions) error {
+	size := opts.BatchSize
+	if size <= 0 {
+		size = DefaultBackfillBatchSize
+	}
+
+	var total int64
+	if opts.OnProgress != nil {
+		var err error
+		q := fmt.Sprintf(`SELECT 1 FROM %s`, qualify(pm.SchemaName(), pm.TableName()))
+		if total, err = estimateRows(ctx, db, q); err != nil {
+			return err
+		}
+	}
+	pr := newProgress(opts.OnProgress, total)
+	th := newThrottler(opts.Throttle)
+
+	var lastKey *string
+	for {
+		if err := th.wait(ctx); err != nil {
+			return err
+		}
+
+		var b struct {
+			Count   int
+			LastKey *string
+		}
+		q, a := createShadowCopyQuery(pm, lastKey, size)
+		if _, err := db.QueryOneContext(ctx, &b, q, a...); err != nil {
+			return err
+		}
+		lastKey = b.LastKey
+
+		th.done(b.Count)
+		pr.add(b.Count)
+
+		if b.Count < size {
+			return nil
+		}
+	}
+}
+
+// SwapShadow replaces pm's table with the shadow table created by CreateShadow
+// in the given transaction. The sync triggers are removed, pm's table is
+// renamed with ShadowOldSuffix and the shadow table takes its name.
+//
+// pm's table is locked until the transaction ends, so the transaction should
+// be committed promptly. The old table is kept so that it can be inspected or
+// swapped back, and should be dropped once it's no longer needed.
+func SwapShadow(pm PGModel, t *pg.Tx) error {
+	// Get everything once
+	sn := pm.SchemaName()
+	tn := pm.TableName()
+	stn := tn + ShadowSuffix
+	qn := qualify(sn, tn)
+
+	qs := []string{
+		fmt.Sprintf(`LOCK TABLE %s IN ACCESS EXCLUSIVE MODE`, qn),
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS %s ON %s`, quoteIdent(stn), qn),
+		fmt.Sprintf(`DROP FUNCTION IF EXISTS %s()`, qualify(sn, stn+"_fn")),
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, qn, quoteIdent(tn+ShadowOldSuffix)),
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, qualify(sn, stn), quoteIdent(tn)),
+	}
+
+	for _, q := range qs {
+		if _, err := t.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MARK: Non-exported functions
+
+// createShadowCopyQuery creates a query copying up to size of pm's rows after
+// lastKey in to its shadow table, and returns it with its parameters. The
+// query returns the number of rows read and the last key read.
+func createShadowCopyQuery(pm PGModel, lastKey *string, size int) (string, []interface{}) {
+	// Get everything once
+	pk := quoteIdent(pm.PrimaryKey())
+	sn := pm.SchemaName()
+	tn := pm.TableName()
+	c := quoteList(columns(pm))
+
+	p := "true"
+	var a []interface{}
+	if lastKey != nil {
+		p = fmt.Sprintf("%s > ?", pk)
+		a = append(a, *lastKey)
+	}
+
+	// Create the query
+	return fmt.Sprintf(
+		`WITH b AS (
+			SELECT %s FROM %s
+			WHERE %s
+			ORDER BY %s
+			LIMIT %d
+		), i AS (
+			INSERT INTO %s (%s)
+			SELECT %s FROM b
+			ON CONFLICT (%s) DO NOTHING
+		)
+		SELECT
+			(SELECT count(*) FROM b) AS count,
+			(SELECT %s::text FROM b ORDER BY %s DESC LIMIT 1) AS last_key`,
+		c, qualify(sn, tn),
+		p,
+		pk,
+		size,
+		qualify(sn, tn+ShadowSuffix), c,
+		c,
+		pk,
+		pk, pk,
+	), a
+}
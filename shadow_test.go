@@ -0,0 +1,52 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestCreateShadowCopyQuery(t *testing.T) {
+	q, a := createShadowCopyQuery(&testModel{}, nil, 100)
+	q = squash(q)
+	if !strings.Contains(q, `FROM "test"."models" WHERE true ORDER BY "id" LIMIT 100`) || len(a) != 0 {
+		t.Errorf("got query %q with parameters %v", q, a)
+	}
+	if !strings.Contains(q, `INSERT INTO "test"."models_shadow" ("id", "name", "tags")`) {
+		t.Errorf("got query %q", q)
+	}
+
+	// Later batches start after the last key
+	k := "42"
+	q, a = createShadowCopyQuery(&testModel{}, &k, 100)
+	if !strings.Contains(squash(q), `WHERE "id" > ?`) || len(a) != 1 || a[0] != "42" {
+		t.Errorf("got query %q with parameters %v", q, a)
+	}
+}
+
+func TestShadowSwap(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	if err := CreateShadow(&testModel{}, tx, "ALTER COLUMN name SET DEFAULT 'unnamed'"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes to the model's table are synced to the shadow table
+	if _, err := Save(&testModel{ID: 1, Name: "one"}, tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := SwapShadow(&testModel{}, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	var name, def string
+	if _, err := tx.QueryOne(pg.Scan(&name, &def),
+		`SELECT (SELECT name FROM test.models WHERE id = 1), (SELECT column_default FROM information_schema.columns WHERE table_schema = 'test' AND table_name = 'models' AND column_name = 'name')`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if name != "one" || !strings.Contains(def, "unnamed") {
+		t.Errorf("got name %q and default %q after swapping", name, def)
+	}
+}
@@ -0,0 +1,88 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// maxSlugAttempts is the number of slugs SaveWithUniqueSlug tries before
+// giving up.
+const maxSlugAttempts = 100
+
+// uniqueViolation is the SQLSTATE of unique constraint violations.
+const uniqueViolation = "23505"
+
+// MARK: Exported functions
+
+// SaveWithUniqueSlug performs a save in the given transaction using baseSlug
+// as the value of column. If the save violates a unique constraint, it is
+// retried with the slugs baseSlug-2, baseSlug-3, and so on until it succeeds.
+// The slug that was saved is returned.
+//
+// The value returned by the model for column is ignored, so callers should set
+// the returned slug on the model after a successful save. Each attempt is made
+// inside a savepoint, so failed attempts don't abort the transaction. Models
+// implementing BeforeSaver and AfterSaver have their hooks called once, before
+// the first attempt and after the successful one.
+func SaveWithUniqueSlug(pm PGModel, t *pg.Tx, column string, baseSlug string, opts ...QueryOption) (string, *Result, error) {
+	return SaveWithUniqueSlugContext(context.Background(), pm, t, column, baseSlug, opts...)
+}
+
+// SaveWithUniqueSlugContext is identical to SaveWithUniqueSlug but performs
+// its queries with the context, ctx, so that they can be cancelled or given a
+// deadline.
+func SaveWithUniqueSlugContext(ctx context.Context, pm PGModel, t *pg.Tx, column string, baseSlug string, opts ...QueryOption) (string, *Result, error) {
+	if err := errPartial(pm, "SaveWithUniqueSlug"); err != nil {
+		return "", nil, err
+	}
+
+	// Find the slug column
+	found := false
+	for _, c := range pm.NonPKColumns() {
+		if c == column {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", nil, fmt.Errorf("pgmodel: %s is not a non-primary key column of %s.%s", column, pm.SchemaName(), pm.TableName())
+	}
+
+	if err := beforeSave(ctx, pm, t); err != nil {
+		return "", nil, err
+	}
+
+	var err error
+	for i := 1; i <= maxSlugAttempts; i++ {
+		slug := baseSlug
+		if i > 1 {
+			slug = fmt.Sprintf("%s-%d", baseSlug, i)
+		}
+
+		var res *Result
+		err = savepoint(t, func() error {
+			var serr error
+			res, serr = saveContext(ctx, pm, t, append(opts[:len(opts):len(opts)], withColumnValue(column, slug)))
+			return serr
+		})
+		if err == nil {
+			return slug, res, afterSave(ctx, pm, t)
+		}
+		if !isUniqueViolation(err) {
+			return "", nil, err
+		}
+	}
+
+	return "", nil, err
+}
+
+// MARK: Non-exported functions
+
+// isUniqueViolation returns whether err is a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pe pg.Error
+	return errors.As(err, &pe) && pe.Field('C') == uniqueViolation
+}
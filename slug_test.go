@@ -0,0 +1,100 @@
+package pgmodel
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// slugModel is a model of the test.slugs table with a unique slug column.
+type slugModel struct {
+	Base[slugModel] `pgmodel:"test.slugs"`
+	ID              int    `pg:"id,pk"`
+	Slug            string `pg:"slug"`
+}
+
+func TestSaveWithUniqueSlugUnknownColumn(t *testing.T) {
+	if _, _, err := SaveWithUniqueSlug(&slugModel{ID: 1}, nil, "title", "a"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestSaveWithUniqueSlug(t *testing.T) {
+	tx := testTx(t)
+	for _, q := range []string{
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.slugs (id int PRIMARY KEY, slug text NOT NULL UNIQUE)`,
+	} {
+		if _, err := tx.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, want := range []string{"post", "post-2", "post-3"} {
+		slug, _, err := SaveWithUniqueSlug(&slugModel{ID: i + 1}, tx, "slug", "post")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slug != want {
+			t.Errorf("got slug %q, want %q", slug, want)
+		}
+	}
+}
+
+// hookedSlugModel is a model of the test.slugs table that records its save
+// hooks.
+type hookedSlugModel struct {
+	Base[hookedSlugModel] `pgmodel:"test.slugs"`
+	ID                    int    `pg:"id,pk"`
+	Slug                  string `pg:"slug"`
+
+	calls []string
+}
+
+func (m *hookedSlugModel) BeforeSave(ctx context.Context, t Executor) error {
+	m.calls = append(m.calls, "BeforeSave")
+	return nil
+}
+
+func (m *hookedSlugModel) AfterSave(ctx context.Context, t Executor) error {
+	m.calls = append(m.calls, "AfterSave")
+	return nil
+}
+
+func TestSaveWithUniqueSlugSavesLikeSave(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.slugs (id int PRIMARY KEY, slug text NOT NULL UNIQUE)`,
+		`INSERT INTO test.slugs VALUES (1, 'post')`,
+	)
+	EnableCache(&hookedSlugModel{}, time.Minute)
+	defer EnableCache(&hookedSlugModel{}, 0)
+	if _, err := Save(&hookedSlugModel{ID: 2, Slug: "draft"}, tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetCached(&hookedSlugModel{}, tx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// The hooks are called once, and the cached row is invalidated
+	m := &hookedSlugModel{ID: 2}
+	slug, _, err := SaveWithUniqueSlugContext(context.Background(), m, tx, "slug", "post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slug != "post-2" {
+		t.Errorf("got slug %q, want post-2", slug)
+	}
+	if want := []string{"BeforeSave", "AfterSave"}; !reflect.DeepEqual(m.calls, want) {
+		t.Errorf("got calls %v, want %v", m.calls, want)
+	}
+	c := new(hookedSlugModel)
+	if _, err := GetCached(c, tx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if c.Slug != "post-2" {
+		t.Errorf("got cached slug %q, want post-2", c.Slug)
+	}
+}
@@ -0,0 +1,86 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// SoftDeletable types are models whose rows are marked as deleted, rather than
+// removed from their table, e.g.
+//
+//	func (u *User) DeletedAtColumn() string { return "deleted_at" }
+//
+// Delete and DeleteWhere set the column to the time of the transaction instead
+// of deleting rows, and rows whose column isn't NULL are excluded from the
+// queries performed by Get, GetMany, GetManyInto, Save, SaveAll, Delete and
+// DeleteWhere, like the default scope of a ScopedModel. Use Unscoped to
+// include them, and ForceDelete and ForceDeleteWhere to remove rows from the
+// table.
+type SoftDeletable interface {
+	PGModel
+
+	// The nullable timestamp column holding the time each row was deleted.
+	DeletedAtColumn() string
+}
+
+// MARK: Exported functions
+
+// ForceDelete deletes the model with the given executor, removing its row
+// from the table even if the model is SoftDeletable and the row has already
+// been soft deleted.
+//
+// Models implementing BeforeDeleter and AfterDeleter have their hooks called
+// before and after the model is deleted.
+func ForceDelete(pm PGModel, t Executor, opts ...QueryOption) (*Result, error) {
+	return ForceDeleteContext(context.Background(), pm, t, opts...)
+}
+
+// ForceDeleteContext is identical to ForceDelete but performs its queries
+// with the context, ctx, so that they can be cancelled or given a deadline.
+func ForceDeleteContext(ctx context.Context, pm PGModel, t Executor, opts ...QueryOption) (*Result, error) {
+	return DeleteContext(ctx, pm, t, append(opts, forced())...)
+}
+
+// ForceDeleteWhere is identical to DeleteWhere but removes the matching rows
+// from the table even if pm is SoftDeletable, including rows that have
+// already been soft deleted.
+func ForceDeleteWhere(pm KeyedModel, t *pg.Tx, c *Condition, opts ...QueryOption) (*Result, error) {
+	return DeleteWhere(pm, t, c, append(opts, forced())...)
+}
+
+// MARK: Non-exported functions
+
+// forced makes a deletion remove rows from the table, rather than soft delete
+// them.
+func forced() QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.force = true
+	})
+}
+
+// deletedAtColumn returns pm's deleted at column, or an empty string if pm
+// isn't SoftDeletable or the operation removes rows from the table.
+func (o *queryOptions) deletedAtColumn(pm TableDescriber) string {
+	if sd, ok := pm.(SoftDeletable); ok && !o.force {
+		return sd.DeletedAtColumn()
+	}
+	return ""
+}
+
+// createSoftDeleteQuery creates a query marking pm's row as deleted.
+func createSoftDeleteQuery(pm PGModel, o *queryOptions) string {
+	// Create the query
+	q, _ := o.statement(pm, "soft delete", func() (string, error) {
+		return fmt.Sprintf(
+			`UPDATE %s
+			SET %s = now()
+			WHERE %s`,
+			o.qualifiedName(pm),
+			quoteIdent(o.deletedAtColumn(pm)),
+			o.scoped(pm, keyPredicate(pm, "")),
+		), nil
+	})
+	return q
+}
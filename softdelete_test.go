@@ -0,0 +1,151 @@
+package pgmodel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// softModel is a model of the test.soft table that's soft deleted.
+type softModel struct {
+	Base[softModel] `pgmodel:"test.soft"`
+	ID              int        `pg:"id,pk"`
+	Name            string     `pg:"name"`
+	DeletedAt       *time.Time `pg:"deleted_at"`
+}
+
+func (m *softModel) DeletedAtColumn() string {
+	return "deleted_at"
+}
+
+func TestDeleteSoftDeletes(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Delete(&softModel{ID: 1}, e); err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE "test"."soft" SET "deleted_at" = now() WHERE "id" = ? AND "deleted_at" IS NULL`
+	if q := squash(e.last().query); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+}
+
+func TestForceDelete(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := ForceDelete(&softModel{ID: 1}, e); err != nil {
+		t.Fatal(err)
+	}
+	want := `DELETE FROM "test"."soft" WHERE "id" = ?`
+	if q := squash(e.last().query); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+}
+
+func TestGetManyExcludesSoftDeleted(t *testing.T) {
+	e := new(testExecutor)
+	if _, _, err := GetMany[*softModel](e, "name", "one"); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); !strings.Contains(q, `AND "deleted_at" IS NULL`) {
+		t.Errorf("query %q doesn't exclude soft deleted rows", q)
+	}
+
+	if _, _, err := GetMany[*softModel](e, "name", "one", Unscoped()); err != nil {
+		t.Fatal(err)
+	}
+	if q := squash(e.last().query); strings.Contains(q, "deleted_at") {
+		t.Errorf("unscoped query %q excludes soft deleted rows", q)
+	}
+}
+
+func TestGetExcludesSoftDeleted(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Get(&softModel{}, e, "id", 1); err != nil {
+		t.Fatal(err)
+	}
+	if want := `SELECT * FROM "test"."soft" WHERE "id" = ? AND "deleted_at" IS NULL`; squash(e.last().query) != want {
+		t.Errorf("got query %q, want %q", squash(e.last().query), want)
+	}
+
+	if _, err := Get(&softModel{}, e, "id", 1, Unscoped()); err != nil {
+		t.Fatal(err)
+	}
+	if want := `SELECT * FROM "test"."soft" WHERE "id" = ?`; squash(e.last().query) != want {
+		t.Errorf("got unscoped query %q, want %q", squash(e.last().query), want)
+	}
+}
+
+func TestSoftDeleteRoundTrip(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.soft (id int PRIMARY KEY, name text, deleted_at timestamptz)`,
+	)
+	for i := 1; i <= 3; i++ {
+		if _, err := Save(&softModel{ID: i, Name: "one"}, tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Deleting marks the row, which is then hidden unless unscoped
+	if _, err := Delete(&softModel{ID: 1}, tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Get(&softModel{}, tx, "id", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want %v", err, ErrNotFound)
+	}
+	m := new(softModel)
+	if _, err := Get(m, tx, "id", 1, Unscoped()); err != nil {
+		t.Fatal(err)
+	}
+	if m.DeletedAt == nil {
+		t.Error("the deleted row wasn't marked")
+	}
+
+	// Deleting again leaves the row untouched
+	if res, err := Delete(&softModel{ID: 1}, tx); err != nil || res.RowsAffected() != 0 {
+		t.Errorf("got %v rows deleted and error %v, want none", res, err)
+	}
+
+	// DeleteWhere marks the matching rows, and ForceDeleteWhere removes them
+	if res, err := DeleteWhere(&softModel{}, tx, Where("id", Eq, 2)); err != nil || res.RowsAffected() != 1 {
+		t.Fatalf("got result %v and error %v, want 1 row", res, err)
+	}
+	ms, _, err := GetMany[*softModel](tx, "name", "one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 1 || ms[0].ID != 3 {
+		t.Errorf("got models %+v, want model 3", ms)
+	}
+	if res, err := ForceDeleteWhere(&softModel{}, tx, Where("name", Eq, "one")); err != nil || res.RowsAffected() != 3 {
+		t.Fatalf("got result %v and error %v, want 3 rows", res, err)
+	}
+	ms, _, err = GetMany[*softModel](tx, "name", "one", Unscoped())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 0 {
+		t.Errorf("got models %+v after forcing deletion, want none", ms)
+	}
+}
+
+func TestDeleteWhereQuerySoftDeletes(t *testing.T) {
+	q, _, err := createDeleteWhereQuery(&softModel{}, Where("name", Eq, "one"), new(queryOptions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE "test"."soft" SET "deleted_at" = now() WHERE "deleted_at" IS NULL AND ("name" = ?)`
+	if q = squash(q); q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+
+	q, _, err = createDeleteWhereQuery(&softModel{}, Where("name", Eq, "one"), newQueryOptions([]QueryOption{forced()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `DELETE FROM "test"."soft" WHERE ("name" = ?)`
+	if q = squash(q); q != want {
+		t.Errorf("got forced query %q, want %q", q, want)
+	}
+}
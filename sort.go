@@ -0,0 +1,78 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortableModel types declare the sorts that external callers, such as the
+// query parameters of an API request, may apply to their rows with ParseSort.
+// Columns that aren't declared can't be sorted by.
+type SortableModel interface {
+	KeyedModel
+
+	// The columns or expressions the model can be sorted by, keyed by the names
+	// callers use for them.
+	Sorts() map[string]string
+}
+
+// SortError is returned by ParseSort when a sort isn't allowed.
+type SortError struct {
+
+	// The name of the sort as given by the caller.
+	Name string
+
+	// A description of the problem.
+	Problem string
+}
+
+// Error returns a message describing the sort and its problem.
+func (e *SortError) Error() string {
+	return fmt.Sprintf("pgmodel: invalid sort %q: %s", e.Name, e.Problem)
+}
+
+// MARK: Exported functions
+
+// ParseSort converts the comma separated list of sort names, s, such as the
+// sort query parameter of a request, in to an ordering of pm's rows, e.g.
+//
+//	o, err := pgmodel.ParseSort(new(Bar), r.URL.Query().Get("sort"))
+//	...
+//	bs, _, err := pgmodel.GetMany[*Bar](t, "", nil, o)
+//
+// Each name is one declared by pm, sorted in ascending order, or prefixed with
+// "-" to sort in descending order, so that "-created_at,name" sorts by the
+// newest rows and then by name.
+//
+// A *SortError is returned for names that aren't declared by pm and names that
+// are given more than once. The option has no effect if s is empty.
+func ParseSort(pm SortableModel, s string) (QueryOption, error) {
+	ss := pm.Sorts()
+
+	var cs []string
+	seen := make(map[string]bool)
+	for _, n := range strings.Split(s, ",") {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+
+		dir := "ASC"
+		sn := n
+		if strings.HasPrefix(sn, "-") {
+			dir = "DESC"
+			sn = sn[1:]
+		}
+
+		c, ok := ss[sn]
+		if !ok {
+			return nil, &SortError{Name: n, Problem: "unknown sort"}
+		}
+		if seen[sn] {
+			return nil, &SortError{Name: n, Problem: "sorted by more than once"}
+		}
+		seen[sn] = true
+		cs = append(cs, c+" "+dir)
+	}
+	return WithOrderBy(cs...), nil
+}
@@ -0,0 +1,44 @@
+package pgmodel
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func (m *filteredModel) Sorts() map[string]string {
+	return map[string]string{
+		"id":   `"id"`,
+		"name": `lower("name")`,
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	opt, err := ParseSort(&filteredModel{}, "-name, id,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := newQueryOptions([]QueryOption{opt})
+	want := []orderClause{{column: `lower("name") DESC`}, {column: `"id" ASC`}}
+	if !reflect.DeepEqual(o.orderBy, want) {
+		t.Errorf("got ordering %+v, want %+v", o.orderBy, want)
+	}
+
+	// An empty list has no ordering
+	opt, err = ParseSort(&filteredModel{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := newQueryOptions([]QueryOption{opt}); len(o.orderBy) != 0 {
+		t.Errorf("got ordering %+v, want none", o.orderBy)
+	}
+}
+
+func TestParseSortErrors(t *testing.T) {
+	for _, s := range []string{"secret", "name,-name"} {
+		var se *SortError
+		if _, err := ParseSort(&filteredModel{}, s); !errors.As(err, &se) {
+			t.Errorf("%s: got error %v, want a *SortError", s, err)
+		}
+	}
+}
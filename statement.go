@@ -0,0 +1,72 @@
+package pgmodel
+
+import (
+	"reflect"
+	"sync"
+)
+
+// statementKey identifies a query created for a model type. The names and
+// scope of the model are part of the key so that models returning different
+// names or scopes for the same type don't share queries.
+type statementKey struct {
+	typ    reflect.Type
+	shape  string
+	schema string
+	table  string
+	scope  string
+}
+
+// statements caches the queries created by Get, Save and Delete keyed by
+// statementKey.
+var statements sync.Map
+
+// MARK: Non-exported functions
+
+// statement returns the query of the given shape, such as "get id", on pm's
+// table, creating it with create and caching it the first time it's needed.
+//
+// Queries are only cached if the options don't change their text beyond the
+// names and scope of the model, and a model type's columns are assumed not to
+// change. The key of a cached query is kept in the options so that its
+// prepared statement can be found.
+func (o *queryOptions) statement(pm TableDescriber, shape string, create func() (string, error)) (string, error) {
+	o.key = nil
+	if o.shaped() {
+		return create()
+	}
+
+	k := statementKey{
+		typ:    reflect.TypeOf(pm),
+		shape:  shape,
+		schema: o.schemaName(pm),
+		table:  o.tableName(pm),
+		scope:  o.scope(pm),
+	}
+	if q, ok := statements.Load(k); ok {
+		o.key = &k
+		return q.(string), nil
+	}
+
+	q, err := create()
+	if err != nil {
+		return "", err
+	}
+	statements.Store(k, q)
+	o.key = &k
+	return q, nil
+}
+
+// shaped returns true if the options change the text of queries beyond the
+// names and scope of the model.
+func (o *queryOptions) shaped() bool {
+	return len(o.orderBy) > 0 ||
+		len(o.searches) > 0 ||
+		len(o.conditions) > 0 ||
+		len(o.columns) > 0 ||
+		len(o.laterals) > 0 ||
+		o.limit > 0 ||
+		o.offset > 0 ||
+		o.maxRows > 0 ||
+		o.returning ||
+		o.constraint != ""
+}
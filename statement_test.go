@@ -0,0 +1,52 @@
+package pgmodel
+
+import "testing"
+
+func TestStatementCached(t *testing.T) {
+	calls := 0
+	create := func() (string, error) {
+		calls++
+		return "q", nil
+	}
+
+	o := new(queryOptions)
+	for i := 0; i < 2; i++ {
+		if q, err := o.statement(&testModel{}, "test shape", create); err != nil || q != "q" {
+			t.Fatalf("got %q, %v", q, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("created the query %d times, want 1", calls)
+	}
+	if o.key == nil || o.key.shape != "test shape" || o.key.table != "models" {
+		t.Errorf("got key %+v", o.key)
+	}
+
+	// Other tables of the same type have their own queries
+	o = newQueryOptions([]QueryOption{WithTable("models_next")})
+	if _, err := o.statement(&testModel{}, "test shape", create); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("created the query %d times, want 2", calls)
+	}
+}
+
+func TestStatementShaped(t *testing.T) {
+	calls := 0
+	create := func() (string, error) {
+		calls++
+		return "q", nil
+	}
+
+	// Queries changed by their options aren't cached
+	o := newQueryOptions([]QueryOption{WithLimit(1)})
+	for i := 0; i < 2; i++ {
+		if _, err := o.statement(&testModel{}, "test shaped", create); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 || o.key != nil {
+		t.Errorf("created the query %d times with key %+v, want 2 without a key", calls, o.key)
+	}
+}
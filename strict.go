@@ -0,0 +1,124 @@
+package pgmodel
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// RowCountError is returned when a query matches more rows than its operation
+// allows.
+type RowCountError struct {
+
+	// The operation that was performed.
+	Operation Operation
+
+	// The schema and table names of the operation's model.
+	Schema string
+	Table  string
+
+	// The number of rows matching the query.
+	Rows int
+
+	// The maximum number of rows the operation allows.
+	Max int
+
+	// Whether the operation expects exactly Max rows.
+	Exact bool
+}
+
+// Error returns a description of the mismatch.
+func (e *RowCountError) Error() string {
+	if e.Exact {
+		return fmt.Sprintf("pgmodel: %s on %s.%s matched %d rows but exactly %d are expected", e.Operation, e.Schema, e.Table, e.Rows, e.Max)
+	}
+	return fmt.Sprintf("pgmodel: %s on %s.%s matched %d rows but at most %d are allowed", e.Operation, e.Schema, e.Table, e.Rows, e.Max)
+}
+
+// MARK: Exported functions
+
+// WithStrict makes Get return a *RowCountError, including the number of rows
+// that matched, instead of pg.ErrMultiRows when more than one row matches its
+// query.
+func WithStrict() QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.strict = true
+	})
+}
+
+// WithMaxRows makes GetMany, GetManyInto and GetManyMap return a
+// *RowCountError, including the number of rows that matched, when more than n
+// rows match their query. This catches queries that are accidentally
+// unbounded without reading every row they match.
+//
+// The destination's contents are undefined when the error is returned.
+//
+// DeleteWhere and UpdateWhere roll back their changes and return the error
+// when they affect more than n rows.
+func WithMaxRows(n int) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.maxRows = n
+	})
+}
+
+// MARK: Non-exported functions
+
+// limitClause returns the LIMIT and OFFSET clauses for the options, or an
+// empty string if no limit, offset or maximum number of rows was given. One
+// more row than the maximum is selected so that exceeding it can be detected.
+func (o *queryOptions) limitClause() string {
+	n := o.limit
+	if o.maxRows > 0 && (n <= 0 || o.maxRows < n) {
+		n = o.maxRows + 1
+	}
+
+	var cs []string
+	if n > 0 {
+		cs = append(cs, fmt.Sprintf("LIMIT %d", n))
+	}
+	if o.offset > 0 {
+		cs = append(cs, fmt.Sprintf("OFFSET %d", o.offset))
+	}
+	return strings.Join(cs, " ")
+}
+
+// checkRows returns a *RowCountError if the result, res, of the operation, op,
+// querying pm's table for the given queryKey and queryValue matched more rows
+// than the options allow. Otherwise res and err are returned.
+func (o *queryOptions) checkRows(op Operation, pm KeyedModel, t Executor, queryKey string, queryValue interface{}, res orm.Result, err error) (orm.Result, error) {
+	var max int
+	switch {
+	case op == OperationGet && o.strict && errors.Is(err, pg.ErrMultiRows):
+		max = 1
+	case op != OperationGet && err == nil && o.maxRows > 0 && res.RowsReturned() > o.maxRows:
+		max = o.maxRows
+	default:
+		return res, err
+	}
+
+	// Count the matching rows without the limit or ordering
+	c := *o
+	c.maxRows = 0
+	c.limit = 0
+	c.offset = 0
+	c.orderBy = nil
+	q, a, err := createGetQuery(pm, queryKey, queryValue, &c)
+	if err != nil {
+		return res, err
+	}
+
+	var n int
+	if _, err := t.QueryOneContext(o.context(), pg.Scan(&n), "SELECT count(*) FROM ("+q+") AS pgmodel_count", a...); err != nil {
+		return res, err
+	}
+	return res, &RowCountError{
+		Operation: op,
+		Schema:    o.schemaName(pm),
+		Table:     o.tableName(pm),
+		Rows:      n,
+		Max:       max,
+	}
+}
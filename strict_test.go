@@ -0,0 +1,63 @@
+package pgmodel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestLimitClause(t *testing.T) {
+	for _, c := range []struct {
+		o    queryOptions
+		want string
+	}{
+		{queryOptions{}, ""},
+		{queryOptions{limit: 10, offset: 20}, "LIMIT 10 OFFSET 20"},
+		{queryOptions{maxRows: 5}, "LIMIT 6"},
+		{queryOptions{limit: 10, maxRows: 5}, "LIMIT 6"},
+		{queryOptions{limit: 3, maxRows: 5}, "LIMIT 3"},
+	} {
+		if l := c.o.limitClause(); l != c.want {
+			t.Errorf("got %q for limit %d and maximum %d, want %q", l, c.o.limit, c.o.maxRows, c.want)
+		}
+	}
+}
+
+func TestWithMaxRows(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return testResult{returned: 3}, nil
+	}}
+	_, _, err := GetMany[*testModel](e, "name", "a", WithMaxRows(2), WithLimit(10))
+
+	var rce *RowCountError
+	if !errors.As(err, &rce) || rce.Operation != OperationGetMany || rce.Max != 2 {
+		t.Fatalf("got error %v, want a *RowCountError", err)
+	}
+
+	// The rows are counted without the limit
+	if q := squash(e.last().query); !strings.HasPrefix(q, "SELECT count(*) FROM (") || strings.Contains(q, "LIMIT") {
+		t.Errorf("got count query %q", q)
+	}
+}
+
+func TestWithStrict(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		if strings.HasPrefix(q, "SELECT count(*)") {
+			return testResult{returned: 1}, nil
+		}
+		return nil, pg.ErrMultiRows
+	}}
+
+	var rce *RowCountError
+	if _, err := Get(&testModel{}, e, "name", "a", WithStrict()); !errors.As(err, &rce) || rce.Max != 1 {
+		t.Errorf("got error %v, want a *RowCountError", err)
+	}
+
+	// Without the option, the driver's error is returned
+	if _, err := Get(&testModel{}, e, "name", "a"); !errors.Is(err, pg.ErrMultiRows) {
+		t.Errorf("got error %v, want pg.ErrMultiRows", err)
+	}
+}
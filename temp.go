@@ -0,0 +1,112 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// temps counts the temporary tables created so that their names are unique.
+var temps uint64
+
+// MARK: Exported functions
+
+// CreateTempTableLike creates a temporary table in the given transaction with
+// the same columns, defaults, constraints and indexes as pm's table, and
+// returns its name. The table is dropped when the transaction ends.
+//
+// Models can be staged in the table by passing WithTempTable to Save or
+// SaveAll, and then written to pm's table with MergeTempTable. For example,
+//
+//	tmp, err := pgmodel.CreateTempTableLike(pm, t)
+//	...
+//	_, err = pgmodel.SaveAll(pms, t, pgmodel.WithTempTable(tmp))
+//	...
+//	_, err = pgmodel.MergeTempTable(pm, t, tmp)
+func CreateTempTableLike(pm PGModel, t *pg.Tx) (string, error) {
+	return CreateTempTableLikeContext(context.Background(), pm, t)
+}
+
+// CreateTempTableLikeContext is identical to CreateTempTableLike but performs
+// its query with the context, ctx, so that it can be cancelled or given a
+// deadline.
+func CreateTempTableLikeContext(ctx context.Context, pm PGModel, t *pg.Tx) (string, error) {
+	name := fmt.Sprintf("pgmodel_tmp_%s_%d", pm.TableName(), atomic.AddUint64(&temps, 1))
+	_, err := t.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TEMPORARY TABLE %s (LIKE %s INCLUDING ALL) ON COMMIT DROP`,
+		quoteIdent(name),
+		new(queryOptions).qualifiedName(pm),
+	))
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// WithTempTable directs an operation at the temporary table, name, returned by
+// CreateTempTableLike instead of the model's table.
+func WithTempTable(name string) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		schema := "pg_temp"
+		o.schema = &schema
+		o.table = name
+	})
+}
+
+// MergeTempTable upserts every row of the temporary table, name, in to pm's
+// table in the given transaction using a single INSERT ... SELECT statement.
+func MergeTempTable(pm PGModel, t *pg.Tx, name string) (*Result, error) {
+	return MergeTempTableContext(context.Background(), pm, t, name)
+}
+
+// MergeTempTableContext is identical to MergeTempTable but performs its query
+// with the context, ctx, so that it can be cancelled or given a deadline.
+func MergeTempTableContext(ctx context.Context, pm PGModel, t *pg.Tx, name string) (*Result, error) {
+	q := createMergeTempQuery(pm, name)
+	defer invalidateCaches(pm)
+	res, err := runContext(ctx, OperationSaveAll, pm, t, func() (orm.Result, error) {
+		return t.ExecContext(ctx, q)
+	})
+	return newResult(res, q), err
+}
+
+// MARK: Non-exported functions
+
+// createMergeTempQuery creates a query upserting the rows of the temporary
+// table, name, in to pm's table.
+func createMergeTempQuery(pm PGModel, name string) string {
+	// Get everything once
+	npkc := pm.NonPKColumns()
+	c := columns(pm)
+	tn := new(queryOptions).tableName(pm)
+
+	// Create arrays to join
+	var sl, sm []string
+	for _, u := range c {
+		sl = append(sl, insertExpr(pm, u, quoteIdent(u)))
+	}
+	for _, u := range npkc {
+		sm = append(sm, fmt.Sprintf("%s = %s", quoteIdent(u), excludedValue(pm, tn, u)))
+	}
+
+	// Create the query
+	return fmt.Sprintf(
+		`INSERT INTO %s (%s)
+		SELECT %s FROM %s
+		ON CONFLICT (%s)
+		DO UPDATE
+		SET %s
+		%s`,
+		new(queryOptions).qualifiedName(pm),
+		quoteList(c),
+		strings.Join(sl, ", "),
+		qualify("pg_temp", name),
+		quoteList(primaryKeys(pm)),
+		strings.Join(sm, ", "),
+		new(queryOptions).scopedWhere(pm, ""),
+	)
+}
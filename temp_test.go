@@ -0,0 +1,59 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestCreateMergeTempQuery(t *testing.T) {
+	q := squash(createMergeTempQuery(&testModel{}, "pgmodel_tmp_models_1"))
+	for _, want := range []string{
+		`INSERT INTO "test"."models" ("id", "name", "tags")`,
+		`FROM "pg_temp"."pgmodel_tmp_models_1"`,
+		`ON CONFLICT ("id") DO UPDATE`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("got query %q, want it to contain %q", q, want)
+		}
+	}
+}
+
+func TestMergeTempTable(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx, `INSERT INTO test.models (id, name) VALUES (1, 'old')`)
+
+	tmp, err := CreateTempTableLike(&testModel{}, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pms := []PGModel{&testModel{ID: 1, Name: "new"}, &testModel{ID: 2, Name: "two"}}
+	if _, err := SaveAll(pms, tx, WithTempTable(tmp)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Staged rows aren't in the model's table until they're merged
+	var n int
+	if _, err := tx.QueryOne(pg.Scan(&n), `SELECT count(*) FROM test.models WHERE name <> 'old'`); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("got %d staged rows in the model's table, want 0", n)
+	}
+
+	res, err := MergeTempTable(&testModel{}, tx, tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsAffected() != 2 {
+		t.Errorf("got %d rows affected, want 2", res.RowsAffected())
+	}
+	if _, err := tx.QueryOne(pg.Scan(&n), `SELECT count(*) FROM test.models WHERE name IN ('new', 'two')`); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d merged rows, want 2", n)
+	}
+}
@@ -0,0 +1,94 @@
+package pgmodel
+
+import (
+	"context"
+	"time"
+)
+
+// Throttle limits the rate at which a bulk operation writes to the database,
+// so that large data fixes don't saturate the primary or its replicas.
+//
+// Both limits may be set, in which case the operation satisfies both.
+type Throttle struct {
+
+	// The maximum number of rows written per second. Zero means no limit.
+	RowsPerSecond float64
+
+	// The maximum number of chunks written per Interval. Zero means no limit.
+	Chunks   int
+	Interval time.Duration
+}
+
+// throttler applies a throttle to the chunks of a single operation.
+type throttler struct {
+	th     Throttle
+	start  time.Time
+	rows   int
+	chunks []time.Time
+}
+
+// MARK: Exported functions
+
+// WithThrottle limits the rate at which SaveAll writes its chunks.
+func WithThrottle(th Throttle) QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.throttle = th
+	})
+}
+
+// MARK: Non-exported functions
+
+// newThrottler returns a throttler for an operation starting now.
+func newThrottler(th Throttle) *throttler {
+	return &throttler{
+		th:    th,
+		start: time.Now(),
+	}
+}
+
+// wait blocks until the next chunk may be written or ctx is done.
+func (t *throttler) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var d time.Duration
+
+	// Wait until the rows written so far fit the row rate
+	if t.th.RowsPerSecond > 0 {
+		due := t.start.Add(time.Duration(float64(t.rows) / t.th.RowsPerSecond * float64(time.Second)))
+		d = time.Until(due)
+	}
+
+	// Wait until the oldest chunk in the window leaves it
+	if t.th.Chunks > 0 && t.th.Interval > 0 && len(t.chunks) >= t.th.Chunks {
+		oldest := t.chunks[len(t.chunks)-t.th.Chunks]
+		if cd := time.Until(oldest.Add(t.th.Interval)); cd > d {
+			d = cd
+		}
+	}
+
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// done records that a chunk of n rows was written.
+func (t *throttler) done(n int) {
+	t.rows += n
+	if t.th.Chunks > 0 {
+		t.chunks = append(t.chunks, time.Now())
+		if len(t.chunks) > t.th.Chunks {
+			t.chunks = t.chunks[1:]
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestThrottlerRows(t *testing.T) {
+	th := newThrottler(Throttle{RowsPerSecond: 1000})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := th.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		th.done(20)
+	}
+
+	// 40 rows were written before the last wait
+	if d := time.Since(start); d < 35*time.Millisecond {
+		t.Errorf("finished after %v, want at least 40ms", d)
+	}
+}
+
+func TestThrottlerChunks(t *testing.T) {
+	th := newThrottler(Throttle{Chunks: 2, Interval: 30 * time.Millisecond})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := th.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		th.done(1)
+	}
+	if d := time.Since(start); d < 25*time.Millisecond {
+		t.Errorf("wrote 3 chunks in %v, want at least 30ms", d)
+	}
+	if len(th.chunks) != 2 {
+		t.Errorf("kept %d chunk times, want 2", len(th.chunks))
+	}
+}
+
+func TestThrottlerCancelled(t *testing.T) {
+	th := newThrottler(Throttle{RowsPerSecond: 1})
+	th.done(100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := th.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,128 @@
+package pgmodel
+
+import "fmt"
+
+// TimestampedModel types are models whose rows record when they were created
+// and last written, e.g.
+//
+//	func (b *Bar) TimestampColumns() (string, string) {
+//		return "created_at", "updated_at"
+//	}
+//
+// The functions that write models, such as Save, SaveAll, CopyFrom, Insert,
+// SaveIf and Update, set the created column to the time of the transaction
+// when a row is inserted, unless the model's value is non-zero, and never
+// change it afterwards. They set the updated column to the time of the
+// transaction on every write, ignoring the model's value, even if Update isn't
+// given the column. UpdateWhere sets the updated column the same way. Use
+// WithReturning to read the values that were written back in to the model.
+type TimestampedModel interface {
+	PGModel
+
+	// The non-primary key columns holding the times rows were created and last
+	// written. Either may be empty if the table doesn't have the column.
+	TimestampColumns() (created string, updated string)
+}
+
+// MARK: Non-exported functions
+
+// timestampColumns returns pm's created and updated columns, which are empty
+// if pm isn't a TimestampedModel.
+func timestampColumns(pm PGModel) (string, string) {
+	if tm, ok := pm.(TimestampedModel); ok {
+		return tm.TimestampColumns()
+	}
+	return "", ""
+}
+
+// stampValues returns a copy of pm's converted non-primary key values, npkv,
+// with the values of its created and updated columns replaced by nil if the
+// database should set them to the time of the transaction.
+func stampValues(pm PGModel, npkv []interface{}) []interface{} {
+	cc, uc := timestampColumns(pm)
+	if cc == "" && uc == "" {
+		return npkv
+	}
+
+	sv := append([]interface{}{}, npkv...)
+	vs := pm.NonPKValues()
+	for i, c := range pm.NonPKColumns() {
+		if c == uc || c == cc && isEmpty(vs[i]) {
+			sv[i] = nil
+		}
+	}
+	return sv
+}
+
+// insertValues returns pm's converted values in the order of its columns, with
+// the values of its timestamp columns replaced as by stampValues.
+func insertValues(pm PGModel) []interface{} {
+	return append(primaryKeyValues(pm), stampValues(pm, convertVariables(pm))...)
+}
+
+// stampColumns returns the columns, cs, set by an update of pm's row, along
+// with pm's updated column if it isn't one of them.
+func stampColumns(pm PGModel, cs []string) []string {
+	_, uc := timestampColumns(pm)
+	if uc == "" {
+		return cs
+	}
+	for _, c := range cs {
+		if c == uc {
+			return cs
+		}
+	}
+	return append(append([]string{}, cs...), uc)
+}
+
+// insertValue returns the expression inserted in to pm's column, c, which is
+// the column's parameter or, for timestamp columns, the time of the
+// transaction if the parameter is NULL.
+func insertValue(pm PGModel, c string) string {
+	cc, uc := timestampColumns(pm)
+	if c != "" && (c == cc || c == uc) {
+		return "COALESCE(?::timestamptz, now())"
+	}
+	return "?"
+}
+
+// insertExpr returns the expression inserted in to pm's column, c, by an
+// INSERT ... SELECT statement selecting the expression, e, which is e or, for
+// timestamp columns, the time of the transaction if e is NULL.
+func insertExpr(pm PGModel, c string, e string) string {
+	cc, uc := timestampColumns(pm)
+	if c != "" && (c == cc || c == uc) {
+		return fmt.Sprintf("COALESCE(%s, now())", e)
+	}
+	return e
+}
+
+// excludedValue returns the expression pm's column, c, is set to by the
+// DO UPDATE action of an upsert in to the table, tn, which is the value of the
+// excluded row or, for created columns, the existing row's value if it has
+// one.
+func excludedValue(pm PGModel, tn string, c string) string {
+	cc, _ := timestampColumns(pm)
+	if c != "" && c == cc {
+		return fmt.Sprintf("COALESCE(%s.%s, EXCLUDED.%s)", quoteIdent(tn), quoteIdent(c), quoteIdent(c))
+	}
+	return "EXCLUDED." + quoteIdent(c)
+}
+
+// setValue returns the expression pm's column, c, is set to by updates of the
+// table, tn, which is the column's parameter or, for timestamp columns, the
+// time of the transaction if the parameter is NULL. Created columns keep their
+// existing value.
+func setValue(pm PGModel, tn string, c string) string {
+	cc, uc := timestampColumns(pm)
+	switch {
+	case c == "":
+		return "?"
+	case c == cc:
+		return fmt.Sprintf("COALESCE(%s.%s, ?::timestamptz, now())", quoteIdent(tn), quoteIdent(c))
+	case c == uc:
+		return "COALESCE(?::timestamptz, now())"
+	default:
+		return "?"
+	}
+}
@@ -0,0 +1,160 @@
+package pgmodel
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// stampedModel is a model of the test.stamped table with timestamp columns.
+type stampedModel struct {
+	Base[stampedModel] `pgmodel:"test.stamped"`
+	ID                 int       `pg:"id,pk"`
+	Name               string    `pg:"name"`
+	CreatedAt          time.Time `pg:"created_at"`
+	UpdatedAt          time.Time `pg:"updated_at"`
+}
+
+func (m *stampedModel) TimestampColumns() (string, string) {
+	return "created_at", "updated_at"
+}
+
+func TestStampValues(t *testing.T) {
+	m := &stampedModel{ID: 1, Name: "one", UpdatedAt: time.Now()}
+	v := stampValues(m, convertVariables(m))
+	if v[0] != "one" || v[1] != nil || v[2] != nil {
+		t.Fatalf("got values %v, want [one <nil> <nil>]", v)
+	}
+
+	m.CreatedAt = time.Now()
+	if v := stampValues(m, convertVariables(m)); v[1] == nil || v[2] != nil {
+		t.Fatalf("got values %v, want a created value and no updated value", v)
+	}
+}
+
+func TestSaveQueryStamps(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Save(&stampedModel{ID: 1, Name: "one", UpdatedAt: time.Now()}, e); err != nil {
+		t.Fatal(err)
+	}
+
+	q := squash(e.last().query)
+	for _, want := range []string{
+		`VALUES (?, ?, COALESCE(?::timestamptz, now()), COALESCE(?::timestamptz, now()))`,
+		`"created_at" = COALESCE("stamped"."created_at", ?::timestamptz, now())`,
+		`"updated_at" = COALESCE(?::timestamptz, now())`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query %q doesn't contain %q", q, want)
+		}
+	}
+	if p := e.last().params; len(p) != 8 || p[2] != nil || p[3] != nil || p[5] != nil || p[6] != nil {
+		t.Errorf("got parameters %v, want nil created and updated values", p)
+	}
+}
+
+func TestTimestampsRoundTrip(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.stamped (id int PRIMARY KEY, name text, created_at timestamptz NOT NULL, updated_at timestamptz NOT NULL)`,
+	)
+
+	// The database sets both columns when the row is inserted
+	m := &stampedModel{ID: 1, Name: "one"}
+	if _, err := Save(m, tx, WithReturning()); err != nil {
+		t.Fatal(err)
+	}
+	if m.CreatedAt.IsZero() || m.UpdatedAt.IsZero() {
+		t.Fatalf("got created at %s and updated at %s, want both set", m.CreatedAt, m.UpdatedAt)
+	}
+	created := m.CreatedAt
+
+	// Later writes keep the created column and ignore the model's updated value
+	later := &stampedModel{ID: 1, Name: "two", UpdatedAt: created.Add(-time.Hour)}
+	if _, err := Save(later, tx, WithReturning()); err != nil {
+		t.Fatal(err)
+	}
+	if !later.CreatedAt.Equal(created) {
+		t.Errorf("got created at %s, want %s", later.CreatedAt, created)
+	}
+	if later.UpdatedAt.Before(created) {
+		t.Errorf("got updated at %s before created at %s", later.UpdatedAt, created)
+	}
+
+	// A created value given by the model is kept when the row is inserted
+	given := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := Insert(&stampedModel{ID: 2, Name: "three", CreatedAt: given}, tx); err != nil {
+		t.Fatal(err)
+	}
+	got := new(stampedModel)
+	if _, err := Get(got, tx, "id", 2); err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(given) {
+		t.Errorf("got created at %s, want %s", got.CreatedAt, given)
+	}
+}
+
+func TestSaveAllQueryStamps(t *testing.T) {
+	q := squash(createSaveAllQuery(&stampedModel{}, 2, new(queryOptions)))
+	for _, want := range []string{
+		`VALUES (?, ?, COALESCE(?::timestamptz, now()), COALESCE(?::timestamptz, now())), (?, ?, COALESCE(?::timestamptz, now()), COALESCE(?::timestamptz, now()))`,
+		`"created_at" = COALESCE("stamped"."created_at", EXCLUDED."created_at")`,
+		`"updated_at" = EXCLUDED."updated_at"`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query %q doesn't contain %q", q, want)
+		}
+	}
+}
+
+func TestUpdateColumnsStampsUpdated(t *testing.T) {
+	m := &stampedModel{ID: 1, Name: "one", UpdatedAt: time.Now()}
+	e := new(testExecutor)
+	if _, err := Update(m, e, "name"); err != nil {
+		t.Fatal(err)
+	}
+
+	q := squash(e.last().query)
+	if !strings.Contains(q, `SET "name" = ?, "updated_at" = COALESCE(?::timestamptz, now())`) {
+		t.Errorf("got query %q", q)
+	}
+	if p := e.last().params; len(p) != 3 || p[1] != nil {
+		t.Errorf("got parameters %v, want a nil updated value", p)
+	}
+}
+
+func TestSaveIfStamps(t *testing.T) {
+	m := &stampedModel{ID: 1, Name: "one", UpdatedAt: time.Now()}
+	e := new(testExecutor)
+	if _, _, err := SaveIf(m, e, "name", "zero"); err != nil {
+		t.Fatal(err)
+	}
+	if p := e.last().params; len(p) != 5 || p[1] != nil || p[2] != nil {
+		t.Errorf("got parameters %v, want nil created and updated values", p)
+	}
+}
+
+func TestUpdateWhereQueryStamps(t *testing.T) {
+	q, a, err := createUpdateWhereQuery(&stampedModel{}, Where("name", Eq, "one"), map[string]interface{}{
+		"name":       "two",
+		"updated_at": time.Time{},
+	}, new(queryOptions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q = squash(q); !strings.Contains(q, `SET "name" = ?, "updated_at" = now()`) {
+		t.Errorf("got query %q", q)
+	}
+	if len(a) != 2 || a[0] != "two" || a[1] != "one" {
+		t.Errorf("got parameters %v, want [two one]", a)
+	}
+}
+
+func TestMergeTempQueryStamps(t *testing.T) {
+	q := squash(createMergeTempQuery(&stampedModel{}, "tmp"))
+	if want := `SELECT "id", "name", COALESCE("created_at", now()), COALESCE("updated_at", now()) FROM "pg_temp"."tmp"`; !strings.Contains(q, want) {
+		t.Errorf("query %q doesn't contain %q", q, want)
+	}
+}
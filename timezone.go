@@ -0,0 +1,184 @@
+package pgmodel
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimeBinding controls how time.Time values are bound to queries.
+type TimeBinding int
+
+const (
+	// BindUTC binds times in UTC. timestamp columns store the UTC wall clock of
+	// the time and are read back in UTC. This is go-pg's default behavior.
+	BindUTC TimeBinding = iota
+
+	// BindPreserveOffset binds times with their own offset. timestamptz columns
+	// store the same instant as with BindUTC, but timestamp columns store the
+	// wall clock of the time in its own location, and are read back as wall
+	// clocks in the policy's location.
+	BindPreserveOffset
+)
+
+// TimePolicy controls how time.Time values are written to and read from the
+// database.
+type TimePolicy struct {
+
+	// How times are bound to Save queries.
+	Binding TimeBinding
+
+	// The location that times scanned in to models by Get and GetMany are
+	// converted to. timestamptz values are scanned with the offset of the
+	// session's TimeZone and timestamp values are scanned as UTC wall clocks, so
+	// setting a location gives both column types a consistent representation.
+	//
+	// Fields are read as timestamp columns if their pg tag gives the column's
+	// type, e.g. `pg:"starts_at,type:timestamp"`. With BindPreserveOffset, their
+	// wall clocks are read in the location, so times in the location are read
+	// back as they were saved. Otherwise they're converted from UTC.
+	//
+	// A nil location leaves scanned times unchanged.
+	Location *time.Location
+}
+
+// TimePolicyModel types are models with their own time policy, which is used
+// instead of the policy set by SetTimePolicy to bind their times and to read
+// the times scanned in to them.
+type TimePolicyModel interface {
+	TimePolicy() TimePolicy
+}
+
+// timestamptzFormat is the format used to bind times with their own offset.
+const timestamptzFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// timePolicy is the policy set by SetTimePolicy.
+var timePolicy struct {
+	sync.RWMutex
+	p TimePolicy
+}
+
+// MARK: Exported functions
+
+// SetTimePolicy sets the policy used to bind and read time.Time values of
+// models that aren't TimePolicyModel types.
+func SetTimePolicy(p TimePolicy) {
+	timePolicy.Lock()
+	defer timePolicy.Unlock()
+	timePolicy.p = p
+}
+
+// MARK: Non-exported functions
+
+// currentTimePolicy returns the policy set by SetTimePolicy.
+func currentTimePolicy() TimePolicy {
+	timePolicy.RLock()
+	defer timePolicy.RUnlock()
+	return timePolicy.p
+}
+
+// modelTimePolicy returns pm's policy if it's a TimePolicyModel, or the policy
+// set by SetTimePolicy otherwise.
+func modelTimePolicy(pm interface{}) TimePolicy {
+	if m, ok := pm.(TimePolicyModel); ok {
+		return m.TimePolicy()
+	}
+	return currentTimePolicy()
+}
+
+// bindTime returns the value to bind for v if it is a time.Time or *time.Time,
+// and whether it was one, using pm's policy.
+func bindTime(pm interface{}, v interface{}) (interface{}, bool) {
+	var tm time.Time
+	switch u := v.(type) {
+	case time.Time:
+		tm = u
+	case *time.Time:
+		if u == nil {
+			return v, true
+		}
+		tm = *u
+	default:
+		return v, false
+	}
+
+	if modelTimePolicy(pm).Binding == BindPreserveOffset {
+		return tm.Format(timestamptzFormat), true
+	}
+	return tm.UTC(), true
+}
+
+// normalizeTimes converts the time.Time fields of the struct pointed to by v,
+// or of the structs in the slice it points to, using each model's policy.
+func normalizeTimes(v interface{}) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return
+	}
+	normalizeValue(rv.Elem(), currentTimePolicy(), false)
+}
+
+// normalizeValue converts the time.Time values in rv using the policy, p, of
+// the model they're in. wall is true if rv is the field of a timestamp column.
+func normalizeValue(rv reflect.Value, p TimePolicy, wall bool) {
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			normalizeValue(rv.Elem(), p, wall)
+		}
+	case reflect.Struct:
+		if rv.Type() == timeType {
+			if rv.CanSet() && p.Location != nil {
+				rv.Set(reflect.ValueOf(normalizeTime(rv.Interface().(time.Time), p, wall)))
+			}
+			return
+		}
+		if rv.CanAddr() {
+			if m, ok := rv.Addr().Interface().(TimePolicyModel); ok {
+				p = m.TimePolicy()
+			}
+		}
+		rt := rv.Type()
+		for i := 0; i < rv.NumField(); i++ {
+			if f := rv.Field(i); f.CanSet() {
+				normalizeValue(f, p, isTimestampField(rt.Field(i)))
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < rv.Len(); i++ {
+			normalizeValue(rv.Index(i), p, wall)
+		}
+	case reflect.Interface:
+		if !rv.IsNil() && rv.Elem().Kind() == reflect.Ptr {
+			normalizeValue(rv.Elem(), p, wall)
+		}
+	}
+}
+
+// normalizeTime returns the scanned time, tm, in the policy's location. wall is
+// true if tm was scanned from a timestamp column.
+func normalizeTime(tm time.Time, p TimePolicy, wall bool) time.Time {
+	if wall && p.Binding == BindPreserveOffset {
+		// The column holds the wall clock the time was saved with, which go-pg
+		// scans as UTC
+		y, mo, d := tm.Date()
+		h, mi, s := tm.Clock()
+		return time.Date(y, mo, d, h, mi, s, tm.Nanosecond(), p.Location)
+	}
+	return tm.In(p.Location)
+}
+
+// isTimestampField returns whether the field's pg tag gives its column's type
+// as timestamp without time zone.
+func isTimestampField(f reflect.StructField) bool {
+	_, opts := tagOptions(f.Tag.Get("pg"))
+	for _, o := range opts {
+		if !strings.HasPrefix(o, "type:") {
+			continue
+		}
+		t := strings.ToLower(strings.Trim(strings.TrimPrefix(o, "type:"), `'"`))
+		return strings.HasPrefix(t, "timestamp") && !strings.Contains(t, "tz") && !strings.Contains(t, "with time zone")
+	}
+	return false
+}
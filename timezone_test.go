@@ -0,0 +1,133 @@
+package pgmodel
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestBindTime(t *testing.T) {
+	defer SetTimePolicy(TimePolicy{})
+
+	tm := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 2*60*60))
+	if v, ok := bindTime(nil, tm); !ok || v != tm.UTC() {
+		t.Errorf("got %v, %v, want the time in UTC", v, ok)
+	}
+
+	SetTimePolicy(TimePolicy{Binding: BindPreserveOffset})
+	if v, ok := bindTime(nil, &tm); !ok || v != "2024-01-02 03:04:05+02:00" {
+		t.Errorf("got %v, %v, want the time with its offset", v, ok)
+	}
+	if v, ok := bindTime(nil, (*time.Time)(nil)); !ok || v != (*time.Time)(nil) {
+		t.Errorf("got %v, %v, want a nil time", v, ok)
+	}
+	if _, ok := bindTime(nil, "2024-01-02"); ok {
+		t.Error("bound a string as a time")
+	}
+}
+
+func TestNormalizeTimes(t *testing.T) {
+	defer SetTimePolicy(TimePolicy{})
+
+	loc := time.FixedZone("test", -5*60*60)
+	SetTimePolicy(TimePolicy{Location: loc})
+
+	tm := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ms := []*stampedModel{{CreatedAt: tm}, {UpdatedAt: tm}}
+	normalizeTimes(&ms)
+	if ms[0].CreatedAt.Location() != loc || ms[1].UpdatedAt.Location() != loc {
+		t.Errorf("got %v and %v, want times in %v", ms[0].CreatedAt, ms[1].UpdatedAt, loc)
+	}
+	if !ms[0].CreatedAt.Equal(tm) {
+		t.Errorf("got %v, want the same instant as %v", ms[0].CreatedAt, tm)
+	}
+}
+
+// meetingModel is a model of the test.meetings table, whose times are stored
+// as wall clocks in Berlin time.
+type meetingModel struct {
+	Base[meetingModel] `pgmodel:"test.meetings"`
+	ID                 int       `pg:"id,pk"`
+	StartsAt           time.Time `pg:"starts_at,type:timestamp"`
+	CreatedAt          time.Time `pg:"created_at"`
+}
+
+// berlin is a fixed stand-in for Europe/Berlin in winter, so that the tests
+// don't depend on the time zone database.
+var berlin = time.FixedZone("CET", 60*60)
+
+func (m *meetingModel) TimePolicy() TimePolicy {
+	return TimePolicy{Binding: BindPreserveOffset, Location: berlin}
+}
+
+func TestIsTimestampField(t *testing.T) {
+	for tag, want := range map[string]bool{
+		`pg:"a,type:timestamp"`:                     true,
+		`pg:"a,type:timestamp(3)"`:                  true,
+		`pg:"a,type:'timestamp without time zone'"`: true,
+		`pg:"a,type:timestamptz"`:                   false,
+		`pg:"a,type:'timestamp with time zone'"`:    false,
+		`pg:"a"`:                                    false,
+	} {
+		f := reflect.StructField{Name: "A", Tag: reflect.StructTag(tag)}
+		if got := isTimestampField(f); got != want {
+			t.Errorf("got %t for %s, want %t", got, tag, want)
+		}
+	}
+}
+
+func TestModelTimePolicy(t *testing.T) {
+	// The model's policy is used instead of the global one
+	tm := time.Date(2024, 1, 2, 3, 4, 5, 0, berlin)
+	if v, ok := bindTime(&meetingModel{}, tm); !ok || v != "2024-01-02 03:04:05+01:00" {
+		t.Errorf("got %v, %v, want the time with its offset", v, ok)
+	}
+
+	// go-pg scans timestamp columns as UTC wall clocks, which are read in the
+	// model's location, and timestamptz columns as instants, which are
+	// converted to it
+	m := &meetingModel{
+		StartsAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		CreatedAt: time.Date(2024, 1, 2, 2, 4, 5, 0, time.UTC),
+	}
+	ms := []*meetingModel{m}
+	normalizeTimes(&ms)
+	if !m.StartsAt.Equal(tm) || m.StartsAt.Location() != berlin {
+		t.Errorf("got start %v, want %v", m.StartsAt, tm)
+	}
+	if !m.CreatedAt.Equal(tm) || m.CreatedAt.Location() != berlin {
+		t.Errorf("got creation %v, want %v", m.CreatedAt, tm)
+	}
+}
+
+func TestTimePolicyRoundTrip(t *testing.T) {
+	tx := testTx(t)
+	testExec(t, tx,
+		`CREATE SCHEMA IF NOT EXISTS test`,
+		`CREATE TABLE test.meetings (id int PRIMARY KEY, starts_at timestamp, created_at timestamptz)`,
+	)
+
+	tm := time.Date(2024, 1, 2, 3, 4, 5, 0, berlin)
+	if _, err := Save(&meetingModel{ID: 1, StartsAt: tm, CreatedAt: tm}, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The wall clock is stored as it was given
+	var wall string
+	if _, err := tx.QueryOne(pg.Scan(&wall), `SELECT starts_at::text FROM test.meetings`); err != nil {
+		t.Fatal(err)
+	}
+	if wall != "2024-01-02 03:04:05" {
+		t.Errorf("got wall clock %s, want 2024-01-02 03:04:05", wall)
+	}
+
+	m, _, err := GetOne[*meetingModel](tx, "id", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.StartsAt.Equal(tm) || !m.CreatedAt.Equal(tm) {
+		t.Errorf("got %v and %v, want %v", m.StartsAt, m.CreatedAt, tm)
+	}
+}
@@ -0,0 +1,114 @@
+package pgmodel
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+func TestSnapshotChanged(t *testing.T) {
+	m := &testModel{ID: 1, Name: "one", Tags: []string{"a", "b"}}
+	s := TakeSnapshot(m)
+	if cs := s.Changed(); len(cs) != 0 {
+		t.Fatalf("got changed columns %v for an unmodified model", cs)
+	}
+
+	m.Tags[1] = "c"
+	if cs := s.Changed(); !reflect.DeepEqual(cs, []string{"tags"}) {
+		t.Fatalf("got changed columns %v, want [tags]", cs)
+	}
+
+	m.Tags[1] = "b"
+	m.Name = "two"
+	if cs := s.Changed(); !reflect.DeepEqual(cs, []string{"name"}) {
+		t.Fatalf("got changed columns %v, want [name]", cs)
+	}
+}
+
+func TestSnapshotSave(t *testing.T) {
+	m := &testModel{ID: 1, Name: "one", Tags: []string{"a"}}
+	s := TakeSnapshot(m)
+	e := new(testExecutor)
+
+	if _, err := s.Save(e); err != nil {
+		t.Fatal(err)
+	}
+	if n := e.count(); n != 0 {
+		t.Fatalf("got %d queries saving an unmodified model, want 0", n)
+	}
+
+	m.Name = "two"
+	if _, err := s.Save(e); err != nil {
+		t.Fatal(err)
+	}
+	q := squash(e.last().query)
+	if !reflect.DeepEqual(e.last().params, []interface{}{"two", 1}) {
+		t.Errorf("got parameters %v", e.last().params)
+	}
+	if want := `UPDATE "test"."models" SET "name" = ? WHERE "models"."id" = ?`; q != want {
+		t.Errorf("got query %q, want %q", q, want)
+	}
+	if cs := s.Changed(); len(cs) != 0 {
+		t.Errorf("got changed columns %v after saving", cs)
+	}
+}
+
+func TestSnapshotBytes(t *testing.T) {
+	m := &bytesModel{ID: 1, Data: []byte{1, 2}}
+	s := TakeSnapshot(m)
+
+	// Changes to the elements of byte slices are detected
+	m.Data[0] = 3
+	if cs := s.Changed(); !reflect.DeepEqual(cs, []string{"data"}) {
+		t.Fatalf("got changed columns %v, want [data]", cs)
+	}
+}
+
+func TestSnapshotSaveError(t *testing.T) {
+	m := &testModel{ID: 1, Name: "one"}
+	s := TakeSnapshot(m)
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return nil, errors.New("failed")
+	}}
+
+	// The changes are kept so that they're written by the next save
+	m.Name = "two"
+	if _, err := s.Save(e); err == nil {
+		t.Fatal("expected the executor's error")
+	}
+	if cs := s.Changed(); !reflect.DeepEqual(cs, []string{"name"}) {
+		t.Errorf("got changed columns %v after failing to save, want [name]", cs)
+	}
+}
+
+func TestSnapshotSaveRoundTrip(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx, `INSERT INTO test.models (id, name, tags) VALUES (1, 'one', '{a}')`)
+
+	m := new(testModel)
+	if _, err := Get(m, tx, "id", 1); err != nil {
+		t.Fatal(err)
+	}
+	s := TakeSnapshot(m)
+	if cs := s.Changed(); len(cs) != 0 {
+		t.Fatalf("got changed columns %v for a model that was just read", cs)
+	}
+
+	// A concurrent write to another column isn't overwritten
+	testExec(t, tx, `UPDATE test.models SET tags = '{b}' WHERE id = 1`)
+	m.Name = "two"
+	if _, err := s.Save(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	got := new(testModel)
+	if _, err := Get(got, tx, "id", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "two" || !reflect.DeepEqual(got.Tags, []string{"b"}) {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+const (
+	// serializationFailure and deadlockDetected are the SQLSTATEs of the
+	// transaction failures that succeed when retried.
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+
+	// maxTxAttempts is the number of times RunInTx attempts a transaction.
+	maxTxAttempts = 10
+
+	// minTxBackoff and maxTxBackoff bound the delay before each retry of a
+	// transaction.
+	minTxBackoff = 10 * time.Millisecond
+	maxTxBackoff = time.Second
+)
+
+// MARK: Exported functions
+
+// RunInTx calls fn in a transaction on db, committing the transaction if fn
+// returns nil and rolling it back if fn returns an error or panics.
+//
+// If the transaction fails with a serialization failure or a deadlock, which
+// the database expects to be retried, it's rolled back and attempted again
+// after a jittered exponential backoff, so fn must be safe to call more than
+// once and shouldn't have effects outside of the transaction. The last error
+// is returned if every attempt fails, and the context's error is returned if
+// ctx is done before an attempt or while waiting to retry. Each transaction is
+// begun with ctx.
+func RunInTx(ctx context.Context, db *pg.DB, fn func(t *pg.Tx) error) error {
+	var err error
+	for i := 0; i < maxTxAttempts; i++ {
+		if i > 0 {
+			if serr := sleep(ctx, txBackoff(i)); serr != nil {
+				return serr
+			}
+		}
+		if cerr := ctx.Err(); cerr != nil {
+			return cerr
+		}
+
+		err = runTx(ctx, db, fn)
+		if !isRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// MARK: Non-exported functions
+
+// runTx calls fn in a transaction begun on db with ctx, committing the
+// transaction if fn returns nil and rolling it back otherwise.
+func runTx(ctx context.Context, db *pg.DB, fn func(t *pg.Tx) error) error {
+	t, err := BeginContext(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = t.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(t); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+	return t.Commit()
+}
+
+// isRetryable returns whether err is a transaction failure that may succeed
+// if the transaction is retried.
+func isRetryable(err error) bool {
+	var pe pg.Error
+	if !errors.As(err, &pe) {
+		return false
+	}
+	c := pe.Field('C')
+	return c == serializationFailure || c == deadlockDetected
+}
+
+// txBackoff returns a random delay before the nth retry of a transaction,
+// between zero and an exponentially increasing bound, so that transactions
+// that failed together don't retry together.
+func txBackoff(n int) time.Duration {
+	d := maxTxBackoff
+	if n < 30 {
+		if b := minTxBackoff << n; b < d {
+			d = b
+		}
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// sleep waits for the duration, d, or until ctx is done, in which case the
+// context's error is returned.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,109 @@
+package pgmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+func TestIsRetryable(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		want bool
+	}{
+		{testPGError{'C': serializationFailure}, true},
+		{fmt.Errorf("wrapped: %w", testPGError{'C': deadlockDetected}), true},
+		{testPGError{'C': "23505"}, false},
+		{errors.New("other"), false},
+		{nil, false},
+	} {
+		if got := isRetryable(c.err); got != c.want {
+			t.Errorf("got %t for %v, want %t", got, c.err, c.want)
+		}
+	}
+}
+
+func TestTxBackoff(t *testing.T) {
+	for n := 1; n < 64; n++ {
+		if d := txBackoff(n); d < 0 || d > maxTxBackoff || n < 6 && d > minTxBackoff<<n {
+			t.Errorf("got backoff %s for retry %d", d, n)
+		}
+	}
+}
+
+func TestSleepReturnsContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+}
+
+func TestRunInTxCancelled(t *testing.T) {
+	// A done context stops the transaction from being attempted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := RunInTx(ctx, unreachableDB(t), func(tx *pg.Tx) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || called {
+		t.Errorf("got error %v and called %t, want context.Canceled without calling", err, called)
+	}
+}
+
+func TestBeginContext(t *testing.T) {
+	db := testDB(t)
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	tx, err := BeginContext(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if got := tx.Context().Value(key{}); got != "request" {
+		t.Errorf("got context value %v, want %q", got, "request")
+	}
+}
+
+func TestRunInTx(t *testing.T) {
+	db := testDB(t)
+
+	// Serialization failures are retried
+	var n int
+	err := RunInTx(context.Background(), db, func(tx *pg.Tx) error {
+		if n++; n < 3 {
+			return testPGError{'C': serializationFailure}
+		}
+		return nil
+	})
+	if err != nil || n != 3 {
+		t.Errorf("got error %v after %d attempts, want nil after 3", err, n)
+	}
+
+	// Other errors roll back without retrying
+	n = 0
+	want := errors.New("failed")
+	if err := RunInTx(context.Background(), db, func(tx *pg.Tx) error {
+		n++
+		return want
+	}); err != want || n != 1 {
+		t.Errorf("got error %v after %d attempts, want %v after 1", err, n, want)
+	}
+
+	// The context's error is returned while waiting to retry
+	ctx, cancel := context.WithCancel(context.Background())
+	err = RunInTx(ctx, db, func(tx *pg.Tx) error {
+		cancel()
+		return testPGError{'C': deadlockDetected}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,97 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// MARK: Exported functions
+
+// GetOne is identical to Get but returns the row as a new model of type T,
+// e.g.
+//
+//	b, _, err := pgmodel.GetOne[*Bar](t, "id", id)
+func GetOne[T KeyedModel](t Executor, queryKey string, queryValue interface{}, opts ...QueryOption) (T, *Result, error) {
+	m := newModel[T]()
+	res, err := Get(m, t, queryKey, queryValue, opts...)
+	if err != nil {
+		var zm T
+		return zm, res, err
+	}
+	return m, res, nil
+}
+
+// GetMany gets the rows matching the given queryKey and queryValue with the
+// given executor and returns them as models of type T, e.g.
+//
+//	bs, _, err := pgmodel.GetMany[*Bar](t, "name", name)
+//
+// An empty queryKey selects rows by the options alone, such as a Condition
+// built with Where.
+func GetMany[T KeyedModel](t Executor, queryKey string, queryValue interface{}, opts ...QueryOption) ([]T, *Result, error) {
+	return GetManyContext[T](context.Background(), t, queryKey, queryValue, opts...)
+}
+
+// GetManyContext is identical to GetMany but performs its queries with the
+// context, ctx, so that they can be cancelled or given a deadline.
+func GetManyContext[T KeyedModel](ctx context.Context, t Executor, queryKey string, queryValue interface{}, opts ...QueryOption) ([]T, *Result, error) {
+	m := newModel[T]()
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	q, a, err := createGetQuery(m, queryKey, queryValue, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ms []T
+	res, err := runContext(o.context(), OperationGetMany, m, t, func() (orm.Result, error) {
+		res, err := o.withSettings(t, func() (orm.Result, error) {
+			res, err := o.query(t, &ms, q, a)
+			return o.checkRows(OperationGetMany, m, t, queryKey, queryValue, res, err)
+		})
+		normalizeTimes(&ms)
+		return res, err
+	})
+	if err == nil {
+		err = afterGet(ctx, &ms, t)
+	}
+	if err != nil {
+		return nil, newResult(res, q), err
+	}
+	return ms, newResult(res, q), nil
+}
+
+// GetManyMap is identical to GetMany but returns the models in a map keyed by
+// their primary key values, which must be of type K.
+func GetManyMap[K comparable, T KeyedModel](t Executor, queryKey string, queryValue interface{}, opts ...QueryOption) (map[K]T, error) {
+	ms, _, err := GetMany[T](t, queryKey, queryValue, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	mm := make(map[K]T, len(ms))
+	for _, u := range ms {
+		k, ok := u.PrimaryKeyValue().(K)
+		if !ok {
+			var zk K
+			return nil, fmt.Errorf("pgmodel: primary key value %v of %T is not of type %T", u.PrimaryKeyValue(), u, zk)
+		}
+		mm[k] = u
+	}
+	return mm, nil
+}
+
+// MARK: Non-exported functions
+
+// newModel returns a new model of type T. If T is a pointer type, the pointer
+// is to a new zero value.
+func newModel[T KeyedModel]() T {
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	if rt.Kind() == reflect.Ptr {
+		return reflect.New(rt.Elem()).Interface().(T)
+	}
+	return reflect.Zero(rt).Interface().(T)
+}
@@ -0,0 +1,57 @@
+package pgmodel
+
+import (
+	"testing"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// rowsExecutor returns a testExecutor answering every query with the testModel
+// rows with the given IDs.
+func rowsExecutor(ids ...int) *testExecutor {
+	return &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		ms := model.(*[]*testModel)
+		for _, id := range ids {
+			*ms = append(*ms, &testModel{ID: id})
+		}
+		return testResult{returned: len(ids)}, nil
+	}}
+}
+
+func TestGetManyMap(t *testing.T) {
+	mm, err := GetManyMap[int, *testModel](rowsExecutor(1, 2), "name", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mm) != 2 || mm[1].ID != 1 || mm[2].ID != 2 {
+		t.Errorf("got map %v", mm)
+	}
+
+	// The key type must match the primary key values
+	if _, err := GetManyMap[string, *testModel](rowsExecutor(1), "name", "a"); err == nil {
+		t.Error("expected an error for a mismatched key type")
+	}
+}
+
+func TestGetOne(t *testing.T) {
+	e := &testExecutor{handle: func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		model.(*testModel).Name = "one"
+		return testResult{returned: 1}, nil
+	}}
+	m, _, err := GetOne[*testModel](e, "id", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.Name != "one" {
+		t.Errorf("got model %+v", m)
+	}
+
+	// The zero value is returned with errors
+	e.handle = func(model interface{}, q string, params []interface{}) (orm.Result, error) {
+		return nil, pg.ErrNoRows
+	}
+	if m, _, err := GetOne[*testModel](e, "id", 1); err == nil || m != nil {
+		t.Errorf("got %+v, %v, want a nil model and an error", m, err)
+	}
+}
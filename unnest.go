@@ -0,0 +1,193 @@
+package pgmodel
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/go-pg/pg/v10/types"
+)
+
+// MARK: Exported functions
+
+// WithUnnest makes SaveAll and SaveAllConcurrent pass each chunk's values as
+// one array per column and expand them with unnest, i.e.
+//
+//	INSERT INTO s.t (id, name, tags)
+//	SELECT u.id, u.name, u.tags::text[]
+//	FROM unnest(?::bigint[], ?::text[], ?::text[]) AS u(id, name, tags)
+//	...
+//
+// instead of listing a row of parameters per model. The statement's text is
+// then the same for every chunk of the table, so the chunk size can be made
+// much larger.
+//
+// Each array has the type of its column, except for array columns, whose
+// values are passed as text and cast to the column's type. The types of the
+// columns are looked up from the catalog once per call.
+func WithUnnest() QueryOption {
+	return queryOptionFunc(func(o *queryOptions) {
+		o.unnest = new(columnTypeCache)
+	})
+}
+
+// MARK: Non-exported functions
+
+// columnTypeCache holds the types of a table's columns once they've been
+// looked up for the first chunk of a batch.
+type columnTypeCache struct {
+
+	// Guards the lookup
+	once sync.Once
+
+	// The column types keyed by column name
+	types map[string]string
+
+	// The lookup's error
+	err error
+}
+
+// get returns the cached column types, looking them up with fn if they
+// haven't been yet.
+func (c *columnTypeCache) get(fn func() (map[string]string, error)) (map[string]string, error) {
+	c.once.Do(func() {
+		c.types, c.err = fn()
+	})
+	return c.types, c.err
+}
+
+// saveChunkUnnest performs a multi-row upsert of the chunk in the given
+// transaction using unnest.
+func saveChunkUnnest(chunk []PGModel, t *pg.Tx, o *queryOptions) (*Result, error) {
+	pm := chunk[0]
+	var q string
+	res, err := runContext(o.context(), OperationSaveAll, pm, t, func() (orm.Result, error) {
+		return o.withSettings(t, func() (orm.Result, error) {
+			ts, err := o.unnest.get(func() (map[string]string, error) {
+				return columnTypes(t, pm, o)
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			q = createUnnestSaveQuery(pm, ts, o)
+			return t.QueryContext(o.context(), pg.Discard, q, unnestValues(chunk, ts)...)
+		})
+	})
+	return newResult(res, q), err
+}
+
+// columnTypes returns the types of the columns of queries on pm's table keyed
+// by column name.
+func columnTypes(t *pg.Tx, pm PGModel, o *queryOptions) (map[string]string, error) {
+	var cts []struct {
+		Name string
+		Type string
+	}
+	_, err := t.QueryContext(o.context(), &cts,
+		`SELECT attname AS name, format_type(atttypid, atttypmod) AS type
+		FROM pg_catalog.pg_attribute
+		WHERE attrelid = ?::regclass AND attnum > 0 AND NOT attisdropped`,
+		o.qualifiedName(pm),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := make(map[string]string, len(cts))
+	for _, ct := range cts {
+		ts[ct.Name] = ct.Type
+	}
+	return ts, nil
+}
+
+// unnestValues returns an array of the chunk's values for each column. Values
+// of array columns, and of columns without a type in ts, are converted to
+// their text representation.
+func unnestValues(chunk []PGModel, ts map[string]string) []interface{} {
+	c := columns(chunk[0])
+	a := make([][]interface{}, len(c))
+	for _, m := range chunk {
+		for i, v := range insertValues(m) {
+			if ct, ok := ts[c[i]]; !ok || isArrayType(ct) {
+				v = textValue(v)
+			}
+			a[i] = append(a[i], v)
+		}
+	}
+
+	tv := make([]interface{}, len(a))
+	for i := range a {
+		tv[i] = pg.Array(a[i])
+	}
+	return tv
+}
+
+// textValue returns the text representation of the value, v, or nil if v is
+// NULL.
+func textValue(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b := types.Append(nil, v, 0)
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
+
+// isArrayType returns whether the column type, ct, is an array type.
+func isArrayType(ct string) bool {
+	return strings.HasSuffix(ct, "]")
+}
+
+// createUnnestSaveQuery creates a multi-row upsert query for pm's table that
+// takes an array of values for each column with the column types, ts. Array
+// columns, and columns without a type, take arrays of text values.
+func createUnnestSaveQuery(pm PGModel, ts map[string]string, o *queryOptions) string {
+	// Get everything once
+	qn := o.qualifiedName(pm)
+	npkc := pm.NonPKColumns()
+	c := columns(pm)
+
+	// Create arrays to join
+	var sl, am, sm []string
+	for _, u := range c {
+		ct, ok := ts[u]
+		switch {
+		case !ok:
+			sl = append(sl, insertExpr(pm, u, "u."+quoteIdent(u)))
+			am = append(am, "?::text[]")
+		case isArrayType(ct):
+			sl = append(sl, insertExpr(pm, u, fmt.Sprintf("u.%s::%s", quoteIdent(u), ct)))
+			am = append(am, "?::text[]")
+		default:
+			sl = append(sl, insertExpr(pm, u, "u."+quoteIdent(u)))
+			am = append(am, fmt.Sprintf("?::%s[]", ct))
+		}
+	}
+	for _, u := range npkc {
+		sm = append(sm, fmt.Sprintf("%s = %s", quoteIdent(u), excludedValue(pm, o.tableName(pm), u)))
+	}
+
+	// Create the query
+	return fmt.Sprintf(
+		`INSERT INTO %s (%s)
+		SELECT %s
+		FROM unnest(%s) AS u(%s)
+		ON CONFLICT (%s)
+		DO UPDATE
+		SET %s
+		%s`,
+		qn,
+		quoteList(c),
+		strings.Join(sl, ", "),
+		strings.Join(am, ", "),
+		quoteList(c),
+		quoteList(primaryKeys(pm)),
+		strings.Join(sm, ", "),
+		o.scopedWhere(pm, ""),
+	)
+}
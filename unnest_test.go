@@ -0,0 +1,105 @@
+package pgmodel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-pg/pg/v10/types"
+)
+
+func TestCreateUnnestSaveQuery(t *testing.T) {
+	ts := map[string]string{"id": "bigint", "name": "text", "tags": "text[]"}
+	q := squash(createUnnestSaveQuery(&testModel{}, ts, newQueryOptions(nil)))
+
+	for _, want := range []string{
+		`SELECT u."id", u."name", u."tags"::text[]`,
+		`FROM unnest(?::bigint[], ?::text[], ?::text[]) AS u("id", "name", "tags")`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("%q doesn't contain %q", q, want)
+		}
+	}
+}
+
+func TestUnnestValues(t *testing.T) {
+	ts := map[string]string{"id": "bigint", "name": "text", "tags": "text[]"}
+	chunk := []PGModel{
+		&testModel{ID: 1, Name: "a", Tags: []string{"x", "y z"}},
+		&testModel{ID: 2, Name: "b"},
+	}
+
+	// Format the arrays as they're quoted in to queries
+	var got []string
+	for _, v := range unnestValues(chunk, ts) {
+		got = append(got, string(types.Append(nil, v, 1)))
+	}
+	want := []string{
+		`'{1,2}'`,
+		`'{"a","b"}'`,
+		`'{"{\"x\",\"y z\"}",NULL}'`,
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("column %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestColumnTypeCache(t *testing.T) {
+	c := new(columnTypeCache)
+	n := 0
+	for i := 0; i < 3; i++ {
+		ts, err := c.get(func() (map[string]string, error) {
+			n++
+			return map[string]string{"id": "bigint"}, nil
+		})
+		if err != nil || ts["id"] != "bigint" {
+			t.Fatalf("got %v, %v", ts, err)
+		}
+	}
+	if n != 1 {
+		t.Errorf("looked up the types %d times, want 1", n)
+	}
+}
+
+func TestIsArrayType(t *testing.T) {
+	for ct, want := range map[string]bool{
+		"text[]":                   true,
+		"integer[][]":              true,
+		"character varying(10)[]":  true,
+		"text":                     false,
+		"timestamp with time zone": false,
+	} {
+		if got := isArrayType(ct); got != want {
+			t.Errorf("%s: got %v, want %v", ct, got, want)
+		}
+	}
+}
+
+func TestSaveAllWithUnnest(t *testing.T) {
+	tx := testTx(t)
+	createModelsTable(t, tx)
+	testExec(t, tx, `INSERT INTO test.models (id, name) VALUES (1, 'old')`)
+
+	pms := []PGModel{
+		&testModel{ID: 1, Name: "one", Tags: []string{"x", "y z"}},
+		&testModel{ID: 2, Name: "two"},
+	}
+	res, err := SaveAll(pms, tx, WithUnnest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RowsAffected() != 2 {
+		t.Errorf("got %d rows affected, want 2", res.RowsAffected())
+	}
+
+	// Existing rows are updated and arrays round trip
+	m := new(testModel)
+	if _, err := Get(m, tx, "id", 1); err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "one" || !reflect.DeepEqual(m.Tags, []string{"x", "y z"}) {
+		t.Errorf("got %+v", m)
+	}
+}
@@ -0,0 +1,82 @@
+package pgmodel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v10/orm"
+)
+
+// MARK: Exported functions
+
+// Update updates only the given columns of pm's existing row with the given
+// executor, e.g.
+//
+//	_, err := pgmodel.Update(b, t, "name", "value")
+//
+// Unlike Save, which writes every column, Update leaves the row's other
+// columns as they are so that it doesn't overwrite concurrent writes to them.
+// It never inserts a row. Every non-primary key column is updated if no
+// columns are given.
+func Update(pm PGModel, t Executor, columns ...string) (*Result, error) {
+	return UpdateContext(context.Background(), pm, t, columns)
+}
+
+// UpdateContext is identical to Update but performs its query with the
+// context, ctx, and the options, opts, e.g. to apply session settings.
+func UpdateContext(ctx context.Context, pm PGModel, t Executor, columns []string, opts ...QueryOption) (*Result, error) {
+	if err := beforeSave(ctx, pm, t); err != nil {
+		return nil, err
+	}
+	o := newQueryOptions(opts)
+	o.ctx = ctx
+	res, err := updateColumns(pm, t, columns, o)
+	if err == nil && res.RowsAffected() > 0 {
+		err = afterSave(ctx, pm, t)
+	}
+	return res, err
+}
+
+// MARK: Non-exported functions
+
+// updateColumns updates the given columns of pm's existing row, applying the
+// settings of the options, o.
+func updateColumns(pm PGModel, t Executor, columns []string, o *queryOptions) (*Result, error) {
+	if err := validateColumns(pm, columns); err != nil {
+		return nil, err
+	}
+	for _, u := range primaryKeys(pm) {
+		for _, c := range columns {
+			if c == u {
+				return nil, fmt.Errorf("pgmodel: Update can't set the primary key column %q", c)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		columns = pm.NonPKColumns()
+	}
+	columns = stampColumns(pm, columns)
+
+	// Get the values of the columns
+	npkc := pm.NonPKColumns()
+	npkv := stampValues(pm, convertVariables(pm))
+	vm := make(map[string]interface{}, len(npkc))
+	for i, u := range npkc {
+		vm[u] = npkv[i]
+	}
+	var tv []interface{}
+	for _, c := range columns {
+		tv = append(tv, vm[c])
+	}
+	tv = append(tv, primaryKeyValues(pm)...)
+
+	// Perform the query
+	defer InvalidateCache(pm)
+	q := createUpdateQuery(pm, columns, keyPredicate(pm, o.tableName(pm)), o)
+	res, err := runContext(o.context(), OperationSave, pm, t, func() (orm.Result, error) {
+		return o.withSettings(t, func() (orm.Result, error) {
+			return t.QueryContext(o.context(), pm, q, tv...)
+		})
+	})
+	return newResult(res, q), err
+}
@@ -0,0 +1,51 @@
+package pgmodel
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Update(&testModel{ID: 1, Name: "one", Tags: []string{"a"}}, e, "name"); err != nil {
+		t.Fatal(err)
+	}
+	q := squash(e.last().query)
+	if !strings.HasPrefix(q, `UPDATE "test"."models" SET "name" = ?`) || strings.Contains(q, `"tags"`) {
+		t.Errorf("got query %q, want only the name to be set", q)
+	}
+	if !strings.Contains(q, `WHERE "models"."id" = ?`) {
+		t.Errorf("got query %q", q)
+	}
+	if p := e.last().params; !reflect.DeepEqual(p, []interface{}{"one", 1}) {
+		t.Errorf("got parameters %v", p)
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Update(&testModel{ID: 1}, e, "id"); err == nil {
+		t.Error("expected an error setting the primary key")
+	}
+	if _, err := Update(&testModel{ID: 1}, e, "missing"); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+	if n := e.count(); n != 0 {
+		t.Errorf("got %d queries, want none", n)
+	}
+}
+
+func TestUpdateContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	e := new(testExecutor)
+
+	if _, err := UpdateContext(ctx, &testModel{ID: 1, Name: "one"}, e, []string{"name"}, WithSchema("other")); err != nil {
+		t.Fatal(err)
+	}
+	if q := e.last(); q.ctx.Value(key{}) != "request" || !strings.HasPrefix(squash(q.query), `UPDATE "other"."models" SET "name" = ?`) {
+		t.Errorf("got query %q performed with the wrong options or context", squash(q.query))
+	}
+}
@@ -0,0 +1,121 @@
+package pgmodel
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ValidationError is returned by Validate when a model has problems.
+type ValidationError struct {
+
+	// Descriptions of each problem.
+	Problems []string
+}
+
+// Error returns the problems joined in to a single message.
+func (e *ValidationError) Error() string {
+	return "pgmodel: invalid model: " + strings.Join(e.Problems, "; ")
+}
+
+// checked holds the result of checking each model type.
+var checked sync.Map
+
+// MARK: Exported functions
+
+// Validate checks that pm's methods are consistent with each other and return
+// non-empty names, and returns every problem found in a
+// *ValidationError. It's intended to be called from tests so that model bugs
+// are caught before they reach production.
+func Validate(pm PGModel) error {
+	var problems []string
+
+	// Check the names
+	type name struct {
+		kind string
+		name string
+	}
+	names := []name{
+		{"table name", pm.TableName()},
+	}
+	for _, pk := range primaryKeys(pm) {
+		names = append(names, name{"primary key", pk})
+	}
+	if sn := pm.SchemaName(); sn != "" {
+		names = append(names, name{"schema name", sn})
+	}
+	for _, n := range names {
+		if n.name == "" {
+			problems = append(problems, fmt.Sprintf("%s is empty", n.kind))
+		}
+	}
+
+	// Check the columns
+	pks := primaryKeys(pm)
+	npkc := pm.NonPKColumns()
+	ispk := make(map[string]bool, len(pks))
+	for _, pk := range pks {
+		ispk[pk] = true
+	}
+	seen := make(map[string]bool, len(npkc))
+	for _, c := range npkc {
+		switch {
+		case ispk[c]:
+			problems = append(problems, fmt.Sprintf("primary key %s is listed in NonPKColumns", c))
+		case seen[c]:
+			problems = append(problems, fmt.Sprintf("column %s is listed more than once", c))
+		case c == "":
+			problems = append(problems, "a column name is empty")
+		}
+		seen[c] = true
+	}
+
+	// Check the counts
+	if nv := len(pm.NonPKValues()); nv != len(npkc) {
+		problems = append(problems, fmt.Sprintf("%d non-primary key columns but %d non-primary key values", len(npkc), nv))
+	}
+	if cm, ok := pm.(CompositeKeyModel); ok {
+		if kv := len(cm.PrimaryKeyValues()); kv != len(pks) {
+			problems = append(problems, fmt.Sprintf("%d primary key columns but %d primary key values", len(pks), kv))
+		}
+	}
+	if cc := pm.ColumnCount(); cc != len(npkc)+len(pks) {
+		problems = append(problems, fmt.Sprintf("ColumnCount returns %d but there are %d columns", cc, len(npkc)+len(pks)))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// MARK: Non-exported functions
+
+// checkModel returns an error if the methods of pm's model type are
+// inconsistent in a way that would corrupt its queries. Each type is only
+// checked the first time it's used. Validate performs a stricter check.
+func checkModel(pm TableDescriber) error {
+	m, ok := pm.(PGModel)
+	if !ok {
+		return nil
+	}
+
+	rt := reflect.TypeOf(m)
+	if err, ok := checked.Load(rt); ok {
+		err, _ := err.(error)
+		return err
+	}
+
+	var err error
+	if nc, nv := len(m.NonPKColumns()), len(m.NonPKValues()); nc != nv {
+		err = fmt.Errorf("pgmodel: %T has %d non-primary key columns but %d non-primary key values", m, nc, nv)
+	}
+	if cm, ok := m.(CompositeKeyModel); ok {
+		if kc, kv := len(cm.PrimaryKeys()), len(cm.PrimaryKeyValues()); kc != kv {
+			err = fmt.Errorf("pgmodel: %T has %d primary key columns but %d primary key values", m, kc, kv)
+		}
+	}
+	checked.Store(rt, err)
+	return err
+}
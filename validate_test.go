@@ -0,0 +1,60 @@
+package pgmodel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// brokenModel is a hand-written model of the test.broken table whose methods
+// disagree with each other.
+type brokenModel struct {
+	ID int
+}
+
+func (m *brokenModel) SchemaName() string           { return "test" }
+func (m *brokenModel) TableName() string            { return "broken" }
+func (m *brokenModel) PrimaryKey() string           { return "id" }
+func (m *brokenModel) PrimaryKeyValue() interface{} { return m.ID }
+func (m *brokenModel) ColumnCount() int             { return 2 }
+func (m *brokenModel) NonPKColumns() []string       { return []string{"name", "id", "name"} }
+func (m *brokenModel) NonPKValues() []interface{}   { return []interface{}{"a"} }
+
+func TestCheckModelAtFirstUse(t *testing.T) {
+	e := new(testExecutor)
+	if _, err := Save(&brokenModel{ID: 1}, e); err == nil || !strings.Contains(err.Error(), "3 non-primary key columns but 1 non-primary key values") {
+		t.Errorf("got error %v, want a column count error", err)
+	}
+	if n := e.count(); n != 0 {
+		t.Errorf("got %d queries, want none", n)
+	}
+
+	// Consistent models are checked once and pass
+	if err := checkModel(&testModel{}); err != nil {
+		t.Error(err)
+	}
+	if _, ok := checked.Load(reflect.TypeOf(&testModel{})); !ok {
+		t.Error("the model's check wasn't cached")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	err := Validate(&brokenModel{})
+	ve, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("got error %v, want a *ValidationError", err)
+	}
+	want := []string{
+		"primary key id is listed in NonPKColumns",
+		"column name is listed more than once",
+		"3 non-primary key columns but 1 non-primary key values",
+		"ColumnCount returns 2 but there are 4 columns",
+	}
+	if !reflect.DeepEqual(ve.Problems, want) {
+		t.Errorf("got problems %q, want %q", ve.Problems, want)
+	}
+
+	if err := Validate(&testModel{}); err != nil {
+		t.Errorf("got error %v for a valid model", err)
+	}
+}
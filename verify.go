@@ -0,0 +1,208 @@
+package pgmodel
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+)
+
+// ColumnMismatch describes a column whose Postgres type or nullability doesn't
+// safely map to the Go type of the model's value.
+type ColumnMismatch struct {
+
+	// The column's name.
+	Column string
+
+	// The Go type of the model's value for the column.
+	GoType string
+
+	// The Postgres type of the column, or an empty string if the column does not
+	// exist.
+	ColumnType string
+
+	// A description of the mismatch.
+	Problem string
+}
+
+// String returns a description of the mismatch.
+func (m ColumnMismatch) String() string {
+	return fmt.Sprintf("%s (%s -> %s): %s", m.Column, m.GoType, m.ColumnType, m.Problem)
+}
+
+// columnInfo holds a row from information_schema.columns.
+type columnInfo struct {
+	ColumnName      string
+	DataType        string
+	IsNullable      string
+	CharacterLength *int `pg:"character_maximum_length"`
+}
+
+// intBits maps integer column types to their sizes.
+var intBits = map[string]int{
+	"smallint": 16,
+	"integer":  32,
+	"bigint":   64,
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// MARK: Exported functions
+
+// CheckColumnTypes compares the Go types of pm's values against the types and
+// nullability of its table's columns and returns every mapping that may lose
+// data, such as an int32 value stored in a bigint column, a string stored in a
+// varchar(10) column, or a nullable column read in to a non-pointer value.
+//
+// Go types are taken from the values returned by PrimaryKeyValue and
+// NonPKValues, so nil interface values can't be checked and are skipped.
+func CheckColumnTypes(ctx context.Context, db *pg.DB, pm PGModel) ([]ColumnMismatch, error) {
+	var cis []columnInfo
+	_, err := db.QueryContext(ctx, &cis,
+		`SELECT column_name, data_type, is_nullable, character_maximum_length
+		FROM information_schema.columns
+		WHERE table_schema = ? AND table_name = ?`,
+		pm.SchemaName(),
+		pm.TableName(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := make(map[string]columnInfo, len(cis))
+	for _, ci := range cis {
+		cm[ci.ColumnName] = ci
+	}
+
+	// Create total column/value slices
+	c := append([]string{pm.PrimaryKey()}, pm.NonPKColumns()...)
+	v := append([]interface{}{pm.PrimaryKeyValue()}, pm.NonPKValues()...)
+
+	var ms []ColumnMismatch
+	for i, cn := range c {
+		if i >= len(v) || v[i] == nil {
+			continue
+		}
+
+		rt := reflect.TypeOf(v[i])
+		ci, ok := cm[cn]
+		if !ok {
+			ms = append(ms, ColumnMismatch{
+				Column:  cn,
+				GoType:  rt.String(),
+				Problem: "column does not exist",
+			})
+			continue
+		}
+
+		for _, p := range columnProblems(rt, ci) {
+			ms = append(ms, ColumnMismatch{
+				Column:     cn,
+				GoType:     rt.String(),
+				ColumnType: columnType(ci),
+				Problem:    p,
+			})
+		}
+	}
+
+	return ms, nil
+}
+
+// MARK: Non-exported functions
+
+// columnType returns a readable description of the column's type.
+func columnType(ci columnInfo) string {
+	if ci.CharacterLength != nil {
+		return fmt.Sprintf("%s(%d)", ci.DataType, *ci.CharacterLength)
+	}
+	return ci.DataType
+}
+
+// columnProblems returns the problems with mapping the Go type, rt, to the
+// column, ci.
+func columnProblems(rt reflect.Type, ci columnInfo) []string {
+	var ps []string
+
+	// Check nullability. NULL is scanned in to slices and maps as nil.
+	var nullable bool
+	switch rt.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		nullable = true
+	default:
+		nullable = rt.Implements(valuerType)
+	}
+	if ci.IsNullable == "YES" && !nullable {
+		ps = append(ps, "column is nullable but the Go type can't hold NULL")
+	}
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	switch {
+	case rt == timeType:
+		switch ci.DataType {
+		case "timestamp with time zone", "timestamp without time zone":
+		case "date":
+			ps = append(ps, "date columns drop the time of day")
+		default:
+			ps = append(ps, "column is not a timestamp")
+		}
+		return ps
+	case rt.Implements(valuerType):
+		// Custom types are responsible for their own mapping
+		return ps
+	}
+
+	switch rt.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		bits, ok := intBits[ci.DataType]
+		gb := rt.Bits()
+		if rt.Kind() >= reflect.Uint && rt.Kind() <= reflect.Uint64 {
+			// Unsigned values need an extra bit in a signed column
+			gb++
+		}
+		switch {
+		case !ok:
+			ps = append(ps, "column is not an integer type")
+		case gb > bits:
+			ps = append(ps, fmt.Sprintf("%d-bit column can't hold every Go value", bits))
+		case gb < bits:
+			ps = append(ps, fmt.Sprintf("Go type can't hold every %d-bit column value", bits))
+		}
+	case reflect.Float32:
+		if ci.DataType != "real" {
+			ps = append(ps, "float32 values should be stored in real columns")
+		}
+	case reflect.Float64:
+		if ci.DataType != "double precision" && ci.DataType != "real" {
+			ps = append(ps, "float64 values should be stored in double precision columns")
+		} else if ci.DataType == "real" {
+			ps = append(ps, "real columns can't hold every float64 value")
+		}
+	case reflect.String:
+		if ci.CharacterLength != nil {
+			ps = append(ps, fmt.Sprintf("column is limited to %d characters", *ci.CharacterLength))
+		}
+	case reflect.Bool:
+		if ci.DataType != "boolean" {
+			ps = append(ps, "column is not a boolean")
+		}
+	case reflect.Slice:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			if ci.DataType != "bytea" {
+				ps = append(ps, "byte slices should be stored in bytea columns")
+			}
+		} else if ci.DataType != "ARRAY" && ci.DataType != "json" && ci.DataType != "jsonb" {
+			ps = append(ps, "column is not an array or JSON type")
+		}
+	}
+
+	return ps
+}
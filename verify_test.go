@@ -0,0 +1,45 @@
+package pgmodel
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestColumnProblems(t *testing.T) {
+	ten := 10
+	for _, c := range []struct {
+		name string
+		v    interface{}
+		ci   columnInfo
+		want int
+	}{
+		{"int64 in bigint", int64(1), columnInfo{DataType: "bigint", IsNullable: "NO"}, 0},
+		{"int32 in bigint", int32(1), columnInfo{DataType: "bigint", IsNullable: "NO"}, 1},
+		{"int64 in integer", int64(1), columnInfo{DataType: "integer", IsNullable: "NO"}, 1},
+		{"uint32 in integer", uint32(1), columnInfo{DataType: "integer", IsNullable: "NO"}, 1},
+		{"string in text", "a", columnInfo{DataType: "text", IsNullable: "NO"}, 0},
+		{"string in varchar", "a", columnInfo{DataType: "character varying", IsNullable: "NO", CharacterLength: &ten}, 1},
+		{"string in nullable text", "a", columnInfo{DataType: "text", IsNullable: "YES"}, 1},
+		{"string pointer in nullable text", new(string), columnInfo{DataType: "text", IsNullable: "YES"}, 0},
+		{"time in timestamptz", time.Time{}, columnInfo{DataType: "timestamp with time zone", IsNullable: "NO"}, 0},
+		{"time in date", time.Time{}, columnInfo{DataType: "date", IsNullable: "NO"}, 1},
+		{"bytes in bytea", []byte{}, columnInfo{DataType: "bytea", IsNullable: "NO"}, 0},
+		{"bytes in text", []byte{}, columnInfo{DataType: "text", IsNullable: "NO"}, 1},
+		{"bytes in nullable bytea", []byte{}, columnInfo{DataType: "bytea", IsNullable: "YES"}, 0},
+		{"strings in nullable array", []string{}, columnInfo{DataType: "ARRAY", IsNullable: "YES"}, 0},
+		{"map in nullable jsonb", map[string]interface{}{}, columnInfo{DataType: "jsonb", IsNullable: "YES"}, 0},
+		{"float64 in real", 1.0, columnInfo{DataType: "real", IsNullable: "NO"}, 1},
+	} {
+		if ps := columnProblems(reflect.TypeOf(c.v), c.ci); len(ps) != c.want {
+			t.Errorf("%s: got problems %v, want %d", c.name, ps, c.want)
+		}
+	}
+}
+
+func TestColumnMismatchString(t *testing.T) {
+	m := ColumnMismatch{Column: "name", GoType: "string", ColumnType: "character varying(10)", Problem: "column is limited to 10 characters"}
+	if got := m.String(); got != "name (string -> character varying(10)): column is limited to 10 characters" {
+		t.Errorf("got %q", got)
+	}
+}
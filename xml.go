@@ -0,0 +1,77 @@
+package pgmodel
+
+import (
+	"database/sql/driver"
+	"encoding/xml"
+	"fmt"
+)
+
+// XML is a value of type T stored in an xml column as the document encoded by
+// encoding/xml, e.g.
+//
+//	type Invoice struct {
+//		pgmodel.Base[Invoice] `pgmodel:"billing.invoices"`
+//		ID                    int                     `pg:"id,pk"`
+//		Payload               pgmodel.XML[InvoiceDoc] `pg:"payload,type:xml"`
+//	}
+//
+// Columns whose documents don't need to be decoded can be mapped to string
+// fields instead.
+type XML[T any] struct {
+	V T
+}
+
+// MARK: Exported functions
+
+// Value returns x's value encoded as an XML document.
+func (x XML[T]) Value() (driver.Value, error) {
+	b, err := xml.Marshal(x.V)
+	if err != nil {
+		return nil, fmt.Errorf("pgmodel: can't encode %T as XML: %w", x.V, err)
+	}
+	return string(b), nil
+}
+
+// Scan sets x's value to the XML document, src.
+func (x *XML[T]) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*x = XML[T]{}
+		return nil
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("pgmodel: can't scan %T in to XML", src)
+	}
+
+	var v T
+	if err := xml.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("pgmodel: can't decode XML in to %T: %w", v, err)
+	}
+	x.V = v
+	return nil
+}
+
+// XPathExists returns a condition matching the rows whose xml column has a
+// node matching the XPath expression, path, such as "/invoice/discount".
+func XPathExists(column string, path string) *Condition {
+	return columnCondition(column, func(col string) string {
+		return fmt.Sprintf("xpath_exists(?, %s)", col)
+	}, path)
+}
+
+// XPathEquals returns a condition matching the rows whose xml column's first
+// node matching the XPath expression, path, has the text, value, e.g.
+//
+//	pgmodel.XPathEquals("payload", "/invoice/status/text()", "paid")
+//
+// Paths should select text or attribute nodes, as the text of element nodes
+// includes their tags.
+func XPathEquals(column string, path string, value string) *Condition {
+	return columnCondition(column, func(col string) string {
+		return fmt.Sprintf("(xpath(?, %s))[1]::text = ?", col)
+	}, path, value)
+}
@@ -0,0 +1,54 @@
+package pgmodel
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+// invoiceDoc is an XML document stored in an xml column.
+type invoiceDoc struct {
+	XMLName xml.Name `xml:"invoice"`
+	Status  string   `xml:"status"`
+	Total   int      `xml:"total,attr"`
+}
+
+func TestXMLValueAndScan(t *testing.T) {
+	v, err := XML[invoiceDoc]{V: invoiceDoc{Status: "paid", Total: 12}}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<invoice total="12"><status>paid</status></invoice>`; v != want {
+		t.Errorf("got value %v, want %s", v, want)
+	}
+
+	var x XML[invoiceDoc]
+	if err := x.Scan([]byte(v.(string))); err != nil {
+		t.Fatal(err)
+	}
+	if x.V.Status != "paid" || x.V.Total != 12 {
+		t.Errorf("got document %+v", x.V)
+	}
+	if err := x.Scan(nil); err != nil || x.V.Status != "" {
+		t.Errorf("got document %+v, %v", x.V, err)
+	}
+	for _, src := range []interface{}{"<invoice>", 1} {
+		if err := x.Scan(src); err == nil {
+			t.Errorf("expected an error for %v", src)
+		}
+	}
+}
+
+func TestXPathConditions(t *testing.T) {
+	p, a, err := XPathExists("payload", "/invoice/discount").
+		AndGroup(XPathEquals("payload", "/invoice/status/text()", "paid")).
+		predicate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `((xpath_exists(?, "payload")) AND ((xpath(?, "payload"))[1]::text = ?))`; p != want {
+		t.Errorf("got predicate %q, want %q", p, want)
+	}
+	if len(a) != 3 || a[0] != "/invoice/discount" || a[2] != "paid" {
+		t.Errorf("got parameters %v", a)
+	}
+}